@@ -10,10 +10,13 @@ import (
 	"time"
 
 	"github.com/raufhm/fairflow/shared/config"
+	"github.com/raufhm/fairflow/shared/crypto"
 	"github.com/raufhm/fairflow/shared/database"
 	"github.com/raufhm/fairflow/shared/health"
+	"github.com/raufhm/fairflow/shared/httpclient"
 	"github.com/raufhm/fairflow/shared/logger"
 	"github.com/raufhm/fairflow/shared/middleware"
+	"github.com/raufhm/fairflow/shared/repository/postgres"
 	"go.uber.org/zap"
 )
 
@@ -38,6 +41,24 @@ func main() {
 
 	logger.Log.Info("Database connected successfully")
 
+	// Calls to other services are signed with this service's identity
+	serviceTokens, err := crypto.LoadServiceTokenService(crypto.AnalyticsService, cfg.ServicePrivateKey, cfg.ServicePublicKeys, cfg.ServiceDevSecret, cfg.ServiceTokenTTL)
+	if err != nil {
+		logger.Log.Fatal("Failed to load service keys", zap.Error(err))
+	}
+	authClient := httpclient.NewServiceClient(cfg.AuthServiceURL, crypto.AuthService).WithServiceAuth(serviceTokens)
+
+	// Initialize authentication middleware, verifying tokens against the auth service's JWKS
+	jwksCache := httpclient.NewJWKSCache(authClient, cfg.JWKSCacheTTL)
+	authenticator := middleware.NewAuthenticator(
+		postgres.NewUserRepository(db),
+		postgres.NewAPIKeyRepository(db),
+		postgres.NewSessionRepository(db),
+		postgres.NewAuditLogRepository(db),
+		crypto.NewVerifyingTokenService(jwksCache),
+		cfg.JWTSecret,
+	)
+
 	// Setup HTTP router
 	mux := http.NewServeMux()
 
@@ -47,8 +68,14 @@ func main() {
 
 	// TODO: Add analytics endpoints
 
+	// Client addresses come from X-Forwarded-For only behind trusted proxies
+	trustedProxies, err := middleware.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		logger.Log.Fatal("Invalid TRUSTED_PROXIES", zap.Error(err))
+	}
+
 	// Apply middleware
-	handlerWithMiddleware := middleware.CORS(mux)
+	handlerWithMiddleware := middleware.CORS(middleware.ResolveClientIP(trustedProxies, authenticator.OptionalAuth(mux)))
 
 	// Start HTTP server
 	port := 3007
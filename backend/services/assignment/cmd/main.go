@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -9,15 +11,20 @@ import (
 	"strings"
 	"syscall"
 	"time"
+	_ "time/tzdata" // Working hours need IANA zones; the runtime image has none
 
 	"github.com/raufhm/fairflow/services/assignment/internal/handler"
 	"github.com/raufhm/fairflow/services/assignment/internal/usecase"
 	"github.com/raufhm/fairflow/shared/config"
+	"github.com/raufhm/fairflow/shared/crypto"
 	"github.com/raufhm/fairflow/shared/database"
+	"github.com/raufhm/fairflow/shared/domain"
 	"github.com/raufhm/fairflow/shared/health"
+	"github.com/raufhm/fairflow/shared/httpclient"
 	"github.com/raufhm/fairflow/shared/logger"
 	"github.com/raufhm/fairflow/shared/middleware"
 	"github.com/raufhm/fairflow/shared/repository/postgres"
+	"github.com/raufhm/fairflow/shared/strategy"
 	"go.uber.org/zap"
 )
 
@@ -46,13 +53,40 @@ func main() {
 	groupRepo := postgres.NewGroupRepository(db)
 	memberRepo := postgres.NewMemberRepository(db)
 	assignmentRepo := postgres.NewAssignmentRepository(db)
+	userRepo := postgres.NewUserRepository(db)
+	apiKeyRepo := postgres.NewAPIKeyRepository(db)
+	sessionRepo := postgres.NewSessionRepository(db)
+	auditRepo := postgres.NewAuditLogRepository(db)
+	grantRepo := postgres.NewGroupGrantRepository(db)
+	cursorRepo := postgres.NewRotationCursorRepository(db)
+	queueRepo := postgres.NewAssignmentQueueRepository(db)
+	timeOffRepo := postgres.NewTimeOffRepository(db)
+	idempotencyRepo := postgres.NewIdempotencyRepository(db)
+	eventRepo := postgres.NewAssignmentEventRepository(db)
+	transactor := postgres.NewTransactor(db)
 
 	// Initialize use case
-	assignmentUseCase := usecase.NewAssignmentUseCase(groupRepo, memberRepo, assignmentRepo)
+	assignmentUseCase := usecase.NewAssignmentUseCase(groupRepo, memberRepo, assignmentRepo, cursorRepo, queueRepo, timeOffRepo, idempotencyRepo, eventRepo, transactor, strategy.DefaultRegistry(), cfg.IdempotencyKeyTTL)
+	idempotencySweeper := usecase.NewIdempotencySweeper(idempotencyRepo)
+	acceptanceSweeper := usecase.NewAcceptanceSweeper(assignmentUseCase)
+	queueDispatcher := usecase.NewQueueDispatcher(assignmentUseCase, queueRepo)
 
 	// Initialize handler
 	assignmentHandler := handler.NewAssignmentHandler(assignmentUseCase)
 
+	// Calls to other services are signed with this service's identity
+	serviceTokens, err := crypto.LoadServiceTokenService(crypto.AssignmentService, cfg.ServicePrivateKey, cfg.ServicePublicKeys, cfg.ServiceDevSecret, cfg.ServiceTokenTTL)
+	if err != nil {
+		logger.Log.Fatal("Failed to load service keys", zap.Error(err))
+	}
+	authClient := httpclient.NewServiceClient(cfg.AuthServiceURL, crypto.AuthService).WithServiceAuth(serviceTokens)
+
+	// Initialize authentication middleware, verifying tokens against the auth service's JWKS
+	jwksCache := httpclient.NewJWKSCache(authClient, cfg.JWKSCacheTTL)
+	tokenService := crypto.NewVerifyingTokenService(jwksCache)
+	authenticator := middleware.NewAuthenticator(userRepo, apiKeyRepo, sessionRepo, auditRepo, tokenService, cfg.JWTSecret)
+	groupAuthorizer := middleware.NewGroupAuthorizer(groupRepo, grantRepo)
+
 	// Setup HTTP router
 	mux := http.NewServeMux()
 
@@ -60,10 +94,53 @@ func main() {
 	healthChecker := health.NewHealthChecker(db)
 	mux.HandleFunc("/health", healthChecker.Handler("assignment-service", "1.0.0"))
 
+	// API key restrictions
+	assignmentPolicy := middleware.APIKeyPolicy{
+		ReadScope:  domain.ScopeAssignmentsRead,
+		WriteScope: domain.ScopeAssignmentsWrite,
+		GroupID:    middleware.GroupIDFromPath("/api/v1/groups/"),
+	}
+	assignmentByIDPolicy := middleware.APIKeyPolicy{
+		ReadScope:  domain.ScopeAssignmentsRead,
+		WriteScope: domain.ScopeAssignmentsWrite,
+		GroupID: func(r *http.Request) (int64, error) {
+			assignmentID := middleware.PathID(r, "/api/v1/assignments/")
+			if assignmentID == 0 {
+				return 0, nil
+			}
+			assignment, err := assignmentRepo.GetByID(r.Context(), assignmentID)
+			if errors.Is(err, sql.ErrNoRows) {
+				return 0, nil
+			}
+			if err != nil {
+				return 0, err
+			}
+			return assignment.GroupID, nil
+		},
+	}
+
+	// Group roles needed on each route
+	assignmentAccess := middleware.GroupAccessPolicy{
+		Read:    domain.GroupRoleViewer,
+		Write:   domain.GroupRoleDispatcher,
+		GroupID: assignmentPolicy.GroupID,
+	}
+	assignmentByIDAccess := middleware.GroupAccessPolicy{
+		Read:    domain.GroupRoleViewer,
+		Write:   domain.GroupRoleDispatcher,
+		GroupID: assignmentByIDPolicy.GroupID,
+	}
+
 	// Assignment endpoints
-	mux.HandleFunc("/api/v1/groups/", func(w http.ResponseWriter, r *http.Request) {
+	mux.Handle("/api/v1/groups/", authenticator.OptionalAuth(middleware.RequireVerifiedEmail(middleware.EnforceAPIKeyPolicy(assignmentPolicy, groupAuthorizer.Enforce(assignmentAccess, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if strings.HasSuffix(r.URL.Path, "/next") {
 			assignmentHandler.GetNextAssignee(w, r)
+		} else if strings.HasSuffix(r.URL.Path, "/assign/batch") {
+			if r.Method == http.MethodPost {
+				assignmentHandler.RecordAssignments(w, r)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
 		} else if strings.HasSuffix(r.URL.Path, "/assign") {
 			if r.Method == http.MethodPost {
 				assignmentHandler.RecordAssignment(w, r)
@@ -81,10 +158,48 @@ func main() {
 		} else {
 			http.Error(w, "Not found", http.StatusNotFound)
 		}
-	})
+	}))))))
+
+	mux.Handle("/api/v1/assignments/", authenticator.OptionalAuth(middleware.RequireVerifiedEmail(middleware.EnforceAPIKeyPolicy(assignmentByIDPolicy, groupAuthorizer.Enforce(assignmentByIDAccess, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/accept") {
+			if r.Method == http.MethodPost {
+				assignmentHandler.AcceptAssignment(w, r)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/decline") {
+			if r.Method == http.MethodPost {
+				assignmentHandler.DeclineAssignment(w, r)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/reassign") {
+			if r.Method == http.MethodPost {
+				assignmentHandler.ReassignAssignment(w, r)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/events") {
+			if r.Method == http.MethodGet {
+				assignmentHandler.GetAssignmentHistory(w, r)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if r.Method == http.MethodPatch {
+			assignmentHandler.UpdateAssignment(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))))))
+
+	// Client addresses come from X-Forwarded-For only behind trusted proxies
+	trustedProxies, err := middleware.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		logger.Log.Fatal("Invalid TRUSTED_PROXIES", zap.Error(err))
+	}
 
 	// Apply middleware
-	handlerWithMiddleware := middleware.CORS(mux)
+	handlerWithMiddleware := middleware.CORS(middleware.ResolveClientIP(trustedProxies, mux))
 
 	// Start HTTP server
 	port := 3004
@@ -98,6 +213,13 @@ func main() {
 		Handler: handlerWithMiddleware,
 	}
 
+	// Assign queued work as members come on shift, move on work nobody accepted
+	// and forget expired idempotency keys
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	go queueDispatcher.Run(workersCtx, time.Minute)
+	go acceptanceSweeper.Run(workersCtx, time.Minute)
+	go idempotencySweeper.Run(workersCtx, time.Hour)
+
 	go func() {
 		logger.Log.Info("Assignment Service is running on " + addr)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -111,6 +233,7 @@ func main() {
 	<-quit
 
 	logger.Log.Info("Shutting down Assignment Service...")
+	stopWorkers()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
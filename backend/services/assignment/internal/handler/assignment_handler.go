@@ -2,12 +2,15 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/raufhm/fairflow/services/assignment/internal/usecase"
+	"github.com/raufhm/fairflow/shared/domain"
 	"github.com/raufhm/fairflow/shared/middleware"
 )
 
@@ -22,11 +25,40 @@ func NewAssignmentHandler(assignmentUseCase *usecase.AssignmentUseCase) *Assignm
 }
 
 type RecordAssignmentRequest struct {
-	MemberID *int64  `json:"memberId"`
-	Metadata *string `json:"metadata"`
+	MemberID    *int64  `json:"memberId"`
+	Metadata    *string `json:"metadata"`
+	ExternalRef *string `json:"external_ref"` // e.g. a ticket ID; unique per group
 }
 
-// GetNextAssignee calculates the next assignee using weighted round-robin
+type BatchAssignmentRequest struct {
+	Items []RecordAssignmentRequest `json:"items"`
+	Mode  string                    `json:"mode"` // all_or_nothing (default) or partial
+}
+
+// Batch modes
+const (
+	batchModeAllOrNothing = "all_or_nothing"
+	batchModePartial      = "partial"
+)
+
+type UpdateAssignmentRequest struct {
+	Status domain.AssignmentStatus `json:"status"`
+	Reason *string                 `json:"reason"`
+}
+
+type DeclineAssignmentRequest struct {
+	Reason *string `json:"reason"`
+}
+
+type ReassignAssignmentRequest struct {
+	MemberID *int64  `json:"memberId"` // Omit to pick the next fair assignee
+	Reason   *string `json:"reason"`
+}
+
+// idempotencyKeyHeader lets clients retry POST /assign without assigning twice
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// GetNextAssignee calculates the next assignee using the group's strategy
 func (h *AssignmentHandler) GetNextAssignee(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	groupID := getIDFromPath(r, "/api/v1/groups/", "/next")
@@ -47,7 +79,8 @@ func (h *AssignmentHandler) GetNextAssignee(w http.ResponseWriter, r *http.Reque
 	})
 }
 
-// RecordAssignment creates a new assignment record
+// RecordAssignment creates a new assignment record. Retries carrying the same
+// Idempotency-Key header or external_ref get the original response back.
 func (h *AssignmentHandler) RecordAssignment(w http.ResponseWriter, r *http.Request) {
 	user := middleware.GetUserFromContext(r.Context())
 	if user == nil {
@@ -68,19 +101,302 @@ func (h *AssignmentHandler) RecordAssignment(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	member, assignmentID, err := h.assignmentUseCase.RecordAssignment(ctx, groupID, user.ID, user.Name, req.MemberID, req.Metadata)
+	idempotencyKey := r.Header.Get(idempotencyKeyHeader)
+	result, err := h.assignmentUseCase.RecordAssignment(ctx, groupID, user.ID, user.Name, req.MemberID, req.Metadata, req.ExternalRef, idempotencyKey)
 	if err != nil {
 		respondJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
 		return
 	}
 
+	if result.Replayed {
+		w.Header().Set("Idempotent-Replayed", "true")
+	}
+
+	if result.Queued() {
+		respondJSON(w, http.StatusAccepted, map[string]interface{}{
+			"message":   "No members can take work right now; the assignment has been queued",
+			"queueId":   result.QueuedID,
+			"replayed":  result.Replayed,
+			"timestamp": time.Now().UTC().Format(time.RFC3339),
+		})
+		return
+	}
+
 	respondJSON(w, http.StatusCreated, map[string]interface{}{
-		"assignmentId": assignmentID,
-		"member":       member,
+		"assignmentId": result.AssignmentID,
+		"groupId":      result.GroupID,
+		"member":       result.Member,
+		"replayed":     result.Replayed,
 		"timestamp":    time.Now().UTC().Format(time.RFC3339),
 	})
 }
 
+// UpdateAssignment moves an assignment between open, completed and cancelled
+func (h *AssignmentHandler) UpdateAssignment(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	ctx := r.Context()
+	assignmentID := getIDFromPath(r, "/api/v1/assignments/")
+	if assignmentID == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid assignment ID"})
+		return
+	}
+
+	var req UpdateAssignmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid request body"})
+		return
+	}
+
+	assignment, err := h.assignmentUseCase.TransitionAssignment(ctx, assignmentID, user.ID, req.Status, req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrAssignmentNotFound):
+			respondJSON(w, http.StatusNotFound, map[string]string{"message": err.Error()})
+		case errors.Is(err, usecase.ErrInvalidStatus):
+			respondJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+		case errors.Is(err, usecase.ErrIllegalTransition):
+			respondJSON(w, http.StatusConflict, map[string]string{"message": err.Error()})
+		default:
+			respondJSON(w, http.StatusInternalServerError, map[string]string{"message": "Failed to update assignment"})
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, assignment)
+}
+
+// AcceptAssignment confirms an assignment waiting for acceptance. The caller
+// must be the assigned member or a manager of the group.
+func (h *AssignmentHandler) AcceptAssignment(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	ctx := r.Context()
+	assignmentID := getIDFromPath(r, "/api/v1/assignments/", "/accept")
+	if assignmentID == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid assignment ID"})
+		return
+	}
+
+	assignment, err := h.assignmentUseCase.AcceptAssignment(ctx, assignmentID, user, middleware.GetGroupRoleFromContext(ctx))
+	if err != nil {
+		respondAcceptanceError(w, err, "Failed to accept assignment")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, assignment)
+}
+
+// DeclineAssignment turns down an assignment waiting for acceptance. It is
+// offered to the next fair candidate shortly afterwards.
+func (h *AssignmentHandler) DeclineAssignment(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	ctx := r.Context()
+	assignmentID := getIDFromPath(r, "/api/v1/assignments/", "/decline")
+	if assignmentID == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid assignment ID"})
+		return
+	}
+
+	// The reason is optional, so an empty body is fine
+	var req DeclineAssignmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid request body"})
+		return
+	}
+
+	assignment, err := h.assignmentUseCase.DeclineAssignment(ctx, assignmentID, user, middleware.GetGroupRoleFromContext(ctx), req.Reason)
+	if err != nil {
+		respondAcceptanceError(w, err, "Failed to decline assignment")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, assignment)
+}
+
+// respondAcceptanceError maps accept and decline errors to responses
+func respondAcceptanceError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, usecase.ErrAssignmentNotFound):
+		respondJSON(w, http.StatusNotFound, map[string]string{"message": err.Error()})
+	case errors.Is(err, usecase.ErrNotPendingAcceptance):
+		respondJSON(w, http.StatusConflict, map[string]string{"message": err.Error()})
+	case errors.Is(err, usecase.ErrNotAssignedMember):
+		respondJSON(w, http.StatusForbidden, map[string]string{"message": err.Error()})
+	default:
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"message": fallback})
+	}
+}
+
+// ReassignAssignment hands an open assignment to the given member, or to the
+// next fair assignee when no member is given
+func (h *AssignmentHandler) ReassignAssignment(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	ctx := r.Context()
+	assignmentID := getIDFromPath(r, "/api/v1/assignments/", "/reassign")
+	if assignmentID == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid assignment ID"})
+		return
+	}
+
+	var req ReassignAssignmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid request body"})
+		return
+	}
+
+	result, err := h.assignmentUseCase.ReassignAssignment(ctx, assignmentID, user.ID, req.MemberID, req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrAssignmentNotFound):
+			respondJSON(w, http.StatusNotFound, map[string]string{"message": err.Error()})
+		case errors.Is(err, usecase.ErrAssignmentNotOpen):
+			respondJSON(w, http.StatusConflict, map[string]string{"message": err.Error()})
+		default:
+			respondJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"assignmentId": result.AssignmentID,
+		"groupId":      result.GroupID,
+		"member":       result.Member,
+		"timestamp":    time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// GetAssignmentHistory lists who moved an assignment, or changed its status, when and why
+func (h *AssignmentHandler) GetAssignmentHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	assignmentID := getIDFromPath(r, "/api/v1/assignments/", "/events")
+	if assignmentID == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid assignment ID"})
+		return
+	}
+
+	events, err := h.assignmentUseCase.GetAssignmentHistory(ctx, assignmentID)
+	if errors.Is(err, usecase.ErrAssignmentNotFound) {
+		respondJSON(w, http.StatusNotFound, map[string]string{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"message": "Failed to retrieve assignment history"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, events)
+}
+
+// RecordAssignments distributes a batch of items in one go and reports the
+// outcome of each item
+func (h *AssignmentHandler) RecordAssignments(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	ctx := r.Context()
+	groupID := getIDFromPath(r, "/api/v1/groups/", "/assign/batch")
+	if groupID == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid group ID"})
+		return
+	}
+
+	var req BatchAssignmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid request body"})
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = batchModeAllOrNothing
+	}
+	if req.Mode != batchModeAllOrNothing && req.Mode != batchModePartial {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "mode must be all_or_nothing or partial"})
+		return
+	}
+
+	items := make([]usecase.BatchItem, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = usecase.BatchItem{
+			MemberID:    item.MemberID,
+			Metadata:    item.Metadata,
+			ExternalRef: item.ExternalRef,
+		}
+	}
+
+	allOrNothing := req.Mode == batchModeAllOrNothing
+	results, err := h.assignmentUseCase.RecordAssignments(ctx, groupID, user.ID, user.Name, items, allOrNothing)
+	if err != nil && !errors.Is(err, usecase.ErrBatchRolledBack) {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+		return
+	}
+	rolledBack := err != nil
+
+	counts := map[string]int{"assigned": 0, "queued": 0, "failed": 0}
+	itemResults := make([]map[string]interface{}, len(results))
+	for i, result := range results {
+		itemResult := map[string]interface{}{"index": i}
+		switch {
+		case result.Err != nil:
+			itemResult["status"] = "failed"
+			itemResult["message"] = result.Err.Error()
+			counts["failed"]++
+		case rolledBack:
+			// Would have succeeded, but another item failed
+			itemResult["status"] = "rolled_back"
+		case result.Result.Queued():
+			itemResult["status"] = "queued"
+			itemResult["queueId"] = result.Result.QueuedID
+			itemResult["replayed"] = result.Result.Replayed
+			counts["queued"]++
+		default:
+			itemResult["status"] = "assigned"
+			itemResult["assignmentId"] = result.Result.AssignmentID
+			itemResult["groupId"] = result.Result.GroupID
+			itemResult["member"] = result.Result.Member
+			itemResult["replayed"] = result.Result.Replayed
+			counts["assigned"]++
+		}
+		itemResults[i] = itemResult
+	}
+
+	response := map[string]interface{}{
+		"mode":       req.Mode,
+		"rolledBack": rolledBack,
+		"assigned":   counts["assigned"],
+		"queued":     counts["queued"],
+		"failed":     counts["failed"],
+		"results":    itemResults,
+		"timestamp":  time.Now().UTC().Format(time.RFC3339),
+	}
+	if rolledBack {
+		response["message"] = err.Error()
+		respondJSON(w, http.StatusUnprocessableEntity, response)
+		return
+	}
+	respondJSON(w, http.StatusOK, response)
+}
+
 // GetAssignments retrieves assignment history for a group with pagination
 func (h *AssignmentHandler) GetAssignments(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
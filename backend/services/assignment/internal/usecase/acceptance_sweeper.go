@@ -0,0 +1,124 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/logger"
+	"go.uber.org/zap"
+)
+
+// acceptanceBatchSize bounds how many pending assignments are looked at per pass
+const acceptanceBatchSize = 100
+
+// acceptanceRetryDelay is how long an assignment nobody else can take waits
+// before it is offered again, when its group no longer has an acceptance window
+const acceptanceRetryDelay = 15 * time.Minute
+
+// errAlreadySettled means a pending assignment was accepted, cancelled or
+// given a new deadline before the sweeper got to it
+var errAlreadySettled = errors.New("assignment no longer awaits reassignment")
+
+// AcceptanceSweeper reassigns pending assignments that were declined or not
+// accepted in time to the next fair candidate
+type AcceptanceSweeper struct {
+	assignments *AssignmentUseCase
+}
+
+func NewAcceptanceSweeper(assignments *AssignmentUseCase) *AcceptanceSweeper {
+	return &AcceptanceSweeper{assignments: assignments}
+}
+
+// Run sweeps every interval until ctx is cancelled
+func (s *AcceptanceSweeper) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Sweep(ctx); err != nil {
+			logger.Log.Error("Failed to reassign unaccepted assignments", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep reassigns every pending assignment past its acceptance deadline and
+// returns how many were reassigned. Members who declined an assignment are
+// never offered it again. Assignments nobody else can take stay with their
+// member under a new deadline one acceptance window away, so they leave the
+// head of the queue and are retried once it passes.
+func (s *AcceptanceSweeper) Sweep(ctx context.Context) (int, error) {
+	now := time.Now()
+	expired, err := s.assignments.assignmentRepo.GetExpiredPending(ctx, now, acceptanceBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	reassigned := 0
+	for _, assignment := range expired {
+		var moved bool
+		_, err := s.assignments.withLockedAssignment(ctx, assignment.ID, func(ctx context.Context, group *domain.Group, current *domain.Assignment) error {
+			var err error
+			moved, err = s.reassign(ctx, group, current, now)
+			return err
+		})
+		if errors.Is(err, errAlreadySettled) {
+			continue
+		}
+		if err != nil {
+			logger.Log.Warn("Unaccepted assignment could not be reassigned", zap.Int64("assignment_id", assignment.ID), zap.Error(err))
+			continue
+		}
+		if !moved {
+			logger.Log.Info("Nobody else can take unaccepted assignment, retrying later", zap.Int64("assignment_id", assignment.ID))
+			continue
+		}
+		reassigned++
+	}
+
+	return reassigned, nil
+}
+
+// reassign hands an expired pending assignment to the next fair candidate
+// who has not declined it and reports whether it moved. When there is no such
+// candidate the assignment's deadline is pushed back instead.
+func (s *AcceptanceSweeper) reassign(ctx context.Context, group *domain.Group, assignment *domain.Assignment, now time.Time) (bool, error) {
+	if assignment.Status != domain.AssignmentStatusPendingAcceptance || assignment.AcceptBy == nil || assignment.AcceptBy.After(now) {
+		return false, errAlreadySettled
+	}
+
+	declined, err := s.assignments.eventRepo.GetDeclinedMemberIDs(ctx, assignment.ID)
+	if err != nil {
+		return false, err
+	}
+
+	reason := "acceptance window expired"
+	exclude := map[int64]bool{assignment.MemberID: true}
+	for _, memberID := range declined {
+		if memberID == assignment.MemberID {
+			reason = "declined"
+		}
+		exclude[memberID] = true
+	}
+
+	target, err := s.assignments.pickReplacement(ctx, group, exclude)
+	if isUnstaffed(err) || errors.Is(err, ErrNoActiveMember) || errors.Is(err, ErrNoMemberWithCapacity) {
+		retryAt := now.Add(acceptanceRetryDelay)
+		if acceptBy := acceptanceDeadline(group, now); acceptBy != nil {
+			retryAt = *acceptBy
+		}
+		return false, s.assignments.assignmentRepo.SetAcceptBy(ctx, assignment.ID, retryAt)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, s.assignments.moveAssignment(ctx, group, assignment, target, true, nil, &reason)
+}
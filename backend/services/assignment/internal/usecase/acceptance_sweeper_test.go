@@ -0,0 +1,243 @@
+package usecase_test
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/raufhm/fairflow/services/assignment/internal/usecase"
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type eventRepo struct {
+	domain.AssignmentEventRepository
+	s *store
+}
+
+func (r *eventRepo) Create(ctx context.Context, event *domain.AssignmentEvent) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.events = append(r.s.events, event)
+	onRollback(ctx, func() {
+		r.s.mu.Lock()
+		defer r.s.mu.Unlock()
+		for i, e := range r.s.events {
+			if e == event {
+				r.s.events = append(r.s.events[:i], r.s.events[i+1:]...)
+				return
+			}
+		}
+	})
+	return nil
+}
+
+func (r *eventRepo) GetDeclinedMemberIDs(ctx context.Context, assignmentID int64) ([]int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	memberIDs := []int64{}
+	for _, event := range r.s.events {
+		if event.AssignmentID == assignmentID && event.Type == domain.AssignmentEventDeclined {
+			memberIDs = append(memberIDs, *event.FromMemberID)
+		}
+	}
+	return memberIDs, nil
+}
+
+func (r *assignmentRepo) GetByID(ctx context.Context, id int64) (*domain.Assignment, error) {
+	return r.s.assignment(id), nil
+}
+
+func (r *assignmentRepo) GetExpiredPending(ctx context.Context, at time.Time, limit int) ([]*domain.Assignment, error) {
+	r.s.mu.Lock()
+	expired := []*domain.Assignment{}
+	for _, a := range r.s.assignments {
+		if a.Status == domain.AssignmentStatusPendingAcceptance && a.AcceptBy != nil && !a.AcceptBy.After(at) {
+			copied := *a
+			expired = append(expired, &copied)
+		}
+	}
+	r.s.mu.Unlock()
+
+	sort.SliceStable(expired, func(i, j int) bool {
+		return expired[i].AcceptBy.Before(*expired[j].AcceptBy)
+	})
+	if len(expired) > limit {
+		expired = expired[:limit]
+	}
+	return expired, nil
+}
+
+func (r *assignmentRepo) SetAcceptBy(ctx context.Context, id int64, acceptBy time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	assignment := r.s.find(id)
+	previous := assignment.AcceptBy
+	assignment.AcceptBy = &acceptBy
+	onRollback(ctx, func() {
+		r.s.mu.Lock()
+		defer r.s.mu.Unlock()
+		assignment.AcceptBy = previous
+	})
+	return nil
+}
+
+func (r *assignmentRepo) Reassign(ctx context.Context, id, memberID int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	assignment := r.s.find(id)
+	previous, previousAt := assignment.MemberID, assignment.ReassignedAt
+	now := time.Now()
+	assignment.MemberID, assignment.ReassignedAt = memberID, &now
+	onRollback(ctx, func() {
+		r.s.mu.Lock()
+		defer r.s.mu.Unlock()
+		assignment.MemberID, assignment.ReassignedAt = previous, previousAt
+	})
+	return nil
+}
+
+func (r *assignmentRepo) UpdateStatus(ctx context.Context, id int64, status domain.AssignmentStatus) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	assignment := r.s.find(id)
+	previous, previousAcceptBy := assignment.Status, assignment.AcceptBy
+	assignment.Status, assignment.AcceptBy = status, nil
+	onRollback(ctx, func() {
+		r.s.mu.Lock()
+		defer r.s.mu.Unlock()
+		assignment.Status, assignment.AcceptBy = previous, previousAcceptBy
+	})
+	return nil
+}
+
+func (r *memberRepo) DecrementOpenAssignments(ctx context.Context, memberID int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.members[memberID].CurrentOpenAssignments--
+	onRollback(ctx, func() {
+		r.s.mu.Lock()
+		defer r.s.mu.Unlock()
+		r.s.members[memberID].CurrentOpenAssignments++
+	})
+	return nil
+}
+
+// find returns the stored assignment with the given ID. The caller holds s.mu.
+func (s *store) find(id int64) *domain.Assignment {
+	for _, a := range s.assignments {
+		if a.ID == id {
+			return a
+		}
+	}
+	return nil
+}
+
+func (s *store) assignment(id int64) *domain.Assignment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.find(id)
+	if a == nil {
+		return nil
+	}
+	copied := *a
+	return &copied
+}
+
+// pending adds an assignment held by memberID whose acceptance window closed at acceptBy
+func (s *store) pending(groupID, memberID int64, acceptBy time.Time) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.assignmentSeq++
+	s.assignments = append(s.assignments, &domain.Assignment{
+		ID:       s.assignmentSeq,
+		GroupID:  groupID,
+		MemberID: memberID,
+		Status:   domain.AssignmentStatusPendingAcceptance,
+		AcceptBy: &acceptBy,
+	})
+	s.members[memberID].CurrentOpenAssignments++
+	return s.assignmentSeq
+}
+
+func TestAcceptanceSweeper_PushesBackAssignmentsNobodyElseCanTake(t *testing.T) {
+	window := `{"acceptance_window":"30m"}`
+	s := newStore(
+		[]*domain.Group{
+			{ID: 1, Strategy: domain.StrategyWeightedRoundRobin, Settings: &window},
+			{ID: 2, Strategy: domain.StrategyWeightedRoundRobin, Settings: &window},
+		},
+		[]*domain.Member{
+			// Group 1 has nobody to hand work to
+			{ID: 1, GroupID: 1, Weight: 1, Active: true, Available: true},
+			{ID: 2, GroupID: 2, Weight: 1, Active: true, Available: true},
+			{ID: 3, GroupID: 2, Weight: 1, Active: true, Available: true},
+		},
+	)
+	ctx := context.Background()
+
+	// The stuck assignments expired first, so they head the sweeper's queue
+	expiredAt := time.Now().Add(-time.Hour)
+	stuck := []int64{s.pending(1, 1, expiredAt), s.pending(1, 1, expiredAt)}
+	movable := s.pending(2, 2, time.Now().Add(-time.Minute))
+
+	sweeper := usecase.NewAcceptanceSweeper(newUseCase(s))
+	before := time.Now()
+	reassigned, err := sweeper.Sweep(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, reassigned)
+	assert.Equal(t, int64(3), s.assignment(movable).MemberID)
+	assert.Equal(t, 0, s.openAssignments(2))
+	assert.Equal(t, 1, s.openAssignments(3))
+
+	for _, id := range stuck {
+		assignment := s.assignment(id)
+		assert.Equal(t, int64(1), assignment.MemberID)
+		assert.Equal(t, domain.AssignmentStatusPendingAcceptance, assignment.Status)
+		require.NotNil(t, assignment.AcceptBy)
+		assert.False(t, assignment.AcceptBy.Before(before.Add(30*time.Minute)))
+	}
+
+	// Nothing is due again until the new deadlines pass
+	reassigned, err = sweeper.Sweep(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, reassigned)
+	assert.Equal(t, 2, s.openAssignments(1))
+}
+
+func TestAcceptanceSweeper_SkipsMembersWhoDeclined(t *testing.T) {
+	window := `{"acceptance_window":"30m"}`
+	s := newStore(
+		[]*domain.Group{{ID: 1, Strategy: domain.StrategyWeightedRoundRobin, Settings: &window}},
+		[]*domain.Member{
+			{ID: 1, GroupID: 1, Weight: 1, Active: true, Available: true, Email: strPtr("ana@example.com")},
+			{ID: 2, GroupID: 1, Weight: 1, Active: true, Available: true, Email: strPtr("ben@example.com")},
+		},
+	)
+	ctx := context.Background()
+	uc := newUseCase(s)
+	ana := &domain.User{ID: 1, Email: "ana@example.com"}
+	ben := &domain.User{ID: 2, Email: "ben@example.com"}
+
+	id := s.pending(1, 1, time.Now().Add(time.Hour))
+	_, err := uc.DeclineAssignment(ctx, id, ana, domain.GroupRoleViewer, nil)
+	require.NoError(t, err)
+
+	sweeper := usecase.NewAcceptanceSweeper(uc)
+	reassigned, err := sweeper.Sweep(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, reassigned)
+	assert.Equal(t, int64(2), s.assignment(id).MemberID)
+
+	// Member 2 declines too; member 1 already turned it down, so it waits
+	_, err = uc.DeclineAssignment(ctx, id, ben, domain.GroupRoleViewer, nil)
+	require.NoError(t, err)
+	reassigned, err = sweeper.Sweep(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, reassigned)
+	assert.Equal(t, int64(2), s.assignment(id).MemberID)
+	assert.True(t, s.assignment(id).AcceptBy.After(time.Now()))
+}
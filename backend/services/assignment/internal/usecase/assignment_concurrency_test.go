@@ -0,0 +1,425 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/raufhm/fairflow/services/assignment/internal/usecase"
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/strategy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const concurrentRequests = 64
+
+// store is an in-memory database. Group locks are held until the transaction
+// that took them ends, like SELECT ... FOR UPDATE. Writes made in a failed
+// transaction or savepoint are undone.
+type store struct {
+	mu            sync.Mutex
+	groups        map[int64]*domain.Group
+	members       map[int64]*domain.Member
+	assignments   []*domain.Assignment
+	assignmentSeq int64
+	events        []*domain.AssignmentEvent
+	idempotency   []*domain.IdempotencyRecord
+	queue         []*domain.QueuedAssignment
+	queueSeq      int64
+	groupLocks    map[int64]*sync.Mutex
+	// failIncrementFor makes incrementing this member's open counter fail
+	failIncrementFor int64
+}
+
+type txKey struct{}
+
+type tx struct {
+	locked []*sync.Mutex
+	undo   []func()
+}
+
+func (t *tx) holds(lock *sync.Mutex) bool {
+	for _, l := range t.locked {
+		if l == lock {
+			return true
+		}
+	}
+	return false
+}
+
+func newStore(groups []*domain.Group, members []*domain.Member) *store {
+	s := &store{
+		groups:     make(map[int64]*domain.Group),
+		members:    make(map[int64]*domain.Member),
+		groupLocks: make(map[int64]*sync.Mutex),
+	}
+	for _, g := range groups {
+		s.groups[g.ID] = g
+		s.groupLocks[g.ID] = &sync.Mutex{}
+	}
+	for _, m := range members {
+		s.members[m.ID] = m
+	}
+	return s
+}
+
+// WithinTx runs fn in a transaction, or in a savepoint of the surrounding one
+func (s *store) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if t, ok := ctx.Value(txKey{}).(*tx); ok {
+		savepoint := len(t.undo)
+		err := fn(ctx)
+		if err != nil {
+			t.rollbackTo(savepoint)
+		}
+		return err
+	}
+	t := &tx{}
+	defer func() {
+		for _, l := range t.locked {
+			l.Unlock()
+		}
+	}()
+	err := fn(context.WithValue(ctx, txKey{}, t))
+	if err != nil {
+		t.rollbackTo(0)
+	}
+	return err
+}
+
+// rollbackTo undoes the writes made since the given savepoint, newest first
+func (t *tx) rollbackTo(savepoint int) {
+	for i := len(t.undo) - 1; i >= savepoint; i-- {
+		t.undo[i]()
+	}
+	t.undo = t.undo[:savepoint]
+}
+
+// onRollback registers undo to run if the write's transaction fails. Writes
+// outside a transaction are final.
+func onRollback(ctx context.Context, undo func()) {
+	if t, ok := ctx.Value(txKey{}).(*tx); ok {
+		t.undo = append(t.undo, undo)
+	}
+}
+
+func (s *store) group(id int64) *domain.Group {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, ok := s.groups[id]
+	if !ok {
+		return nil
+	}
+	copied := *g
+	return &copied
+}
+
+func (s *store) member(id int64) *domain.Member {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.members[id]
+	if !ok {
+		return nil
+	}
+	copied := *m
+	return &copied
+}
+
+func (s *store) openAssignments(memberID int64) int {
+	return s.member(memberID).CurrentOpenAssignments
+}
+
+func (s *store) dailyAssignments(memberID int64) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := 0
+	for _, a := range s.assignments {
+		if a.MemberID == memberID {
+			count++
+		}
+	}
+	return count
+}
+
+type groupRepo struct {
+	domain.GroupRepository
+	s *store
+}
+
+func (r *groupRepo) GetByID(ctx context.Context, id int64) (*domain.Group, error) {
+	return r.s.group(id), nil
+}
+
+func (r *groupRepo) LockByID(ctx context.Context, id int64) (*domain.Group, error) {
+	t, ok := ctx.Value(txKey{}).(*tx)
+	if !ok {
+		return nil, errors.New("lock taken outside a transaction")
+	}
+	lock, ok := r.s.groupLocks[id]
+	if !ok {
+		return nil, nil
+	}
+	// Row locks are reentrant within a transaction
+	if !t.holds(lock) {
+		lock.Lock()
+		t.locked = append(t.locked, lock)
+	}
+	return r.s.group(id), nil
+}
+
+type memberRepo struct {
+	domain.MemberRepository
+	s *store
+}
+
+func (r *memberRepo) GetByID(ctx context.Context, id int64) (*domain.Member, error) {
+	return r.s.member(id), nil
+}
+
+func (r *memberRepo) GetActiveByGroupID(ctx context.Context, groupID int64) ([]*domain.Member, error) {
+	r.s.mu.Lock()
+	members := []*domain.Member{}
+	for _, m := range r.s.members {
+		if m.GroupID == groupID && m.Active {
+			copied := *m
+			members = append(members, &copied)
+		}
+	}
+	r.s.mu.Unlock()
+
+	// Give other requests the chance to read the same counters
+	runtime.Gosched()
+	return members, nil
+}
+
+func (r *memberRepo) IncrementOpenAssignments(ctx context.Context, memberID int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if memberID == r.s.failIncrementFor {
+		return errors.New("write failed")
+	}
+	r.s.members[memberID].CurrentOpenAssignments++
+	onRollback(ctx, func() {
+		r.s.mu.Lock()
+		defer r.s.mu.Unlock()
+		r.s.members[memberID].CurrentOpenAssignments--
+	})
+	return nil
+}
+
+func (r *memberRepo) GetDailyAssignmentCount(ctx context.Context, memberID int64) (int, error) {
+	count := r.s.dailyAssignments(memberID)
+	runtime.Gosched()
+	return count, nil
+}
+
+type assignmentRepo struct {
+	domain.AssignmentRepository
+	s *store
+}
+
+func (r *assignmentRepo) Create(ctx context.Context, assignment *domain.Assignment) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.assignmentSeq++
+	assignment.ID = r.s.assignmentSeq
+	r.s.assignments = append(r.s.assignments, assignment)
+	onRollback(ctx, func() {
+		r.s.mu.Lock()
+		defer r.s.mu.Unlock()
+		for i, a := range r.s.assignments {
+			if a == assignment {
+				r.s.assignments = append(r.s.assignments[:i], r.s.assignments[i+1:]...)
+				return
+			}
+		}
+	})
+	return nil
+}
+
+func (r *assignmentRepo) GetCountsByMemberIDs(ctx context.Context, memberIDs []int64) (map[int64]int, error) {
+	counts := make(map[int64]int)
+	for _, id := range memberIDs {
+		counts[id] = r.s.dailyAssignments(id)
+	}
+	return counts, nil
+}
+
+type cursorRepo struct{}
+
+func (cursorRepo) Get(ctx context.Context, groupID int64) (*domain.RotationCursor, error) {
+	return nil, nil
+}
+
+func (cursorRepo) Set(ctx context.Context, groupID, lastMemberID int64) error {
+	return nil
+}
+
+type timeOffRepo struct {
+	domain.TimeOffRepository
+}
+
+func (timeOffRepo) GetOutOfOfficeMemberIDs(ctx context.Context, memberIDs []int64, at time.Time) (map[int64]bool, error) {
+	return map[int64]bool{}, nil
+}
+
+func newUseCase(s *store) *usecase.AssignmentUseCase {
+	return usecase.NewAssignmentUseCase(
+		&groupRepo{s: s},
+		&memberRepo{s: s},
+		&assignmentRepo{s: s},
+		cursorRepo{},
+		&queueRepo{s: s},
+		timeOffRepo{},
+		&idempotencyRepo{s: s},
+		&eventRepo{s: s},
+		s,
+		strategy.DefaultRegistry(),
+		time.Hour,
+	)
+}
+
+// assignConcurrently fires concurrentRequests automatic assignments per group
+// at once and returns how many succeeded
+func assignConcurrently(t *testing.T, uc *usecase.AssignmentUseCase, groupIDs ...int64) int {
+	t.Helper()
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		succeeded int
+	)
+	start := make(chan struct{})
+	for _, groupID := range groupIDs {
+		for i := 0; i < concurrentRequests; i++ {
+			wg.Add(1)
+			go func(groupID int64) {
+				defer wg.Done()
+				<-start
+				result, err := uc.RecordAssignment(context.Background(), groupID, 1, "dispatcher", nil, nil, nil, "")
+				if err != nil {
+					return
+				}
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+				assert.False(t, result.Queued())
+			}(groupID)
+		}
+	}
+	close(start)
+	wg.Wait()
+
+	return succeeded
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestRecordAssignment_ConcurrentRespectsMaxConcurrentOpen(t *testing.T) {
+	s := newStore(
+		[]*domain.Group{{ID: 1, Strategy: domain.StrategyWeightedRoundRobin}},
+		[]*domain.Member{
+			{ID: 1, GroupID: 1, Weight: 1, Active: true, Available: true, MaxConcurrentOpen: intPtr(2)},
+			{ID: 2, GroupID: 1, Weight: 2, Active: true, Available: true, MaxConcurrentOpen: intPtr(3)},
+			{ID: 3, GroupID: 1, Weight: 1, Active: true, Available: true, MaxConcurrentOpen: intPtr(1), CurrentOpenAssignments: 1},
+		},
+	)
+
+	succeeded := assignConcurrently(t, newUseCase(s), 1)
+
+	assert.Equal(t, 5, succeeded)
+	assert.Equal(t, 2, s.openAssignments(1))
+	assert.Equal(t, 3, s.openAssignments(2))
+	assert.Equal(t, 1, s.openAssignments(3))
+}
+
+func TestRecordAssignment_ConcurrentRespectsMaxDailyAssignments(t *testing.T) {
+	s := newStore(
+		[]*domain.Group{{ID: 1, Strategy: domain.StrategyWeightedRoundRobin}},
+		[]*domain.Member{
+			{ID: 1, GroupID: 1, Weight: 1, Active: true, Available: true, MaxDailyAssignments: intPtr(3)},
+			{ID: 2, GroupID: 1, Weight: 1, Active: true, Available: true, MaxDailyAssignments: intPtr(4)},
+		},
+	)
+
+	succeeded := assignConcurrently(t, newUseCase(s), 1)
+
+	assert.Equal(t, 7, succeeded)
+	assert.Equal(t, 3, s.dailyAssignments(1))
+	assert.Equal(t, 4, s.dailyAssignments(2))
+}
+
+func TestRecordAssignment_ConcurrentOverflowIntoEachOther(t *testing.T) {
+	overflowSettings := func(target string) *string {
+		settings := `{"off_hours_fallback":"overflow","overflow_group_id":` + target + `}`
+		return &settings
+	}
+	s := newStore(
+		[]*domain.Group{
+			{ID: 1, Strategy: domain.StrategyWeightedRoundRobin, Settings: overflowSettings("2")},
+			{ID: 2, Strategy: domain.StrategyWeightedRoundRobin, Settings: overflowSettings("1")},
+		},
+		[]*domain.Member{
+			// Nobody in group 1 is available, so its work overflows into group 2
+			{ID: 1, GroupID: 1, Weight: 1, Active: true, Available: false},
+			{ID: 2, GroupID: 2, Weight: 1, Active: true, Available: true, MaxConcurrentOpen: intPtr(3)},
+			{ID: 3, GroupID: 2, Weight: 1, Active: true, Available: true, MaxConcurrentOpen: intPtr(2), MaxDailyAssignments: intPtr(1)},
+		},
+	)
+
+	// Both groups lock each other; a fixed lock order keeps this from deadlocking
+	succeeded := assignConcurrently(t, newUseCase(s), 1, 2)
+
+	require.Equal(t, 4, succeeded)
+	assert.Equal(t, 3, s.openAssignments(2))
+	assert.Equal(t, 1, s.openAssignments(3))
+	assert.Equal(t, 1, s.dailyAssignments(3))
+	assert.Equal(t, 0, s.dailyAssignments(1))
+}
+
+func TestRecordAssignments_ConcurrentBatchesRespectCapacity(t *testing.T) {
+	s := newStore(
+		[]*domain.Group{{ID: 1, Strategy: domain.StrategyWeightedRoundRobin}},
+		[]*domain.Member{
+			{ID: 1, GroupID: 1, Weight: 1, Active: true, Available: true, MaxConcurrentOpen: intPtr(4)},
+			{ID: 2, GroupID: 1, Weight: 1, Active: true, Available: true, MaxConcurrentOpen: intPtr(5)},
+		},
+	)
+	uc := newUseCase(s)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		assigned int
+	)
+	start := make(chan struct{})
+	for i := 0; i < concurrentRequests/8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			results, err := uc.RecordAssignments(context.Background(), 1, 1, "dispatcher", make([]usecase.BatchItem, 3), false)
+			if !assert.NoError(t, err) {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, result := range results {
+				if result.Err == nil {
+					assigned++
+				}
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	assert.Equal(t, 9, assigned)
+	assert.Equal(t, 4, s.openAssignments(1))
+	assert.Equal(t, 5, s.openAssignments(2))
+}
@@ -2,54 +2,325 @@ package usecase
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
 	"math"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/logger"
+	"github.com/raufhm/fairflow/shared/strategy"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrGroupNotFound     = errors.New("group not found")
+	ErrAssignmentsPaused = errors.New("assignments are paused for this group")
+	// ErrNoMemberOnShift is returned when every available member is outside their working hours
+	ErrNoMemberOnShift = errors.New("no members are on shift")
+	// ErrNoMemberAvailable is returned when every active member is unavailable or on time off
+	ErrNoMemberAvailable = errors.New("no members are available")
+	// ErrNoActiveMember is returned when the group has no active members to pick from
+	ErrNoActiveMember = errors.New("no active members available for assignment")
+	// ErrNoMemberWithCapacity is returned when every candidate is at a capacity limit
+	ErrNoMemberWithCapacity  = errors.New("no members available with capacity for assignment")
+	ErrMemberUnavailable     = errors.New("member is unavailable or on time off")
+	ErrMemberAtCapacity      = errors.New("member has reached their open or daily assignment limit")
+	ErrInvalidIdempotencyKey = errors.New("Idempotency-Key must be at most 255 characters")
+	ErrInvalidExternalRef    = errors.New("external_ref must be between 1 and 255 characters")
+	ErrAssignmentNotFound    = errors.New("assignment not found")
+	ErrInvalidStatus         = errors.New("status must be open, completed or cancelled")
+	// ErrIllegalTransition is returned when the state machine forbids a status change
+	ErrIllegalTransition     = errors.New("illegal status transition")
+	ErrAssignmentNotOpen     = errors.New("only open or pending assignments can be reassigned")
+	ErrNotPendingAcceptance  = errors.New("assignment is not waiting for acceptance")
+	ErrNotAssignedMember     = errors.New("only the assigned member or a group manager can accept or decline this assignment")
+	ErrInvalidReassignTarget = errors.New("target must be another active member of the assignment's group")
+	ErrInvalidBatchSize      = errors.New("batch must contain between 1 and 1000 items")
+	// ErrBatchRolledBack is returned when an all-or-nothing batch had a failing item
+	ErrBatchRolledBack = errors.New("batch was rolled back because an item failed")
+)
+
+const (
+	// maxReferenceLength bounds idempotency keys and external references
+	maxReferenceLength = 255
+	// maxBatchSize bounds the items of a single batch request
+	maxBatchSize = 1000
 )
 
 type AssignmentUseCase struct {
-	groupRepo      domain.GroupRepository
-	memberRepo     domain.MemberRepository
-	assignmentRepo domain.AssignmentRepository
+	groupRepo       domain.GroupRepository
+	memberRepo      domain.MemberRepository
+	assignmentRepo  domain.AssignmentRepository
+	cursorRepo      domain.RotationCursorRepository
+	queueRepo       domain.AssignmentQueueRepository
+	timeOffRepo     domain.TimeOffRepository
+	idempotencyRepo domain.IdempotencyRepository
+	eventRepo       domain.AssignmentEventRepository
+	transactor      domain.Transactor
+	strategies      *strategy.Registry
+	idempotencyTTL  time.Duration
 }
 
 func NewAssignmentUseCase(
 	groupRepo domain.GroupRepository,
 	memberRepo domain.MemberRepository,
 	assignmentRepo domain.AssignmentRepository,
+	cursorRepo domain.RotationCursorRepository,
+	queueRepo domain.AssignmentQueueRepository,
+	timeOffRepo domain.TimeOffRepository,
+	idempotencyRepo domain.IdempotencyRepository,
+	eventRepo domain.AssignmentEventRepository,
+	transactor domain.Transactor,
+	strategies *strategy.Registry,
+	idempotencyTTL time.Duration,
 ) *AssignmentUseCase {
 	return &AssignmentUseCase{
-		groupRepo:      groupRepo,
-		memberRepo:     memberRepo,
-		assignmentRepo: assignmentRepo,
+		groupRepo:       groupRepo,
+		memberRepo:      memberRepo,
+		assignmentRepo:  assignmentRepo,
+		cursorRepo:      cursorRepo,
+		queueRepo:       queueRepo,
+		timeOffRepo:     timeOffRepo,
+		idempotencyRepo: idempotencyRepo,
+		eventRepo:       eventRepo,
+		transactor:      transactor,
+		strategies:      strategies,
+		idempotencyTTL:  idempotencyTTL,
 	}
 }
 
-// CalculateNextAssignee calculates the next assignee using weighted round robin
+// CalculateNextAssignee calculates the next assignee using the group's strategy,
+// applying the group's off-hours fallback when nobody is on shift. It does not
+// move the rotation cursor; only recorded assignments do. The returned member
+// belongs to the overflow group when the overflow fallback was used.
 func (uc *AssignmentUseCase) CalculateNextAssignee(ctx context.Context, groupID int64) (*domain.Member, error) {
+	group, err := uc.assignableGroup(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.selectAssignee(ctx, group, time.Now())
+}
+
+// assignableGroup loads a group that is accepting assignments
+func (uc *AssignmentUseCase) assignableGroup(ctx context.Context, groupID int64) (*domain.Group, error) {
 	// Check if group is paused
 	group, err := uc.groupRepo.GetByID(ctx, groupID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrGroupNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 	if group == nil {
-		return nil, errors.New("group not found")
+		return nil, ErrGroupNotFound
 	}
 	if group.AssignmentPaused {
-		return nil, errors.New("assignments are paused for this group")
+		return nil, ErrAssignmentsPaused
+	}
+	return group, nil
+}
+
+// lockForAssignment locks the group, and its overflow group when it has one,
+// until the surrounding transaction ends. Concurrent assignments to the same
+// group therefore see each other's counters. Groups are locked in ID order so
+// two groups overflowing into each other cannot deadlock.
+func (uc *AssignmentUseCase) lockForAssignment(ctx context.Context, groupID int64) (*domain.Group, error) {
+	group, err := uc.assignableGroup(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	var locked *domain.Group
+	for _, id := range assignmentGroupIDs(group) {
+		g, err := uc.groupRepo.LockByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if id == groupID {
+			locked = g
+		}
+	}
+
+	// Re-check the state now that no one else can change it
+	if locked == nil {
+		return nil, ErrGroupNotFound
+	}
+	if locked.AssignmentPaused {
+		return nil, ErrAssignmentsPaused
 	}
+	return locked, nil
+}
+
+// assignmentGroupIDs returns, in ascending order, the groups an automatic
+// assignment to group may land in: the group and its overflow group
+func assignmentGroupIDs(group *domain.Group) []int64 {
+	groupIDs := []int64{group.ID}
+	if settings, err := group.ParseSettings(); err == nil && settings.Fallback() == domain.OffHoursOverflow &&
+		settings.OverflowGroupID != nil && *settings.OverflowGroupID != group.ID {
+		groupIDs = append(groupIDs, *settings.OverflowGroupID)
+	}
+	sort.Slice(groupIDs, func(i, j int) bool { return groupIDs[i] < groupIDs[j] })
+	return groupIDs
+}
+
+// selectAssignee picks a member who is available and on shift, falling back as
+// configured in the group's settings when nobody is. Under the queue fallback
+// it returns ErrNoMemberOnShift or ErrNoMemberAvailable.
+func (uc *AssignmentUseCase) selectAssignee(ctx context.Context, group *domain.Group, now time.Time) (*domain.Member, error) {
+	member, err := uc.pickMember(ctx, group, now, true, nil)
+	if !isUnstaffed(err) {
+		return member, err
+	}
+
+	settings, settingsErr := group.ParseSettings()
+	if settingsErr != nil {
+		return nil, settingsErr
+	}
+
+	switch settings.Fallback() {
+	case domain.OffHoursIgnoreHours:
+		// Time off still applies; only working hours are ignored
+		return uc.pickMember(ctx, group, now, false, nil)
+	case domain.OffHoursOverflow:
+		if settings.OverflowGroupID == nil {
+			return nil, err
+		}
+		overflow, overflowErr := uc.assignableGroup(ctx, *settings.OverflowGroupID)
+		if overflowErr != nil {
+			return nil, err
+		}
+		// Only one hop: the overflow group's own fallback is not applied
+		return uc.pickMember(ctx, overflow, now, true, nil)
+	}
+
+	return nil, err
+}
 
+// pickMember runs the group's strategy over its active, available members with
+// capacity. With enforceHours set, members who are off shift are left out, as
+// are the members in exclude.
+func (uc *AssignmentUseCase) pickMember(ctx context.Context, group *domain.Group, now time.Time, enforceHours bool, exclude map[int64]bool) (*domain.Member, error) {
 	// Get active members
-	members, err := uc.memberRepo.GetActiveByGroupID(ctx, groupID)
+	members, err := uc.memberRepo.GetActiveByGroupID(ctx, group.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(exclude) > 0 {
+		members = withoutMembers(members, exclude)
+	}
+	if len(members) == 0 {
+		return nil, ErrNoActiveMember
+	}
+
+	members, err = uc.availableMembers(ctx, members, now)
 	if err != nil {
 		return nil, err
 	}
 	if len(members) == 0 {
-		return nil, errors.New("no active members available for assignment")
+		return nil, ErrNoMemberAvailable
+	}
+
+	if enforceHours {
+		members = onShiftMembers(members, now)
+		if len(members) == 0 {
+			return nil, ErrNoMemberOnShift
+		}
+	}
+
+	eligibleMembers := uc.eligibleMembers(ctx, members)
+	if len(eligibleMembers) == 0 {
+		return nil, ErrNoMemberWithCapacity
+	}
+
+	picker, err := uc.strategies.ForGroup(group)
+	if err != nil {
+		return nil, err
+	}
+
+	nextAssignee, err := picker.Pick(ctx, strategy.Input{
+		Group:   group,
+		Members: eligibleMembers,
+		History: &assignmentHistory{groupID: group.ID, assignmentRepo: uc.assignmentRepo, cursorRepo: uc.cursorRepo},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if nextAssignee == nil {
+		return nil, ErrNoMemberWithCapacity
+	}
+
+	return nextAssignee, nil
+}
+
+// withoutMembers drops the members in exclude
+func withoutMembers(members []*domain.Member, exclude map[int64]bool) []*domain.Member {
+	kept := []*domain.Member{}
+	for _, member := range members {
+		if !exclude[member.ID] {
+			kept = append(kept, member)
+		}
+	}
+	return kept
+}
+
+// availableMembers keeps members who are marked available and not on time off
+func (uc *AssignmentUseCase) availableMembers(ctx context.Context, members []*domain.Member, now time.Time) ([]*domain.Member, error) {
+	candidates := []*domain.Member{}
+	for _, member := range members {
+		if member.Available {
+			candidates = append(candidates, member)
+		}
+	}
+
+	memberIDs := make([]int64, len(candidates))
+	for i, m := range candidates {
+		memberIDs[i] = m.ID
+	}
+
+	outOfOffice, err := uc.timeOffRepo.GetOutOfOfficeMemberIDs(ctx, memberIDs, now)
+	if err != nil {
+		return nil, err
+	}
+
+	available := []*domain.Member{}
+	for _, member := range candidates {
+		if !outOfOffice[member.ID] {
+			available = append(available, member)
+		}
 	}
+	return available, nil
+}
 
-	// Filter members based on capacity limits
+// isUnstaffed reports whether err means nobody could take work right now,
+// which triggers the group's off-hours fallback
+func isUnstaffed(err error) bool {
+	return errors.Is(err, ErrNoMemberOnShift) || errors.Is(err, ErrNoMemberAvailable)
+}
+
+// onShiftMembers keeps the members working at now in their own timezone.
+// Members whose working hours cannot be read are kept rather than silently
+// dropped from the rotation.
+func onShiftMembers(members []*domain.Member, now time.Time) []*domain.Member {
+	onShift := []*domain.Member{}
+	for _, member := range members {
+		working, err := member.OnShift(now)
+		if err != nil {
+			logger.Log.Warn("Ignoring unreadable working hours", zap.Int64("member_id", member.ID), zap.Error(err))
+			working = true
+		}
+		if working {
+			onShift = append(onShift, member)
+		}
+	}
+	return onShift
+}
+
+// eligibleMembers filters out members who have reached their capacity limits
+func (uc *AssignmentUseCase) eligibleMembers(ctx context.Context, members []*domain.Member) []*domain.Member {
 	eligibleMembers := []*domain.Member{}
 	for _, member := range members {
 		// Check concurrent open assignments limit
@@ -70,80 +341,655 @@ func (uc *AssignmentUseCase) CalculateNextAssignee(ctx context.Context, groupID
 
 		eligibleMembers = append(eligibleMembers, member)
 	}
+	return eligibleMembers
+}
 
-	if len(eligibleMembers) == 0 {
-		return nil, errors.New("no members available with capacity for assignment")
+// assignmentHistory reads a group's past assignments on behalf of a strategy
+type assignmentHistory struct {
+	groupID        int64
+	assignmentRepo domain.AssignmentRepository
+	cursorRepo     domain.RotationCursorRepository
+}
+
+func (h *assignmentHistory) AssignmentCounts(ctx context.Context, memberIDs []int64) (map[int64]int, error) {
+	return h.assignmentRepo.GetCountsByMemberIDs(ctx, memberIDs)
+}
+
+func (h *assignmentHistory) LastAssignedAt(ctx context.Context, memberIDs []int64) (map[int64]time.Time, error) {
+	return h.assignmentRepo.GetLastAssignedAtByMemberIDs(ctx, memberIDs)
+}
+
+func (h *assignmentHistory) LastRotationMemberID(ctx context.Context) (int64, error) {
+	cursor, err := h.cursorRepo.Get(ctx, h.groupID)
+	if err != nil || cursor == nil {
+		return 0, err
 	}
+	return cursor.LastMemberID, nil
+}
 
-	// Get member IDs for assignment count query
-	memberIDs := make([]int64, len(eligibleMembers))
-	for i, m := range eligibleMembers {
-		memberIDs[i] = m.ID
+// AssignmentResult describes the outcome of an assignment request. Either an
+// assignment was created, or the request was queued because nobody was on shift.
+// Replayed is set when the outcome is that of an earlier request with the same
+// idempotency key or external reference.
+type AssignmentResult struct {
+	AssignmentID int64
+	GroupID      int64
+	Member       *domain.Member
+	QueuedID     int64
+	Replayed     bool
+}
+
+// Queued reports whether the request is waiting for a member to come on shift
+func (r *AssignmentResult) Queued() bool {
+	return r.QueuedID != 0
+}
+
+// RecordAssignment creates a new assignment record. Without a member ID the
+// assignee is calculated; when nobody is available or on shift and the group
+// falls back to queueing, the request is queued instead. Overflow assignments
+// are recorded in the overflow group.
+//
+// A request repeating an unexpired idempotency key or an external reference
+// already used in the group gets the earlier result instead of a new
+// assignment. Selection, insert and counter updates run in one transaction
+// holding the group's lock, so concurrent requests cannot exceed capacity
+// limits or slip past each other's keys.
+func (uc *AssignmentUseCase) RecordAssignment(ctx context.Context, groupID, userID int64, userName string, memberID *int64, metadata, externalRef *string, idempotencyKey string) (*AssignmentResult, error) {
+	if err := validateReferences(externalRef, idempotencyKey); err != nil {
+		return nil, err
 	}
 
-	// Get assignment counts
-	counts, err := uc.assignmentRepo.GetCountsByMemberIDs(ctx, memberIDs)
+	req := assignmentRequest{
+		groupID:        groupID,
+		userID:         userID,
+		metadata:       metadata,
+		externalRef:    externalRef,
+		idempotencyKey: idempotencyKey,
+	}
+
+	var result *AssignmentResult
+	err := uc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if memberID != nil {
+			result, err = uc.recordManualAssignment(ctx, req, *memberID)
+		} else {
+			result, err = uc.recordAutomaticAssignment(ctx, req)
+		}
+		if err != nil || result.Replayed || idempotencyKey == "" {
+			return err
+		}
+		return uc.rememberResult(ctx, groupID, idempotencyKey, result)
+	})
 	if err != nil {
 		return nil, err
 	}
+	return result, nil
+}
 
-	// Calculate weighted round robin
-	var lowestRatio float64 = math.MaxFloat64
-	var nextAssignee *domain.Member
+// BatchItem is one assignment request of a batch
+type BatchItem struct {
+	MemberID    *int64
+	Metadata    *string
+	ExternalRef *string
+}
 
-	for _, member := range eligibleMembers {
-		actual := float64(counts[member.ID])
-		expected := float64(member.Weight) / 100.0
+// BatchItemResult is the outcome of one batch item: its assignment, or the
+// error that stopped it
+type BatchItemResult struct {
+	Result *AssignmentResult
+	Err    error
+}
 
-		var ratio float64
-		if expected > 0 {
-			ratio = actual / expected
-		} else {
-			ratio = actual
+// RecordAssignments distributes a batch of items in one transaction holding
+// the group's lock, so each item's pick sees the items before it and no other
+// request interleaves with the batch. Items are handled like RecordAssignment
+// requests and results come back in item order.
+//
+// With allOrNothing set, a failing item rolls back the whole batch and
+// ErrBatchRolledBack is returned alongside the results. Otherwise failing
+// items are skipped and the rest are kept.
+func (uc *AssignmentUseCase) RecordAssignments(ctx context.Context, groupID, userID int64, userName string, items []BatchItem, allOrNothing bool) ([]BatchItemResult, error) {
+	if len(items) == 0 || len(items) > maxBatchSize {
+		return nil, ErrInvalidBatchSize
+	}
+
+	results := make([]BatchItemResult, len(items))
+	err := uc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		// Paused or missing groups fail the batch as a whole
+		if _, err := uc.lockForAssignment(ctx, groupID); err != nil {
+			return err
 		}
 
-		if ratio < lowestRatio {
-			lowestRatio = ratio
-			nextAssignee = member
+		failed := false
+		for i, item := range items {
+			req := assignmentRequest{
+				groupID:     groupID,
+				userID:      userID,
+				metadata:    item.Metadata,
+				externalRef: item.ExternalRef,
+			}
+			// Each item runs in a savepoint so a failure undoes only its own writes
+			var result *AssignmentResult
+			err := uc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+				if err := validateReferences(item.ExternalRef, ""); err != nil {
+					return err
+				}
+				var err error
+				if item.MemberID != nil {
+					result, err = uc.recordManualAssignment(ctx, req, *item.MemberID)
+				} else {
+					result, err = uc.recordAutomaticAssignment(ctx, req)
+				}
+				return err
+			})
+			results[i] = BatchItemResult{Result: result, Err: err}
+			if err != nil {
+				failed = true
+			}
 		}
+
+		if failed && allOrNothing {
+			return ErrBatchRolledBack
+		}
+		return nil
+	})
+	if errors.Is(err, ErrBatchRolledBack) {
+		return results, err
 	}
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
 
-	return nextAssignee, nil
+// validateReferences checks the caller's external reference and idempotency key
+func validateReferences(externalRef *string, idempotencyKey string) error {
+	if len(idempotencyKey) > maxReferenceLength {
+		return ErrInvalidIdempotencyKey
+	}
+	if externalRef != nil && (*externalRef == "" || len(*externalRef) > maxReferenceLength) {
+		return ErrInvalidExternalRef
+	}
+	return nil
 }
 
-// RecordAssignment creates a new assignment record
-func (uc *AssignmentUseCase) RecordAssignment(ctx context.Context, groupID, userID int64, userName string, memberID *int64, metadata *string) (*domain.Member, int64, error) {
-	var assignedMember *domain.Member
-	var err error
+// assignmentRequest carries the caller's input through RecordAssignment
+type assignmentRequest struct {
+	groupID        int64
+	userID         int64
+	metadata       *string
+	externalRef    *string
+	idempotencyKey string
+}
+
+// recordManualAssignment assigns to the member chosen by the caller. Manual
+// assignments skip the strategy but not the rules: the group must not be
+// paused and the member must be available and within their capacity limits,
+// checked under the group's lock like automatic picks.
+func (uc *AssignmentUseCase) recordManualAssignment(ctx context.Context, req assignmentRequest, memberID int64) (*AssignmentResult, error) {
+	groupID := req.groupID
+	if _, err := uc.lockForAssignment(ctx, groupID); err != nil {
+		return nil, err
+	}
+
+	if previous, err := uc.previousResult(ctx, req, []int64{groupID}); previous != nil || err != nil {
+		return previous, err
+	}
+
+	member, err := uc.memberRepo.GetByID(ctx, memberID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if member == nil || member.GroupID != groupID || !member.Active {
+		return nil, errors.New("invalid or inactive member ID provided")
+	}
 
-	if memberID == nil {
-		assignedMember, err = uc.CalculateNextAssignee(ctx, groupID)
+	available, err := uc.availableMembers(ctx, []*domain.Member{member}, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if len(available) == 0 {
+		return nil, ErrMemberUnavailable
+	}
+	if len(uc.eligibleMembers(ctx, available)) == 0 {
+		return nil, ErrMemberAtCapacity
+	}
+
+	return uc.createAssignment(ctx, member, req.metadata, req.externalRef, false)
+}
+
+// recordAutomaticAssignment assigns to the member the group's strategy picks
+func (uc *AssignmentUseCase) recordAutomaticAssignment(ctx context.Context, req assignmentRequest) (*AssignmentResult, error) {
+	groupID := req.groupID
+	group, err := uc.lockForAssignment(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The assignment may have landed in the overflow group, which is locked too
+	if previous, err := uc.previousResult(ctx, req, assignmentGroupIDs(group)); previous != nil || err != nil {
+		return previous, err
+	}
+
+	member, err := uc.selectAssignee(ctx, group, time.Now())
+	if isUnstaffed(err) && uc.queuesOffHours(group) {
+		item := &domain.QueuedAssignment{
+			GroupID:     groupID,
+			Metadata:    req.metadata,
+			ExternalRef: req.externalRef,
+			QueuedBy:    req.userID,
+		}
+		if err := uc.queueRepo.Enqueue(ctx, item); err != nil {
+			return nil, err
+		}
+		return &AssignmentResult{GroupID: groupID, QueuedID: item.ID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.createAssignment(ctx, member, req.metadata, req.externalRef, true)
+}
+
+// previousResult returns the result of an earlier request with the same
+// idempotency key, or with the same external reference in one of groupIDs.
+// It returns nil when the request is new.
+func (uc *AssignmentUseCase) previousResult(ctx context.Context, req assignmentRequest, groupIDs []int64) (*AssignmentResult, error) {
+	if req.idempotencyKey != "" {
+		record, err := uc.idempotencyRepo.Get(ctx, req.groupID, req.idempotencyKey, time.Now())
 		if err != nil {
-			return nil, 0, err
+			return nil, err
 		}
-	} else {
-		assignedMember, err = uc.memberRepo.GetByID(ctx, *memberID)
+		if record != nil && record.QueuedID != nil {
+			return &AssignmentResult{GroupID: req.groupID, QueuedID: *record.QueuedID, Replayed: true}, nil
+		}
+		if record != nil && record.AssignmentID != nil {
+			assignment, err := uc.assignmentRepo.GetByID(ctx, *record.AssignmentID)
+			if err != nil {
+				return nil, err
+			}
+			return uc.replayAssignment(ctx, assignment)
+		}
+	}
+
+	if req.externalRef == nil {
+		return nil, nil
+	}
+	for _, groupID := range groupIDs {
+		assignment, err := uc.assignmentRepo.GetByExternalRef(ctx, groupID, *req.externalRef)
 		if err != nil {
-			return nil, 0, err
+			return nil, err
 		}
-		if assignedMember == nil || assignedMember.GroupID != groupID || !assignedMember.Active {
-			return nil, 0, errors.New("invalid or inactive member ID provided")
+		if assignment != nil {
+			return uc.replayAssignment(ctx, assignment)
 		}
 	}
+	item, err := uc.queueRepo.GetByExternalRef(ctx, req.groupID, *req.externalRef)
+	if err != nil {
+		return nil, err
+	}
+	if item != nil {
+		return &AssignmentResult{GroupID: item.GroupID, QueuedID: item.ID, Replayed: true}, nil
+	}
+	return nil, nil
+}
+
+// replayAssignment describes an existing assignment as a replayed result
+func (uc *AssignmentUseCase) replayAssignment(ctx context.Context, assignment *domain.Assignment) (*AssignmentResult, error) {
+	member, err := uc.memberRepo.GetByID(ctx, assignment.MemberID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	return &AssignmentResult{
+		AssignmentID: assignment.ID,
+		GroupID:      assignment.GroupID,
+		Member:       member,
+		Replayed:     true,
+	}, nil
+}
+
+// rememberResult stores result under the idempotency key until the key expires
+func (uc *AssignmentUseCase) rememberResult(ctx context.Context, groupID int64, key string, result *AssignmentResult) error {
+	record := &domain.IdempotencyRecord{
+		GroupID:   groupID,
+		Key:       key,
+		ExpiresAt: time.Now().Add(uc.idempotencyTTL),
+	}
+	if result.Queued() {
+		record.QueuedID = &result.QueuedID
+	} else {
+		record.AssignmentID = &result.AssignmentID
+	}
+	return uc.idempotencyRepo.Create(ctx, record)
+}
+
+// queuesOffHours reports whether the group queues work while nobody is on shift
+func (uc *AssignmentUseCase) queuesOffHours(group *domain.Group) bool {
+	settings, err := group.ParseSettings()
+	return err == nil && settings.Fallback() == domain.OffHoursQueue
+}
+
+// acceptanceDeadline returns when a new assignment in group must be accepted
+// by, or nil when the group does not ask for acceptance
+func acceptanceDeadline(group *domain.Group, now time.Time) *time.Time {
+	settings, err := group.ParseSettings()
+	if err != nil {
+		return nil
+	}
+	window, err := settings.AcceptanceTimeout()
+	if err != nil || window == 0 {
+		return nil
+	}
+	acceptBy := now.Add(window)
+	return &acceptBy
+}
+
+// createAssignment records an assignment to member in the member's group,
+// pending acceptance when the group asks for it. It must run inside a
+// transaction holding the group's lock.
+func (uc *AssignmentUseCase) createAssignment(ctx context.Context, member *domain.Member, metadata, externalRef *string, automatic bool) (*AssignmentResult, error) {
+	group, err := uc.groupRepo.GetByID(ctx, member.GroupID)
+	if err != nil {
+		return nil, err
+	}
 
 	assignment := &domain.Assignment{
-		GroupID:  groupID,
-		MemberID: assignedMember.ID,
-		Metadata: metadata,
+		GroupID:     member.GroupID,
+		MemberID:    member.ID,
+		Metadata:    metadata,
+		ExternalRef: externalRef,
+		AcceptBy:    acceptanceDeadline(group, time.Now()),
+	}
+	if assignment.AcceptBy != nil {
+		assignment.Status = domain.AssignmentStatusPendingAcceptance
 	}
 
 	if err := uc.assignmentRepo.Create(ctx, assignment); err != nil {
-		return nil, 0, err
+		return nil, err
+	}
+
+	if err := uc.memberRepo.IncrementOpenAssignments(ctx, member.ID); err != nil {
+		return nil, err
+	}
+
+	// Only automatic picks advance the rotation; manual assignments are out of turn.
+	// The cursor is kept for every strategy so switching to strict rotation
+	// carries on from the last pick.
+	if automatic {
+		if err := uc.cursorRepo.Set(ctx, member.GroupID, member.ID); err != nil {
+			return nil, err
+		}
 	}
 
-	_ = uc.memberRepo.IncrementOpenAssignments(ctx, assignedMember.ID)
+	return &AssignmentResult{AssignmentID: assignment.ID, GroupID: member.GroupID, Member: member}, nil
+}
+
+// TransitionAssignment moves an assignment to status. Pending assignments can
+// be accepted or cancelled, open ones completed or cancelled and closed ones
+// reopened; anything else is rejected with ErrIllegalTransition. The member's
+// open assignment counter moves with the status in the same transaction.
+// Reopening does not check capacity since the work already belongs to the
+// member.
+func (uc *AssignmentUseCase) TransitionAssignment(ctx context.Context, id, actorID int64, status domain.AssignmentStatus, reason *string) (*domain.Assignment, error) {
+	if !status.IsValid() {
+		return nil, ErrInvalidStatus
+	}
+
+	return uc.withLockedAssignment(ctx, id, func(ctx context.Context, group *domain.Group, current *domain.Assignment) error {
+		if !current.Status.CanTransitionTo(status) {
+			return fmt.Errorf("%w from %s to %s", ErrIllegalTransition, current.Status, status)
+		}
+		return uc.changeStatus(ctx, current, status, domain.AssignmentEventStatusChanged, &actorID, reason)
+	})
+}
+
+// AcceptAssignment confirms a pending assignment, which becomes open. Only
+// the assigned member or a manager of the group, as given by actorRole, may
+// accept.
+func (uc *AssignmentUseCase) AcceptAssignment(ctx context.Context, id int64, actor *domain.User, actorRole domain.GroupRole) (*domain.Assignment, error) {
+	return uc.withLockedAssignment(ctx, id, func(ctx context.Context, group *domain.Group, current *domain.Assignment) error {
+		if current.Status != domain.AssignmentStatusPendingAcceptance {
+			return ErrNotPendingAcceptance
+		}
+		if err := uc.checkResponder(ctx, current, actor, actorRole); err != nil {
+			return err
+		}
+		return uc.changeStatus(ctx, current, domain.AssignmentStatusOpen, domain.AssignmentEventAccepted, &actor.ID, nil)
+	})
+}
 
-	return assignedMember, assignment.ID, nil
+// DeclineAssignment turns down a pending assignment. Like accepting, only the
+// assigned member or a manager of the group may decline. The acceptance
+// window is closed at once, so the acceptance sweeper hands the work to the
+// next fair candidate who has not declined it.
+func (uc *AssignmentUseCase) DeclineAssignment(ctx context.Context, id int64, actor *domain.User, actorRole domain.GroupRole, reason *string) (*domain.Assignment, error) {
+	return uc.withLockedAssignment(ctx, id, func(ctx context.Context, group *domain.Group, current *domain.Assignment) error {
+		if current.Status != domain.AssignmentStatusPendingAcceptance {
+			return ErrNotPendingAcceptance
+		}
+		if err := uc.checkResponder(ctx, current, actor, actorRole); err != nil {
+			return err
+		}
+		if err := uc.assignmentRepo.SetAcceptBy(ctx, current.ID, time.Now()); err != nil {
+			return err
+		}
+		return uc.eventRepo.Create(ctx, &domain.AssignmentEvent{
+			AssignmentID: current.ID,
+			Type:         domain.AssignmentEventDeclined,
+			FromMemberID: &current.MemberID,
+			Reason:       reason,
+			ActorID:      &actor.ID,
+		})
+	})
+}
+
+// checkResponder checks that actor may answer for the assignment. Members are
+// linked to users by email address; managers may answer on a member's behalf.
+func (uc *AssignmentUseCase) checkResponder(ctx context.Context, assignment *domain.Assignment, actor *domain.User, actorRole domain.GroupRole) error {
+	if actorRole.Includes(domain.GroupRoleManager) {
+		return nil
+	}
+	member, err := uc.memberRepo.GetByID(ctx, assignment.MemberID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if member == nil || member.Email == nil || !strings.EqualFold(*member.Email, actor.Email) {
+		return ErrNotAssignedMember
+	}
+	return nil
+}
+
+// changeStatus updates the assignment's status, keeps the member's open
+// assignment counter in step and records the change
+func (uc *AssignmentUseCase) changeStatus(ctx context.Context, assignment *domain.Assignment, status domain.AssignmentStatus, eventType domain.AssignmentEventType, actorID *int64, reason *string) error {
+	if err := uc.assignmentRepo.UpdateStatus(ctx, assignment.ID, status); err != nil {
+		return err
+	}
+
+	var err error
+	switch {
+	case !assignment.Status.HoldsCapacity() && status.HoldsCapacity():
+		err = uc.memberRepo.IncrementOpenAssignments(ctx, assignment.MemberID)
+	case assignment.Status.HoldsCapacity() && !status.HoldsCapacity():
+		err = uc.memberRepo.DecrementOpenAssignments(ctx, assignment.MemberID)
+	}
+	if err != nil {
+		return err
+	}
+
+	return uc.eventRepo.Create(ctx, &domain.AssignmentEvent{
+		AssignmentID: assignment.ID,
+		Type:         eventType,
+		FromStatus:   &assignment.Status,
+		ToStatus:     &status,
+		Reason:       reason,
+		ActorID:      actorID,
+	})
+}
+
+// ReassignAssignment hands an open or pending assignment to another member of
+// its group: the target member when one is given, otherwise the next fair
+// assignee other than the current one. Both members' open counters move in
+// the same transaction and the move is recorded in the assignment's history.
+// Stats and strategies count an assignment for the member holding it, so the
+// new member is credited with the work.
+func (uc *AssignmentUseCase) ReassignAssignment(ctx context.Context, id, actorID int64, targetMemberID *int64, reason *string) (*AssignmentResult, error) {
+	var target *domain.Member
+	assignment, err := uc.withLockedAssignment(ctx, id, func(ctx context.Context, group *domain.Group, current *domain.Assignment) error {
+		if !current.Status.HoldsCapacity() {
+			return ErrAssignmentNotOpen
+		}
+
+		var err error
+		if targetMemberID != nil {
+			target, err = uc.reassignTarget(ctx, current, *targetMemberID)
+		} else {
+			target, err = uc.pickReplacement(ctx, group, map[int64]bool{current.MemberID: true})
+		}
+		if err != nil {
+			return err
+		}
+
+		return uc.moveAssignment(ctx, group, current, target, targetMemberID == nil, &actorID, reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &AssignmentResult{AssignmentID: assignment.ID, GroupID: assignment.GroupID, Member: target}, nil
+}
+
+// moveAssignment hands assignment to target, moving the open counters and
+// recording the move. A pending assignment gives the new member a fresh
+// acceptance window. Fair picks take their turn in the rotation like any
+// automatic pick.
+func (uc *AssignmentUseCase) moveAssignment(ctx context.Context, group *domain.Group, assignment *domain.Assignment, target *domain.Member, fairPick bool, actorID *int64, reason *string) error {
+	if err := uc.assignmentRepo.Reassign(ctx, assignment.ID, target.ID); err != nil {
+		return err
+	}
+	if err := uc.memberRepo.DecrementOpenAssignments(ctx, assignment.MemberID); err != nil {
+		return err
+	}
+	if err := uc.memberRepo.IncrementOpenAssignments(ctx, target.ID); err != nil {
+		return err
+	}
+	if fairPick {
+		if err := uc.cursorRepo.Set(ctx, group.ID, target.ID); err != nil {
+			return err
+		}
+	}
+
+	if assignment.Status == domain.AssignmentStatusPendingAcceptance {
+		var err error
+		if acceptBy := acceptanceDeadline(group, time.Now()); acceptBy != nil {
+			err = uc.assignmentRepo.SetAcceptBy(ctx, assignment.ID, *acceptBy)
+		} else {
+			// The group stopped asking for acceptance since the assignment was made
+			err = uc.assignmentRepo.UpdateStatus(ctx, assignment.ID, domain.AssignmentStatusOpen)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return uc.eventRepo.Create(ctx, &domain.AssignmentEvent{
+		AssignmentID: assignment.ID,
+		Type:         domain.AssignmentEventReassigned,
+		FromMemberID: &assignment.MemberID,
+		ToMemberID:   &target.ID,
+		Reason:       reason,
+		ActorID:      actorID,
+	})
+}
+
+// withLockedAssignment runs fn in a transaction holding the lock of the
+// assignment's group, passing the assignment as read under the lock so
+// concurrent changes cannot both apply. It returns the assignment as fn left
+// it.
+func (uc *AssignmentUseCase) withLockedAssignment(ctx context.Context, id int64, fn func(ctx context.Context, group *domain.Group, current *domain.Assignment) error) (*domain.Assignment, error) {
+	assignment, err := uc.findAssignment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = uc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		group, err := uc.groupRepo.LockByID(ctx, assignment.GroupID)
+		if err != nil {
+			return err
+		}
+		if group == nil {
+			return ErrGroupNotFound
+		}
+		current, err := uc.findAssignment(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := fn(ctx, group, current); err != nil {
+			return err
+		}
+
+		assignment, err = uc.findAssignment(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return assignment, nil
+}
+
+// reassignTarget checks that the chosen member can take over the assignment.
+// Explicit targets are not held to capacity limits: the work already exists
+// and has to land somewhere.
+func (uc *AssignmentUseCase) reassignTarget(ctx context.Context, assignment *domain.Assignment, memberID int64) (*domain.Member, error) {
+	member, err := uc.memberRepo.GetByID(ctx, memberID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if member == nil || member.GroupID != assignment.GroupID || !member.Active || member.ID == assignment.MemberID {
+		return nil, ErrInvalidReassignTarget
+	}
+
+	available, err := uc.availableMembers(ctx, []*domain.Member{member}, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if len(available) == 0 {
+		return nil, ErrMemberUnavailable
+	}
+	return member, nil
+}
+
+// pickReplacement picks the next fair assignee within group, leaving out
+// exclude. Working hours are waived under the ignore_hours fallback; the other
+// fallbacks do not apply because the work stays in its group.
+func (uc *AssignmentUseCase) pickReplacement(ctx context.Context, group *domain.Group, exclude map[int64]bool) (*domain.Member, error) {
+	now := time.Now()
+	member, err := uc.pickMember(ctx, group, now, true, exclude)
+	if errors.Is(err, ErrNoMemberOnShift) {
+		if settings, settingsErr := group.ParseSettings(); settingsErr == nil && settings.Fallback() == domain.OffHoursIgnoreHours {
+			return uc.pickMember(ctx, group, now, false, exclude)
+		}
+	}
+	return member, err
+}
+
+// GetAssignmentHistory returns the assignment's events, oldest first
+func (uc *AssignmentUseCase) GetAssignmentHistory(ctx context.Context, id int64) ([]*domain.AssignmentEvent, error) {
+	if _, err := uc.findAssignment(ctx, id); err != nil {
+		return nil, err
+	}
+	return uc.eventRepo.GetByAssignmentID(ctx, id)
+}
+
+// findAssignment loads an assignment, mapping a missing row to ErrAssignmentNotFound
+func (uc *AssignmentUseCase) findAssignment(ctx context.Context, id int64) (*domain.Assignment, error) {
+	assignment, err := uc.assignmentRepo.GetByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && assignment == nil) {
+		return nil, ErrAssignmentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return assignment, nil
 }
 
 // GetAssignments retrieves assignments for a group with pagination
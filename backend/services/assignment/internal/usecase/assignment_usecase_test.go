@@ -0,0 +1,183 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/raufhm/fairflow/services/assignment/internal/usecase"
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+func strPtr(v string) *string {
+	return &v
+}
+
+func TestRecordAssignment_ManualRespectsPauseAndCapacity(t *testing.T) {
+	tests := []struct {
+		name    string
+		group   *domain.Group
+		member  *domain.Member
+		wantErr error
+	}{
+		{
+			name:    "paused group",
+			group:   &domain.Group{ID: 1, AssignmentPaused: true},
+			member:  &domain.Member{ID: 1, GroupID: 1, Weight: 1, Active: true, Available: true},
+			wantErr: usecase.ErrAssignmentsPaused,
+		},
+		{
+			name:    "member at their open limit",
+			group:   &domain.Group{ID: 1},
+			member:  &domain.Member{ID: 1, GroupID: 1, Weight: 1, Active: true, Available: true, MaxConcurrentOpen: intPtr(0)},
+			wantErr: usecase.ErrMemberAtCapacity,
+		},
+		{
+			name:    "member at their daily limit",
+			group:   &domain.Group{ID: 1},
+			member:  &domain.Member{ID: 1, GroupID: 1, Weight: 1, Active: true, Available: true, MaxDailyAssignments: intPtr(0)},
+			wantErr: usecase.ErrMemberAtCapacity,
+		},
+		{
+			name:    "unavailable member",
+			group:   &domain.Group{ID: 1},
+			member:  &domain.Member{ID: 1, GroupID: 1, Weight: 1, Active: true, Available: false},
+			wantErr: usecase.ErrMemberUnavailable,
+		},
+		{
+			name:   "member with room",
+			group:  &domain.Group{ID: 1},
+			member: &domain.Member{ID: 1, GroupID: 1, Weight: 1, Active: true, Available: true, MaxConcurrentOpen: intPtr(1)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStore([]*domain.Group{tt.group}, []*domain.Member{tt.member})
+
+			result, err := newUseCase(s).RecordAssignment(context.Background(), 1, 1, "dispatcher", int64Ptr(1), nil, nil, "")
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Equal(t, 0, s.dailyAssignments(1))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), result.Member.ID)
+			assert.Equal(t, 1, s.openAssignments(1))
+		})
+	}
+}
+
+func TestRecordAssignments_ManualItemsRespectCapacity(t *testing.T) {
+	s := newStore(
+		[]*domain.Group{{ID: 1, Strategy: domain.StrategyWeightedRoundRobin}},
+		[]*domain.Member{
+			{ID: 1, GroupID: 1, Weight: 1, Active: true, Available: true, MaxConcurrentOpen: intPtr(1)},
+		},
+	)
+	items := []usecase.BatchItem{{MemberID: int64Ptr(1)}, {MemberID: int64Ptr(1)}}
+
+	results, err := newUseCase(s).RecordAssignments(context.Background(), 1, 1, "dispatcher", items, false)
+
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.NoError(t, results[0].Err)
+	assert.ErrorIs(t, results[1].Err, usecase.ErrMemberAtCapacity)
+	assert.Equal(t, 1, s.openAssignments(1))
+}
+
+func TestRecordAssignments_FailedItemRollsBackOnlyItsOwnWrites(t *testing.T) {
+	s := newStore(
+		[]*domain.Group{{ID: 1, Strategy: domain.StrategyWeightedRoundRobin}},
+		[]*domain.Member{
+			{ID: 1, GroupID: 1, Weight: 1, Active: true, Available: true},
+			{ID: 2, GroupID: 1, Weight: 1, Active: true, Available: true},
+		},
+	)
+	// The manual item's assignment row is written before its counter update fails
+	s.failIncrementFor = 2
+	items := []usecase.BatchItem{{MemberID: int64Ptr(1)}, {MemberID: int64Ptr(2)}, {MemberID: int64Ptr(1)}}
+
+	results, err := newUseCase(s).RecordAssignments(context.Background(), 1, 1, "dispatcher", items, false)
+
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.NoError(t, results[0].Err)
+	assert.Error(t, results[1].Err)
+	assert.NoError(t, results[2].Err)
+	assert.Equal(t, 2, s.dailyAssignments(1))
+	assert.Equal(t, 0, s.dailyAssignments(2))
+	assert.Equal(t, 2, s.openAssignments(1))
+}
+
+func TestRecordAssignments_AllOrNothingRollsBackEveryItem(t *testing.T) {
+	s := newStore(
+		[]*domain.Group{{ID: 1, Strategy: domain.StrategyWeightedRoundRobin}},
+		[]*domain.Member{
+			{ID: 1, GroupID: 1, Weight: 1, Active: true, Available: true},
+			{ID: 2, GroupID: 1, Weight: 1, Active: true, Available: true},
+		},
+	)
+	s.failIncrementFor = 2
+	items := []usecase.BatchItem{{MemberID: int64Ptr(1)}, {MemberID: int64Ptr(2)}}
+
+	results, err := newUseCase(s).RecordAssignments(context.Background(), 1, 1, "dispatcher", items, true)
+
+	assert.ErrorIs(t, err, usecase.ErrBatchRolledBack)
+	require.Len(t, results, 2)
+	assert.NoError(t, results[0].Err)
+	assert.Error(t, results[1].Err)
+	assert.Equal(t, 0, s.dailyAssignments(1))
+	assert.Equal(t, 0, s.dailyAssignments(2))
+	assert.Equal(t, 0, s.openAssignments(1))
+}
+
+func TestAcceptAssignment_OnlyAssignedMemberOrManager(t *testing.T) {
+	tests := []struct {
+		name    string
+		actor   *domain.User
+		role    domain.GroupRole
+		wantErr error
+	}{
+		{name: "assigned member", actor: &domain.User{ID: 1, Email: "Ana@Example.com"}, role: domain.GroupRoleViewer},
+		{name: "manager on the member's behalf", actor: &domain.User{ID: 2, Email: "lead@example.com"}, role: domain.GroupRoleManager},
+		{name: "dispatcher", actor: &domain.User{ID: 3, Email: "desk@example.com"}, role: domain.GroupRoleDispatcher, wantErr: usecase.ErrNotAssignedMember},
+		{name: "another member", actor: &domain.User{ID: 4, Email: "ben@example.com"}, role: domain.GroupRoleViewer, wantErr: usecase.ErrNotAssignedMember},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window := `{"acceptance_window":"30m"}`
+			s := newStore(
+				[]*domain.Group{{ID: 1, Strategy: domain.StrategyWeightedRoundRobin, Settings: &window}},
+				[]*domain.Member{
+					{ID: 1, GroupID: 1, Weight: 1, Active: true, Available: true, Email: strPtr("ana@example.com")},
+					{ID: 2, GroupID: 1, Weight: 1, Active: true, Available: true, Email: strPtr("ben@example.com")},
+				},
+			)
+			uc := newUseCase(s)
+			id := s.pending(1, 1, time.Now().Add(time.Hour))
+
+			_, acceptErr := uc.AcceptAssignment(context.Background(), id, tt.actor, tt.role)
+			_, declineErr := uc.DeclineAssignment(context.Background(), id, tt.actor, tt.role, nil)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, acceptErr, tt.wantErr)
+				assert.ErrorIs(t, declineErr, tt.wantErr)
+				assert.Equal(t, domain.AssignmentStatusPendingAcceptance, s.assignment(id).Status)
+				return
+			}
+			require.NoError(t, acceptErr)
+			// Once accepted there is nothing left to decline
+			assert.ErrorIs(t, declineErr, usecase.ErrNotPendingAcceptance)
+			assert.Equal(t, domain.AssignmentStatusOpen, s.assignment(id).Status)
+		})
+	}
+}
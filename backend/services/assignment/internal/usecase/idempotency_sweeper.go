@@ -0,0 +1,41 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/logger"
+	"go.uber.org/zap"
+)
+
+// IdempotencySweeper deletes idempotency records once they have expired.
+// Expired records are already ignored; sweeping only keeps the table small.
+type IdempotencySweeper struct {
+	idempotencyRepo domain.IdempotencyRepository
+}
+
+func NewIdempotencySweeper(idempotencyRepo domain.IdempotencyRepository) *IdempotencySweeper {
+	return &IdempotencySweeper{idempotencyRepo: idempotencyRepo}
+}
+
+// Run sweeps every interval until ctx is cancelled
+func (s *IdempotencySweeper) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := s.idempotencyRepo.DeleteExpired(ctx, time.Now())
+		if err != nil {
+			logger.Log.Error("Failed to delete expired idempotency keys", zap.Error(err))
+		} else if deleted > 0 {
+			logger.Log.Info("Deleted expired idempotency keys", zap.Int("count", deleted))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
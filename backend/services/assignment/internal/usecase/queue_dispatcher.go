@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/logger"
+	"go.uber.org/zap"
+)
+
+// queueBatchSize bounds how many of a group's queued items are dispatched per pass
+const queueBatchSize = 100
+
+// QueueDispatcher assigns queued requests once members are back on shift or
+// return from time off
+type QueueDispatcher struct {
+	assignments *AssignmentUseCase
+	queueRepo   domain.AssignmentQueueRepository
+}
+
+func NewQueueDispatcher(assignments *AssignmentUseCase, queueRepo domain.AssignmentQueueRepository) *QueueDispatcher {
+	return &QueueDispatcher{
+		assignments: assignments,
+		queueRepo:   queueRepo,
+	}
+}
+
+// Run dispatches the queue every interval until ctx is cancelled
+func (d *QueueDispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.Dispatch(ctx); err != nil {
+			logger.Log.Error("Failed to dispatch queued assignments", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch assigns queued items group by group, starting with the group that
+// has waited longest, and returns how many were assigned. Each group's items
+// go oldest first; once one has to stay queued because the group is paused or
+// nobody can take work, the group's later items wait too so the order is kept.
+// A blocked group never holds up the others.
+func (d *QueueDispatcher) Dispatch(ctx context.Context) (int, error) {
+	groupIDs, err := d.queueRepo.GetQueuedGroupIDs(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	dispatched := 0
+	for _, groupID := range groupIDs {
+		assigned, err := d.dispatchGroup(ctx, groupID, now)
+		dispatched += assigned
+		if err != nil {
+			return dispatched, err
+		}
+	}
+
+	return dispatched, nil
+}
+
+// dispatchGroup assigns up to queueBatchSize of a group's queued items and
+// returns how many were assigned
+func (d *QueueDispatcher) dispatchGroup(ctx context.Context, groupID int64, now time.Time) (int, error) {
+	items, err := d.queueRepo.GetPendingByGroupID(ctx, groupID, queueBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	dispatched := 0
+	for _, item := range items {
+		var assigned bool
+		err := d.assignments.transactor.WithinTx(ctx, func(ctx context.Context) error {
+			var err error
+			assigned, err = d.dispatchItem(ctx, item, now)
+			return err
+		})
+		if err != nil {
+			if !isUnstaffed(err) && !errors.Is(err, ErrAssignmentsPaused) {
+				logger.Log.Warn("Queued assignment is still waiting", zap.Int64("queue_id", item.ID), zap.Error(err))
+			}
+			break
+		}
+		if assigned {
+			dispatched++
+		}
+	}
+
+	return dispatched, nil
+}
+
+// dispatchItem assigns one queued item under the group's lock and removes it
+// from the queue. Idempotency records of the request are pointed at the new
+// assignment so a retry replays it rather than the queued item. Items of
+// deleted groups are dropped without being assigned.
+func (d *QueueDispatcher) dispatchItem(ctx context.Context, item *domain.QueuedAssignment, now time.Time) (bool, error) {
+	group, err := d.assignments.lockForAssignment(ctx, item.GroupID)
+	if errors.Is(err, ErrGroupNotFound) {
+		// The group is gone, so the item can never be assigned
+		return false, d.queueRepo.Delete(ctx, item.ID)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	member, err := d.assignments.selectAssignee(ctx, group, now)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := d.assignments.createAssignment(ctx, member, item.Metadata, item.ExternalRef, true)
+	if err != nil {
+		return false, err
+	}
+	if err := d.assignments.idempotencyRepo.ResolveQueued(ctx, item.ID, result.AssignmentID); err != nil {
+		return false, err
+	}
+	return true, d.queueRepo.Delete(ctx, item.ID)
+}
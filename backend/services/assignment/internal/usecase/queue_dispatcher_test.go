@@ -0,0 +1,219 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/raufhm/fairflow/services/assignment/internal/usecase"
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type queueRepo struct {
+	domain.AssignmentQueueRepository
+	s *store
+}
+
+func (r *queueRepo) Enqueue(ctx context.Context, item *domain.QueuedAssignment) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.queueSeq++
+	item.ID = r.s.queueSeq
+	if item.CreatedAt.IsZero() {
+		item.CreatedAt = time.Now()
+	}
+	r.s.queue = append(r.s.queue, item)
+	onRollback(ctx, func() {
+		r.s.mu.Lock()
+		defer r.s.mu.Unlock()
+		for i, queued := range r.s.queue {
+			if queued == item {
+				r.s.queue = append(r.s.queue[:i], r.s.queue[i+1:]...)
+				return
+			}
+		}
+	})
+	return nil
+}
+
+func (r *queueRepo) GetQueuedGroupIDs(ctx context.Context) ([]int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	seen := make(map[int64]bool)
+	groupIDs := []int64{}
+	// The queue is kept oldest first
+	for _, item := range r.s.queue {
+		if !seen[item.GroupID] {
+			seen[item.GroupID] = true
+			groupIDs = append(groupIDs, item.GroupID)
+		}
+	}
+	return groupIDs, nil
+}
+
+func (r *queueRepo) GetPendingByGroupID(ctx context.Context, groupID int64, limit int) ([]*domain.QueuedAssignment, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	items := []*domain.QueuedAssignment{}
+	for _, item := range r.s.queue {
+		if item.GroupID == groupID && len(items) < limit {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func (r *queueRepo) Delete(ctx context.Context, id int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for i, item := range r.s.queue {
+		if item.ID == id {
+			r.s.queue = append(r.s.queue[:i], r.s.queue[i+1:]...)
+			onRollback(ctx, func() {
+				r.s.mu.Lock()
+				defer r.s.mu.Unlock()
+				r.s.queue = append(r.s.queue[:i], append([]*domain.QueuedAssignment{item}, r.s.queue[i:]...)...)
+			})
+			return nil
+		}
+	}
+	return nil
+}
+
+type idempotencyRepo struct {
+	domain.IdempotencyRepository
+	s *store
+}
+
+func (r *idempotencyRepo) Create(ctx context.Context, record *domain.IdempotencyRecord) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.idempotency = append(r.s.idempotency, record)
+	return nil
+}
+
+func (r *idempotencyRepo) Get(ctx context.Context, groupID int64, key string, at time.Time) (*domain.IdempotencyRecord, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, record := range r.s.idempotency {
+		if record.GroupID == groupID && record.Key == key && record.ExpiresAt.After(at) {
+			copied := *record
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *idempotencyRepo) ResolveQueued(ctx context.Context, queuedID, assignmentID int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, record := range r.s.idempotency {
+		if record.QueuedID != nil && *record.QueuedID == queuedID {
+			record.AssignmentID, record.QueuedID = &assignmentID, nil
+		}
+	}
+	return nil
+}
+
+func (s *store) queued(groupID int64) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := 0
+	for _, item := range s.queue {
+		if item.GroupID == groupID {
+			count++
+		}
+	}
+	return count
+}
+
+func TestQueueDispatcher_PausedGroupDoesNotBlockOthers(t *testing.T) {
+	s := newStore(
+		[]*domain.Group{
+			{ID: 1, Strategy: domain.StrategyWeightedRoundRobin, AssignmentPaused: true},
+			{ID: 2, Strategy: domain.StrategyWeightedRoundRobin},
+		},
+		[]*domain.Member{
+			{ID: 1, GroupID: 1, Weight: 1, Active: true, Available: true},
+			{ID: 2, GroupID: 2, Weight: 1, Active: true, Available: true},
+		},
+	)
+	queue := &queueRepo{s: s}
+	ctx := context.Background()
+
+	// The paused group's backlog is older and larger than a whole batch
+	queuedAt := time.Now().Add(-time.Hour)
+	for i := 0; i < 150; i++ {
+		require.NoError(t, queue.Enqueue(ctx, &domain.QueuedAssignment{GroupID: 1, CreatedAt: queuedAt}))
+	}
+	for i := 0; i < 3; i++ {
+		require.NoError(t, queue.Enqueue(ctx, &domain.QueuedAssignment{GroupID: 2, CreatedAt: time.Now()}))
+	}
+
+	dispatcher := usecase.NewQueueDispatcher(newUseCase(s), queue)
+	dispatched, err := dispatcher.Dispatch(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, dispatched)
+	assert.Equal(t, 0, s.queued(2))
+	assert.Equal(t, 150, s.queued(1))
+	assert.Equal(t, 3, s.openAssignments(2))
+	assert.Equal(t, 0, s.openAssignments(1))
+}
+
+func TestQueueDispatcher_KeepsOrderWithinAGroup(t *testing.T) {
+	s := newStore(
+		[]*domain.Group{{ID: 1, Strategy: domain.StrategyWeightedRoundRobin}},
+		[]*domain.Member{
+			{ID: 1, GroupID: 1, Weight: 1, Active: true, Available: true, MaxConcurrentOpen: intPtr(2)},
+		},
+	)
+	queue := &queueRepo{s: s}
+	ctx := context.Background()
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, queue.Enqueue(ctx, &domain.QueuedAssignment{GroupID: 1}))
+	}
+
+	dispatcher := usecase.NewQueueDispatcher(newUseCase(s), queue)
+	dispatched, err := dispatcher.Dispatch(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, dispatched)
+	// The two oldest items were assigned; the newer ones wait their turn
+	require.Equal(t, 2, s.queued(1))
+	assert.Equal(t, int64(3), s.queue[0].ID)
+	assert.Equal(t, int64(4), s.queue[1].ID)
+}
+
+func TestQueueDispatcher_RetryAfterDispatchReplaysTheAssignment(t *testing.T) {
+	s := newStore(
+		[]*domain.Group{{ID: 1, Strategy: domain.StrategyWeightedRoundRobin}},
+		[]*domain.Member{{ID: 1, GroupID: 1, Weight: 1, Active: true, Available: false}},
+	)
+	uc := newUseCase(s)
+	ctx := context.Background()
+
+	// Nobody is available, so the request is queued
+	queued, err := uc.RecordAssignment(ctx, 1, 1, "dispatcher", nil, nil, nil, "retry-1")
+	require.NoError(t, err)
+	require.True(t, queued.Queued())
+
+	s.mu.Lock()
+	s.members[1].Available = true
+	s.mu.Unlock()
+	dispatched, err := usecase.NewQueueDispatcher(uc, &queueRepo{s: s}).Dispatch(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, dispatched)
+
+	replayed, err := uc.RecordAssignment(ctx, 1, 1, "dispatcher", nil, nil, nil, "retry-1")
+
+	require.NoError(t, err)
+	assert.True(t, replayed.Replayed)
+	assert.False(t, replayed.Queued())
+	assert.Equal(t, int64(1), replayed.AssignmentID)
+	assert.Equal(t, int64(1), replayed.Member.ID)
+	assert.Equal(t, 1, s.dailyAssignments(1))
+}
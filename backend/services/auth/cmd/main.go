@@ -6,16 +6,21 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/raufhm/fairflow/services/auth/internal/handler"
 	"github.com/raufhm/fairflow/services/auth/internal/usecase"
 	"github.com/raufhm/fairflow/shared/config"
+	"github.com/raufhm/fairflow/shared/crypto"
 	"github.com/raufhm/fairflow/shared/database"
+	"github.com/raufhm/fairflow/shared/domain"
 	"github.com/raufhm/fairflow/shared/health"
 	"github.com/raufhm/fairflow/shared/logger"
+	"github.com/raufhm/fairflow/shared/mailer"
 	"github.com/raufhm/fairflow/shared/middleware"
+	"github.com/raufhm/fairflow/shared/oidc"
 	"github.com/raufhm/fairflow/shared/repository/postgres"
 	"go.uber.org/zap"
 )
@@ -44,12 +49,116 @@ func main() {
 	// Initialize repositories
 	userRepo := postgres.NewUserRepository(db)
 	apiKeyRepo := postgres.NewAPIKeyRepository(db)
+	sessionRepo := postgres.NewSessionRepository(db)
+	refreshTokenRepo := postgres.NewRefreshTokenRepository(db)
+	resetTokenRepo := postgres.NewPasswordResetTokenRepository(db)
+	auditRepo := postgres.NewAuditLogRepository(db)
+	totpRepo := postgres.NewTOTPFactorRepository(db)
+	recoveryCodeRepo := postgres.NewRecoveryCodeRepository(db)
+	identityRepo := postgres.NewUserIdentityRepository(db)
+	throttleRepo := postgres.NewLoginThrottleRepository(db)
+	orgRepo := postgres.NewOrganizationRepository(db)
+	groupRepo := postgres.NewGroupRepository(db)
+	invitationRepo := postgres.NewInvitationRepository(db)
+	verificationTokenRepo := postgres.NewEmailVerificationTokenRepository(db)
+	transactor := postgres.NewTransactor(db)
 
-	// Initialize use case
-	authUseCase := usecase.NewAuthUseCase(userRepo, apiKeyRepo, cfg.JWTSecret)
+	// Initialize mailer
+	var mail mailer.Mailer
+	if cfg.SMTPHost != "" {
+		mail = mailer.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom)
+	} else {
+		logger.Log.Warn("SMTP_HOST not set, emails will be written to " + cfg.MailDir)
+		mail = mailer.NewFileMailer(cfg.MailDir)
+	}
+
+	// Load token signing keys
+	var keySet *crypto.KeySet
+	if cfg.JWTKeysDir != "" {
+		keySet, err = crypto.LoadKeySet(cfg.JWTKeysDir, cfg.JWTSigningKeyID)
+	} else if cfg.Environment == "development" {
+		logger.Log.Warn("JWT_KEYS_DIR not set, using an ephemeral signing key")
+		keySet, err = crypto.NewEphemeralKeySet()
+	} else {
+		err = fmt.Errorf("JWT_KEYS_DIR is required outside development")
+	}
+	if err != nil {
+		logger.Log.Fatal("Failed to load signing keys", zap.Error(err))
+	}
+	tokenService := crypto.NewTokenService(keySet).WithAccessTTL(cfg.AccessTokenTTL)
+
+	// Secrets stored by the auth service are encrypted with a key only it holds,
+	// so the JWT secret shared with every service cannot decrypt them
+	encryptionKey := cfg.SecretEncryptionKey
+	if encryptionKey == "" {
+		if cfg.Environment != "development" {
+			logger.Log.Fatal("SECRET_ENCRYPTION_KEY is required outside development")
+		}
+		logger.Log.Warn("SECRET_ENCRYPTION_KEY not set, deriving one from JWT_SECRET")
+		encryptionKey = crypto.HashToken("secret-encryption", cfg.JWTSecret)
+	} else if encryptionKey == cfg.JWTSecret {
+		logger.Log.Fatal("SECRET_ENCRYPTION_KEY must differ from JWT_SECRET")
+	}
+
+	// Initialize use cases
+	mfaRequiredRoles := make([]domain.UserRole, len(cfg.MFARequiredRoles))
+	for i, role := range cfg.MFARequiredRoles {
+		mfaRequiredRoles[i] = domain.UserRole(role)
+	}
+	mfaUseCase := usecase.NewMFAUseCase(userRepo, totpRepo, recoveryCodeRepo, orgRepo, tokenService, cfg.JWTSecret, encryptionKey, mfaRequiredRoles, cfg.MFAChallengeTTL)
+	guardPolicy := usecase.DefaultLoginGuardPolicy()
+	guardPolicy.AccountLockThreshold = cfg.LoginLockoutThreshold
+	guardPolicy.IPLockThreshold = cfg.LoginIPLockoutThreshold
+	guardPolicy.LockoutDuration = cfg.LoginLockoutDuration
+	loginGuard := usecase.NewLoginGuard(throttleRepo, userRepo, auditRepo, guardPolicy)
+	authUseCase := usecase.NewAuthUseCase(userRepo, apiKeyRepo, sessionRepo, refreshTokenRepo, tokenService, mfaUseCase, loginGuard, cfg.JWTSecret, cfg.RefreshTokenTTL, cfg.APIKeyRotationGrace, cfg.APIKeyExpiryWarning)
+	orgUseCase := usecase.NewOrganizationUseCase(orgRepo, userRepo, groupRepo, auditRepo)
+	userAdminUseCase := usecase.NewUserAdminUseCase(userRepo, apiKeyRepo, sessionRepo, auditRepo)
+	impersonationUseCase := usecase.NewImpersonationUseCase(userRepo, sessionRepo, auditRepo, tokenService, cfg.ImpersonationTTL)
+	passwordResetUseCase := usecase.NewPasswordResetUseCase(userRepo, resetTokenRepo, sessionRepo, auditRepo, mail, cfg.JWTSecret, cfg.AppBaseURL, cfg.PasswordResetTTL)
+	verificationUseCase := usecase.NewEmailVerificationUseCase(userRepo, verificationTokenRepo, auditRepo, mail, cfg.JWTSecret, cfg.AppBaseURL, cfg.EmailVerificationTTL)
+	invitationUseCase := usecase.NewInvitationUseCase(authUseCase, verificationUseCase, invitationRepo, userRepo, orgRepo, auditRepo, transactor, mail, cfg.JWTSecret, cfg.AppBaseURL, cfg.InvitationTTL)
 
 	// Initialize handler
-	authHandler := handler.NewAuthHandler(authUseCase)
+	// Single sign-on is optional
+	var ssoUseCase *usecase.SSOUseCase
+	if cfg.OIDCIssuerURL != "" {
+		roleMapping, err := usecase.ParseRoleMapping(cfg.OIDCRoleMapping)
+		if err != nil {
+			logger.Log.Fatal("Invalid OIDC_ROLE_MAPPING", zap.Error(err))
+		}
+		provider := oidc.NewProvider(oidc.Config{
+			IssuerURL:    cfg.OIDCIssuerURL,
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+			RedirectURL:  cfg.OIDCRedirectURL,
+			Scopes:       cfg.OIDCScopes,
+			GroupsClaim:  cfg.OIDCGroupsClaim,
+		}, nil)
+		var ssoOrganizationID *int64
+		if cfg.OIDCOrganizationID != 0 {
+			ssoOrganizationID = &cfg.OIDCOrganizationID
+		}
+		ssoUseCase = usecase.NewSSOUseCase(authUseCase, userRepo, identityRepo, invitationRepo, transactor, provider, roleMapping, ssoOrganizationID, encryptionKey, cfg.AppBaseURL)
+		logger.Log.Info("Single sign-on enabled", zap.String("issuer", cfg.OIDCIssuerURL))
+	}
+
+	expiryNotifier := usecase.NewAPIKeyExpiryNotifier(apiKeyRepo, userRepo, mail, cfg.AppBaseURL, cfg.APIKeyExpiryWarning)
+
+	authHandler := handler.NewAuthHandler(authUseCase, passwordResetUseCase, mfaUseCase, loginGuard, ssoUseCase)
+	orgHandler := handler.NewOrganizationHandler(orgUseCase)
+	invitationHandler := handler.NewInvitationHandler(invitationUseCase, verificationUseCase)
+	userAdminHandler := handler.NewUserAdminHandler(userAdminUseCase, impersonationUseCase)
+
+	// Initialize authentication middleware
+	authenticator := middleware.NewAuthenticator(userRepo, apiKeyRepo, sessionRepo, auditRepo, tokenService, cfg.JWTSecret)
+
+	// Internal endpoints only answer the services named on each route
+	serviceTokens, err := crypto.LoadServiceTokenService(crypto.AuthService, cfg.ServicePrivateKey, cfg.ServicePublicKeys, cfg.ServiceDevSecret, cfg.ServiceTokenTTL)
+	if err != nil {
+		logger.Log.Fatal("Failed to load service keys", zap.Error(err))
+	}
+	serviceAuth := middleware.NewServiceAuthenticator(serviceTokens)
 
 	// Setup HTTP router
 	mux := http.NewServeMux()
@@ -58,12 +167,75 @@ func main() {
 	healthChecker := health.NewHealthChecker(db)
 	mux.HandleFunc("/health", healthChecker.Handler("auth-service", "1.0.0"))
 
-	// Auth endpoints
-	mux.HandleFunc("/api/v1/auth/register", authHandler.Register)
+	// Token verification keys. The well-known set is public; services fetch
+	// theirs through the authenticated internal route.
+	mux.HandleFunc("/.well-known/jwks.json", authHandler.JWKS)
+	mux.Handle("/internal/v1/jwks.json", serviceAuth.RequireService(
+		[]string{crypto.GroupService, crypto.MemberService, crypto.AssignmentService, crypto.WebhookService, crypto.AnalyticsService},
+		http.HandlerFunc(authHandler.JWKS),
+	))
+
+	// Auth endpoints. Account, session and key management require an interactive
+	// login so a leaked API key cannot mint new credentials. Registration needs an
+	// invitation, and unverified accounts can only manage their own login.
+	mux.HandleFunc("/api/v1/auth/register", invitationHandler.Register)
+	mux.HandleFunc("/api/v1/auth/verify-email", invitationHandler.VerifyEmail)
+	mux.Handle("/api/v1/auth/verify-email/resend", authenticator.Authenticate(middleware.DenyAPIKeys(middleware.DenyImpersonation(http.HandlerFunc(invitationHandler.ResendVerification)))))
 	mux.HandleFunc("/api/v1/auth/login", authHandler.Login)
+	mux.HandleFunc("/api/v1/auth/refresh", authHandler.Refresh)
 	mux.HandleFunc("/api/v1/auth/forgot-password", authHandler.ForgotPassword)
-	mux.HandleFunc("/api/v1/auth/settings", authHandler.UpdateUserSettings)
-	mux.HandleFunc("/api/v1/auth/api-keys", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/v1/auth/reset-password", authHandler.ResetPassword)
+	mux.HandleFunc("/api/v1/auth/oidc/login", authHandler.OIDCLogin)
+	mux.HandleFunc("/api/v1/auth/oidc/callback", authHandler.OIDCCallback)
+	mux.Handle("/api/v1/auth/oidc/link", authenticator.Authenticate(middleware.DenyAPIKeys(middleware.DenyImpersonation(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			authHandler.OIDCLink(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})))))
+	mux.HandleFunc("/api/v1/auth/mfa/verify", authHandler.VerifyMFA)
+	mux.Handle("/api/v1/auth/mfa/totp/enroll", authenticator.OptionalAuth(middleware.DenyAPIKeys(middleware.DenyImpersonation(http.HandlerFunc(authHandler.EnrollTOTP)))))
+	mux.Handle("/api/v1/auth/mfa/totp/confirm", authenticator.OptionalAuth(middleware.DenyAPIKeys(middleware.DenyImpersonation(http.HandlerFunc(authHandler.ConfirmTOTP)))))
+	mux.Handle("/api/v1/auth/mfa/totp/disable", authenticator.Authenticate(middleware.DenyAPIKeys(middleware.DenyImpersonation(http.HandlerFunc(authHandler.DisableTOTP)))))
+	mux.Handle("/api/v1/auth/mfa/recovery-codes", authenticator.Authenticate(middleware.DenyAPIKeys(middleware.DenyImpersonation(http.HandlerFunc(authHandler.RegenerateRecoveryCodes)))))
+	mux.Handle("/api/v1/auth/mfa", authenticator.Authenticate(middleware.DenyAPIKeys(middleware.DenyImpersonation(http.HandlerFunc(authHandler.GetMFAStatus)))))
+	mux.Handle("/api/v1/auth/logout", authenticator.Authenticate(middleware.DenyAPIKeys(http.HandlerFunc(authHandler.Logout))))
+	mux.Handle("/api/v1/auth/logout-all", authenticator.Authenticate(middleware.DenyAPIKeys(middleware.DenyImpersonation(http.HandlerFunc(authHandler.LogoutAll)))))
+	mux.Handle("/api/v1/auth/sessions", authenticator.Authenticate(middleware.DenyAPIKeys(middleware.DenyImpersonation(http.HandlerFunc(authHandler.GetSessions)))))
+	mux.Handle("/api/v1/auth/sessions/", authenticator.Authenticate(middleware.DenyAPIKeys(middleware.DenyImpersonation(http.HandlerFunc(authHandler.RevokeSession)))))
+	mux.Handle("/api/v1/auth/settings", authenticator.Authenticate(middleware.DenyAPIKeys(middleware.DenyImpersonation(http.HandlerFunc(authHandler.UpdateUserSettings)))))
+	mux.Handle("/api/v1/auth/lockouts", authenticator.Authenticate(middleware.DenyAPIKeys(middleware.DenyImpersonation(middleware.AdminOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			authHandler.GetLockouts(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))))))
+	mux.Handle("/api/v1/auth/lockouts/unlock", authenticator.Authenticate(middleware.DenyAPIKeys(middleware.DenyImpersonation(middleware.AdminOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			authHandler.Unlock(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))))))
+	mux.Handle("/api/v1/auth/invitations", authenticator.Authenticate(middleware.DenyAPIKeys(middleware.DenyImpersonation(middleware.RequireVerifiedEmail(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			invitationHandler.GetInvitations(w, r)
+		} else if r.Method == http.MethodPost {
+			invitationHandler.CreateInvitation(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))))))
+	mux.Handle("/api/v1/auth/invitations/", authenticator.Authenticate(middleware.DenyAPIKeys(middleware.DenyImpersonation(middleware.RequireVerifiedEmail(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			invitationHandler.RevokeInvitation(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))))))
+	mux.Handle("/api/v1/auth/api-keys", authenticator.Authenticate(middleware.DenyAPIKeys(middleware.DenyImpersonation(middleware.RequireVerifiedEmail(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodGet {
 			authHandler.GetAPIKeys(w, r)
 		} else if r.Method == http.MethodPost {
@@ -71,11 +243,103 @@ func main() {
 		} else {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
-	})
-	mux.HandleFunc("/api/v1/auth/api-keys/", authHandler.RevokeAPIKey)
+	}))))))
+	mux.Handle("/api/v1/auth/api-keys/", authenticator.Authenticate(middleware.DenyAPIKeys(middleware.DenyImpersonation(middleware.RequireVerifiedEmail(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/rotate") {
+			if r.Method == http.MethodPost {
+				authHandler.RotateAPIKey(w, r)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if r.Method == http.MethodDelete {
+			authHandler.RevokeAPIKey(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))))))
+
+	// Organization endpoints. Access is checked per organization in the use case.
+	mux.Handle("/api/v1/organizations", authenticator.Authenticate(middleware.DenyAPIKeys(middleware.DenyImpersonation(middleware.RequireVerifiedEmail(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			orgHandler.GetOrganizations(w, r)
+		} else if r.Method == http.MethodPost {
+			orgHandler.CreateOrganization(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))))))
+	mux.Handle("/api/v1/organizations/", authenticator.Authenticate(middleware.DenyAPIKeys(middleware.DenyImpersonation(middleware.RequireVerifiedEmail(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/users") {
+			if r.Method == http.MethodGet {
+				orgHandler.GetOrganizationUsers(w, r)
+			} else if r.Method == http.MethodPost {
+				orgHandler.AddOrganizationUser(w, r)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if r.Method == http.MethodGet {
+			orgHandler.GetOrganization(w, r)
+		} else if r.Method == http.MethodPut {
+			orgHandler.UpdateOrganization(w, r)
+		} else if r.Method == http.MethodDelete {
+			orgHandler.DeleteOrganization(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))))))
+
+	// User administration. Admins manage their own organization; deleting and
+	// impersonating users is reserved for super admins. Impersonated callers are
+	// kept out of every credential and account management route.
+	mux.Handle("/api/v1/admin/users", authenticator.Authenticate(middleware.DenyAPIKeys(middleware.DenyImpersonation(middleware.RequireVerifiedEmail(middleware.AdminOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			userAdminHandler.SearchUsers(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})))))))
+	mux.Handle("/api/v1/admin/users/", authenticator.Authenticate(middleware.DenyAPIKeys(middleware.DenyImpersonation(middleware.RequireVerifiedEmail(middleware.AdminOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/role") {
+			if r.Method == http.MethodPut {
+				userAdminHandler.ChangeRole(w, r)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/deactivate") {
+			if r.Method == http.MethodPost {
+				userAdminHandler.DeactivateUser(w, r)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/impersonate") {
+			if r.Method == http.MethodPost {
+				middleware.SuperAdminOnly(http.HandlerFunc(userAdminHandler.Impersonate)).ServeHTTP(w, r)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/reactivate") {
+			if r.Method == http.MethodPost {
+				userAdminHandler.ReactivateUser(w, r)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if r.Method == http.MethodGet {
+			userAdminHandler.GetUser(w, r)
+		} else if r.Method == http.MethodDelete {
+			middleware.SuperAdminOnly(http.HandlerFunc(userAdminHandler.DeleteUser)).ServeHTTP(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})))))))
+
+	// Client addresses come from X-Forwarded-For only behind trusted proxies
+	trustedProxies, err := middleware.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		logger.Log.Fatal("Invalid TRUSTED_PROXIES", zap.Error(err))
+	}
 
 	// Apply middleware
-	handler := middleware.CORS(mux)
+	handler := middleware.CORS(middleware.ResolveClientIP(trustedProxies, mux))
 
 	// Start HTTP server
 	port := 3001
@@ -89,6 +353,10 @@ func main() {
 		Handler: handler,
 	}
 
+	// Warn owners about expiring API keys
+	notifierCtx, stopNotifier := context.WithCancel(context.Background())
+	go expiryNotifier.Run(notifierCtx, time.Hour)
+
 	go func() {
 		logger.Log.Info("Auth Service is running on " + addr)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -102,6 +370,7 @@ func main() {
 	<-quit
 
 	logger.Log.Info("Shutting down Auth Service...")
+	stopNotifier()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
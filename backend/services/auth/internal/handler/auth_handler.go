@@ -2,35 +2,54 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/raufhm/fairflow/services/auth/internal/usecase"
-	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/logger"
 	"github.com/raufhm/fairflow/shared/middleware"
+	"go.uber.org/zap"
 )
 
 type AuthHandler struct {
-	authUseCase *usecase.AuthUseCase
+	authUseCase          *usecase.AuthUseCase
+	passwordResetUseCase *usecase.PasswordResetUseCase
+	mfaUseCase           *usecase.MFAUseCase
+	loginGuard           *usecase.LoginGuard
+	ssoUseCase           *usecase.SSOUseCase // nil when single sign-on is not configured
 }
 
-func NewAuthHandler(authUseCase *usecase.AuthUseCase) *AuthHandler {
-	return &AuthHandler{authUseCase: authUseCase}
+func NewAuthHandler(authUseCase *usecase.AuthUseCase, passwordResetUseCase *usecase.PasswordResetUseCase, mfaUseCase *usecase.MFAUseCase, loginGuard *usecase.LoginGuard, ssoUseCase *usecase.SSOUseCase) *AuthHandler {
+	return &AuthHandler{
+		authUseCase:          authUseCase,
+		passwordResetUseCase: passwordResetUseCase,
+		mfaUseCase:           mfaUseCase,
+		loginGuard:           loginGuard,
+		ssoUseCase:           ssoUseCase,
+	}
 }
 
-type RegisterRequest struct {
+type LoginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
-	Name     string `json:"name"`
-	Role     string `json:"role"`
 }
 
-type LoginRequest struct {
-	Email    string `json:"email"`
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
 	Password string `json:"password"`
 }
 
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
 type UpdateSettingsRequest struct {
 	Name            *string `json:"name"`
 	CurrentPassword *string `json:"currentPassword"`
@@ -38,71 +57,85 @@ type UpdateSettingsRequest struct {
 }
 
 type CreateAPIKeyRequest struct {
-	Name      string  `json:"name"`
-	ExpiresAt *string `json:"expiresAt"`
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	GroupIDs  []int64  `json:"groupIds"`
+	ExpiresIn int64    `json:"expiresIn"` // Lifetime in seconds; takes precedence over ExpiresAt
+	ExpiresAt *string  `json:"expiresAt"` // RFC 3339 timestamp
 }
 
-// Register handles user registration
-func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
+// Login handles user authentication
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	var req RegisterRequest
+	var req LoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid request body"})
 		return
 	}
 
-	if req.Email == "" || req.Password == "" || req.Name == "" {
-		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Missing fields: email, password, name are required"})
+	if req.Email == "" || req.Password == "" {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Missing fields: email and password are required"})
 		return
 	}
 
-	role := domain.UserRole(req.Role)
-	if role == "" {
-		role = domain.RoleUser
-	}
-
-	user, token, err := h.authUseCase.Register(ctx, req.Email, req.Password, req.Name, role)
+	user, tokens, challenge, err := h.authUseCase.Login(ctx, req.Email, req.Password, clientInfo(r))
 	if err != nil {
-		if err == usecase.ErrEmailExists {
-			respondJSON(w, http.StatusConflict, map[string]string{"message": "Registration failed. Email may already be in use"})
+		if err == usecase.ErrInvalidCredentials {
+			respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Invalid email or password"})
+			return
+		}
+		if err == usecase.ErrAccountDeactivated {
+			respondJSON(w, http.StatusForbidden, map[string]string{"message": err.Error()})
+			return
+		}
+		var throttled *usecase.LoginThrottledError
+		if errors.As(err, &throttled) {
+			respondThrottled(w, throttled)
 			return
 		}
-		respondJSON(w, http.StatusInternalServerError, map[string]string{"message": "Registration failed"})
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"message": "An internal error occurred during login"})
 		return
 	}
 
-	respondJSON(w, http.StatusCreated, map[string]interface{}{
+	if challenge != nil {
+		respondMFAChallenge(w, http.StatusOK, user, challenge)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
 		"user": map[string]interface{}{
 			"id":    user.ID,
 			"email": user.Email,
 			"name":  user.Name,
 			"role":  user.Role,
 		},
-		"token": token,
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
 	})
 }
 
-// Login handles user authentication
-func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+// Refresh exchanges a refresh token for a new token pair
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	var req LoginRequest
+	var req RefreshRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid request body"})
 		return
 	}
 
-	if req.Email == "" || req.Password == "" {
-		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Missing fields: email and password are required"})
+	if req.RefreshToken == "" {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Missing field: refresh_token is required"})
 		return
 	}
 
-	user, token, err := h.authUseCase.Login(ctx, req.Email, req.Password)
+	user, tokens, err := h.authUseCase.RefreshSession(ctx, req.RefreshToken, clientInfo(r))
 	if err != nil {
-		if err == usecase.ErrInvalidCredentials {
-			respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Invalid email or password"})
+		if err == usecase.ErrInvalidRefreshToken || err == usecase.ErrRefreshTokenReused || err == usecase.ErrUserNotFound || err == usecase.ErrAccountDeactivated {
+			respondJSON(w, http.StatusUnauthorized, map[string]string{"message": err.Error()})
 			return
 		}
-		respondJSON(w, http.StatusInternalServerError, map[string]string{"message": "An internal error occurred during login"})
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"message": "Failed to refresh session"})
 		return
 	}
 
@@ -113,17 +146,169 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 			"name":  user.Name,
 			"role":  user.Role,
 		},
-		"token": token,
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
 	})
 }
 
+// Logout revokes the session of the current access token
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	sessionID := middleware.GetSessionIDFromContext(r.Context())
+	if sessionID == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Logout requires a session token"})
+		return
+	}
+
+	// Logging out of an impersonation ends it; the session belongs to the real actor
+	userID := user.ID
+	if impersonator := middleware.GetImpersonatorFromContext(r.Context()); impersonator != nil {
+		userID = impersonator.ID
+	}
+
+	if err := h.authUseCase.Logout(r.Context(), userID, sessionID); err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"message": "Failed to log out"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Logged out successfully"})
+}
+
+// LogoutAll revokes every session of the current user
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	if err := h.authUseCase.LogoutAll(r.Context(), user.ID); err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"message": "Failed to log out of all devices"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Logged out of all devices successfully"})
+}
+
+// GetSessions lists the active sessions of the current user
+func (h *AuthHandler) GetSessions(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	sessions, err := h.authUseCase.GetSessions(r.Context(), user.ID)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"message": "Failed to retrieve sessions"})
+		return
+	}
+
+	currentSessionID := middleware.GetSessionIDFromContext(r.Context())
+	result := make([]map[string]interface{}, len(sessions))
+	for i, session := range sessions {
+		result[i] = map[string]interface{}{
+			"id":           session.ID,
+			"device":       session.UserAgent,
+			"ip_address":   session.IPAddress,
+			"last_seen_at": session.LastSeenAt,
+			"created_at":   session.CreatedAt,
+			"expires_at":   session.ExpiresAt,
+			"current":      session.ID == currentSessionID,
+		}
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"sessions": result})
+}
+
+// RevokeSession revokes one of the current user's sessions
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	sessionID := getIDFromPath(r, "/api/v1/auth/sessions/")
+	if sessionID == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid session ID"})
+		return
+	}
+
+	// Logging out of an impersonation ends it; the session belongs to the real actor
+	userID := user.ID
+	if impersonator := middleware.GetImpersonatorFromContext(r.Context()); impersonator != nil {
+		userID = impersonator.ID
+	}
+
+	if err := h.authUseCase.Logout(r.Context(), userID, sessionID); err != nil {
+		if err == usecase.ErrSessionNotFound {
+			respondJSON(w, http.StatusNotFound, map[string]string{"message": "Session not found"})
+			return
+		}
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"message": "Failed to revoke session"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Session revoked successfully"})
+}
+
 // ForgotPassword handles password reset requests
 func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid request body"})
+		return
+	}
+
+	if req.Email == "" {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Missing field: email is required"})
+		return
+	}
+
+	// The response is identical whether or not the email exists
+	if err := h.passwordResetUseCase.RequestReset(ctx, req.Email, middleware.ClientIP(r)); err != nil {
+		logger.Log.Error("Failed to process password reset request", zap.Error(err))
+	}
+
 	respondJSON(w, http.StatusOK, map[string]string{
 		"message": "If the email exists, a password reset link has been sent",
 	})
 }
 
+// ResetPassword sets a new password using a reset token
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid request body"})
+		return
+	}
+
+	if req.Token == "" || req.Password == "" {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Missing fields: token and password are required"})
+		return
+	}
+
+	if err := h.passwordResetUseCase.ResetPassword(ctx, req.Token, req.Password, middleware.ClientIP(r)); err != nil {
+		if err == usecase.ErrInvalidResetToken || err == usecase.ErrWeakPassword {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+			return
+		}
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"message": "Failed to reset password"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Password has been reset. Please log in again"})
+}
+
 // UpdateUserSettings updates user settings
 func (h *AuthHandler) UpdateUserSettings(w http.ResponseWriter, r *http.Request) {
 	user := middleware.GetUserFromContext(r.Context())
@@ -175,13 +360,18 @@ func (h *AuthHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
 	maskedKeys := make([]map[string]interface{}, len(keys))
 	for i, key := range keys {
 		maskedKeys[i] = map[string]interface{}{
-			"id":           key.ID,
-			"name":         key.Name,
-			"key_prefix":   "rr_live_***",
-			"expires_at":   key.ExpiresAt,
-			"last_used_at": key.LastUsedAt,
-			"created_at":   key.CreatedAt,
-			"active":       key.Active,
+			"id":            key.ID,
+			"name":          key.Name,
+			"key_prefix":    "rr_live_***",
+			"scopes":        key.Scopes,
+			"group_ids":     key.GroupIDs,
+			"expires_at":    key.ExpiresAt,
+			"last_used_at":  key.LastUsedAt,
+			"created_at":    key.CreatedAt,
+			"active":        key.Active,
+			"revoked_at":    key.RevokedAt,
+			"replaces_id":   key.ReplacesID,
+			"expiring_soon": h.authUseCase.IsAPIKeyExpiringSoon(key),
 		}
 	}
 
@@ -208,15 +398,76 @@ func (h *AuthHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	rawKey, keyID, err := h.authUseCase.CreateAPIKey(ctx, user.ID, req.Name)
+	var ttl time.Duration
+	if req.ExpiresIn < 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "expiresIn must be positive"})
+		return
+	} else if req.ExpiresIn > 0 {
+		ttl = time.Duration(req.ExpiresIn) * time.Second
+	} else if req.ExpiresAt != nil {
+		expiresAt, err := time.Parse(time.RFC3339, *req.ExpiresAt)
+		if err != nil || !expiresAt.After(time.Now()) {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"message": "expiresAt must be a future RFC 3339 timestamp"})
+			return
+		}
+		ttl = time.Until(expiresAt)
+	}
+
+	rawKey, apiKey, err := h.authUseCase.CreateAPIKey(ctx, user.ID, req.Name, req.Scopes, req.GroupIDs, ttl)
+	if errors.Is(err, usecase.ErrInvalidScope) {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+		return
+	}
 	if err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"message": "Failed to generate API key"})
 		return
 	}
 
 	respondJSON(w, http.StatusCreated, map[string]interface{}{
-		"key": rawKey,
-		"id":  keyID,
+		"key":        rawKey,
+		"id":         apiKey.ID,
+		"scopes":     apiKey.Scopes,
+		"group_ids":  apiKey.GroupIDs,
+		"expires_at": apiKey.ExpiresAt,
+	})
+}
+
+// RotateAPIKey issues a successor key; the old key stays valid for a grace period
+func (h *AuthHandler) RotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	ctx := r.Context()
+	keyID := getIDFromPath(r, "/api/v1/auth/api-keys/", "/rotate")
+	if keyID == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid key ID"})
+		return
+	}
+
+	rawKey, apiKey, err := h.authUseCase.RotateAPIKey(ctx, user.ID, keyID)
+	if errors.Is(err, usecase.ErrAPIKeyNotFound) {
+		respondJSON(w, http.StatusNotFound, map[string]string{"message": "API key not found"})
+		return
+	}
+	if errors.Is(err, usecase.ErrAPIKeyNotUsable) || errors.Is(err, usecase.ErrAPIKeyRotated) {
+		respondJSON(w, http.StatusConflict, map[string]string{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"message": "Failed to rotate API key"})
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"key":         rawKey,
+		"id":          apiKey.ID,
+		"replaces_id": keyID,
+		"scopes":      apiKey.Scopes,
+		"group_ids":   apiKey.GroupIDs,
+		"expires_at":  apiKey.ExpiresAt,
 	})
 }
 
@@ -235,16 +486,33 @@ func (h *AuthHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.authUseCase.RevokeAPIKey(ctx, user.ID, keyID); err != nil {
-		respondJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+	if err := h.authUseCase.RevokeAPIKey(ctx, user.ID, keyID); errors.Is(err, usecase.ErrAPIKeyNotFound) {
+		respondJSON(w, http.StatusNotFound, map[string]string{"message": "API key not found"})
+		return
+	} else if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"message": "Failed to revoke API key"})
 		return
 	}
 
 	respondJSON(w, http.StatusOK, map[string]string{"message": "API Key revoked successfully"})
 }
 
+// JWKS publishes the public keys used to verify access tokens
+func (h *AuthHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	respondJSON(w, http.StatusOK, h.authUseCase.JWKS())
+}
+
 // Helper functions
 
+// clientInfo describes the device making the request
+func clientInfo(r *http.Request) usecase.ClientInfo {
+	return usecase.ClientInfo{
+		UserAgent: r.UserAgent(),
+		IPAddress: middleware.ClientIP(r),
+	}
+}
+
 // respondJSON writes a JSON response
 func respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
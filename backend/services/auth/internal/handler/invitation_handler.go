@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/raufhm/fairflow/services/auth/internal/usecase"
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/middleware"
+)
+
+type InvitationHandler struct {
+	invitationUseCase   *usecase.InvitationUseCase
+	verificationUseCase *usecase.EmailVerificationUseCase
+}
+
+func NewInvitationHandler(invitationUseCase *usecase.InvitationUseCase, verificationUseCase *usecase.EmailVerificationUseCase) *InvitationHandler {
+	return &InvitationHandler{
+		invitationUseCase:   invitationUseCase,
+		verificationUseCase: verificationUseCase,
+	}
+}
+
+type CreateInvitationRequest struct {
+	Email          string `json:"email"`
+	Role           string `json:"role"`
+	OrganizationID *int64 `json:"organizationId"` // Super admins only; others invite into their own organization
+}
+
+type RegisterRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+	Name     string `json:"name"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+// GetInvitations lists the pending invitations the caller may manage
+func (h *InvitationHandler) GetInvitations(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	invitations, err := h.invitationUseCase.ListInvitations(r.Context(), user)
+	if err != nil {
+		respondInvitationError(w, err, "Failed to retrieve invitations")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, invitations)
+}
+
+// CreateInvitation emails an invitation to register
+func (h *InvitationHandler) CreateInvitation(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	var req CreateInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid request body"})
+		return
+	}
+	if req.Email == "" {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Email is required"})
+		return
+	}
+
+	invitation, err := h.invitationUseCase.CreateInvitation(r.Context(), user, req.Email, domain.UserRole(req.Role), req.OrganizationID, middleware.ClientIP(r))
+	if err != nil {
+		respondInvitationError(w, err, "Failed to create invitation")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, invitation)
+}
+
+// RevokeInvitation cancels a pending invitation
+func (h *InvitationHandler) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	id := getIDFromPath(r, "/api/v1/auth/invitations/")
+	if id == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid invitation ID"})
+		return
+	}
+
+	if err := h.invitationUseCase.RevokeInvitation(r.Context(), user, id, middleware.ClientIP(r)); err != nil {
+		respondInvitationError(w, err, "Failed to revoke invitation")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Invitation revoked"})
+}
+
+// Register creates an account from an invitation. The email address, role and
+// organization are taken from the invitation.
+func (h *InvitationHandler) Register(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req RegisterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid request body"})
+		return
+	}
+
+	if req.Token == "" || req.Password == "" || req.Name == "" {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Missing fields: token, password, name are required"})
+		return
+	}
+
+	user, tokens, challenge, err := h.invitationUseCase.Accept(r.Context(), req.Token, req.Password, req.Name, clientInfo(r))
+	if err != nil {
+		respondInvitationError(w, err, "Registration failed")
+		return
+	}
+
+	if challenge != nil {
+		respondMFAChallenge(w, http.StatusCreated, user, challenge)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"user": map[string]interface{}{
+			"id":             user.ID,
+			"email":          user.Email,
+			"name":           user.Name,
+			"role":           user.Role,
+			"email_verified": user.IsEmailVerified(),
+		},
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+	})
+}
+
+// VerifyEmail confirms an email address using the token from the verification email
+func (h *InvitationHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid request body"})
+		return
+	}
+	if req.Token == "" {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Missing field: token is required"})
+		return
+	}
+
+	if err := h.verificationUseCase.VerifyEmail(r.Context(), req.Token, middleware.ClientIP(r)); err != nil {
+		if err == usecase.ErrInvalidVerificationToken {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+			return
+		}
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"message": "Failed to verify email address"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Email address verified"})
+}
+
+// ResendVerification sends the caller a new verification email
+func (h *InvitationHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	if err := h.verificationUseCase.SendVerification(r.Context(), user); err != nil {
+		if err == usecase.ErrEmailAlreadyVerified {
+			respondJSON(w, http.StatusConflict, map[string]string{"message": err.Error()})
+			return
+		}
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"message": "Failed to send verification email"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Verification email sent"})
+}
+
+// respondInvitationError maps invitation errors to HTTP responses
+func respondInvitationError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, usecase.ErrInvitationNotFound), errors.Is(err, usecase.ErrOrganizationNotFound):
+		respondJSON(w, http.StatusNotFound, map[string]string{"message": err.Error()})
+	case errors.Is(err, usecase.ErrInvitationForbidden):
+		respondJSON(w, http.StatusForbidden, map[string]string{"message": err.Error()})
+	case errors.Is(err, usecase.ErrEmailExists):
+		respondJSON(w, http.StatusConflict, map[string]string{"message": err.Error()})
+	case errors.Is(err, usecase.ErrInvalidInvitation), errors.Is(err, usecase.ErrWeakPassword):
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+	default:
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"message": fallback})
+	}
+}
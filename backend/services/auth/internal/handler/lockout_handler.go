@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/raufhm/fairflow/services/auth/internal/usecase"
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/middleware"
+)
+
+type UnlockRequest struct {
+	Email     string `json:"email"`
+	IPAddress string `json:"ip_address"`
+}
+
+// GetLockouts lists the active lockouts the admin may see (admin only)
+func (h *AuthHandler) GetLockouts(w http.ResponseWriter, r *http.Request) {
+	admin := middleware.GetUserFromContext(r.Context())
+	if admin == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	lockouts, err := h.loginGuard.GetLockouts(r.Context(), admin)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"message": "Failed to retrieve lockouts"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"lockouts": lockouts})
+}
+
+// Unlock lifts an account or IP lockout (admin only)
+func (h *AuthHandler) Unlock(w http.ResponseWriter, r *http.Request) {
+	admin := middleware.GetUserFromContext(r.Context())
+	if admin == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	var req UnlockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid request body"})
+		return
+	}
+
+	scope, identifier := domain.ThrottleScopeAccount, req.Email
+	if req.IPAddress != "" {
+		scope, identifier = domain.ThrottleScopeIP, req.IPAddress
+	}
+	if identifier == "" || (req.Email != "" && req.IPAddress != "") {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Provide either email or ip_address"})
+		return
+	}
+
+	err := h.loginGuard.Unlock(r.Context(), scope, identifier, admin, middleware.ClientIP(r))
+	if errors.Is(err, usecase.ErrLockoutNotFound) {
+		respondJSON(w, http.StatusNotFound, map[string]string{"message": "No lockout found"})
+		return
+	}
+	if errors.Is(err, usecase.ErrLockoutForbidden) {
+		respondJSON(w, http.StatusForbidden, map[string]string{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"message": "Failed to unlock"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Unlocked successfully"})
+}
+
+// respondThrottled rejects a login attempt that arrived too soon
+func respondThrottled(w http.ResponseWriter, err *usecase.LoginThrottledError) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(err.RetryAfter.Seconds()))))
+	respondJSON(w, http.StatusTooManyRequests, map[string]string{"message": err.Error()})
+}
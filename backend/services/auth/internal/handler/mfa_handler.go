@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/raufhm/fairflow/services/auth/internal/usecase"
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/logger"
+	"github.com/raufhm/fairflow/shared/middleware"
+	"go.uber.org/zap"
+)
+
+type MFATokenRequest struct {
+	MFAToken string `json:"mfa_token"`
+}
+
+type MFACodeRequest struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+// GetMFAStatus returns the current user's two-factor configuration
+func (h *AuthHandler) GetMFAStatus(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	status, err := h.mfaUseCase.GetStatus(r.Context(), user)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"message": "Failed to retrieve MFA status"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, status)
+}
+
+// EnrollTOTP starts TOTP enrolment. It accepts either a signed-in user or the
+// MFA token of a login that requires enrolment.
+func (h *AuthHandler) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req MFATokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && middleware.GetUserFromContext(ctx) == nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid request body"})
+		return
+	}
+
+	user, ok := h.mfaUser(w, r, req.MFAToken)
+	if !ok {
+		return
+	}
+
+	enrollment, err := h.mfaUseCase.BeginEnrollment(ctx, user)
+	if err != nil {
+		respondMFAError(w, err, "Failed to start enrolment")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, enrollment)
+}
+
+// ConfirmTOTP activates a pending TOTP factor and returns recovery codes. When
+// called with an MFA token it also completes the login that required enrolment.
+func (h *AuthHandler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req MFACodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid request body"})
+		return
+	}
+	if req.Code == "" {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Missing field: code is required"})
+		return
+	}
+
+	if user := middleware.GetUserFromContext(ctx); user != nil {
+		recoveryCodes, err := h.mfaUseCase.ConfirmEnrollment(ctx, user, req.Code)
+		if err != nil {
+			respondMFAError(w, err, "Failed to confirm enrolment")
+			return
+		}
+		respondJSON(w, http.StatusOK, map[string]interface{}{"recovery_codes": recoveryCodes})
+		return
+	}
+
+	if req.MFAToken == "" {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	user, tokens, recoveryCodes, err := h.authUseCase.CompleteMFAEnrollment(ctx, req.MFAToken, req.Code, clientInfo(r))
+	if err != nil {
+		respondMFAError(w, err, "Failed to confirm enrolment")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"user": map[string]interface{}{
+			"id":    user.ID,
+			"email": user.Email,
+			"name":  user.Name,
+			"role":  user.Role,
+		},
+		"token":          tokens.AccessToken,
+		"refresh_token":  tokens.RefreshToken,
+		"expires_in":     tokens.ExpiresIn,
+		"recovery_codes": recoveryCodes,
+	})
+}
+
+// VerifyMFA completes a login with a TOTP or recovery code
+func (h *AuthHandler) VerifyMFA(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req MFACodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid request body"})
+		return
+	}
+	if req.MFAToken == "" || req.Code == "" {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Missing fields: mfa_token and code are required"})
+		return
+	}
+
+	user, tokens, err := h.authUseCase.CompleteMFALogin(ctx, req.MFAToken, req.Code, clientInfo(r))
+	if err != nil {
+		respondMFAError(w, err, "Failed to verify code")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"user": map[string]interface{}{
+			"id":    user.ID,
+			"email": user.Email,
+			"name":  user.Name,
+			"role":  user.Role,
+		},
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+	})
+}
+
+// RegenerateRecoveryCodes replaces the current user's recovery codes
+func (h *AuthHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	var req MFACodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Missing field: code is required"})
+		return
+	}
+
+	recoveryCodes, err := h.mfaUseCase.RegenerateRecoveryCodes(r.Context(), user, req.Code)
+	if err != nil {
+		respondMFAError(w, err, "Failed to regenerate recovery codes")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"recovery_codes": recoveryCodes})
+}
+
+// DisableTOTP removes the current user's second factor
+func (h *AuthHandler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	var req MFACodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Missing field: code is required"})
+		return
+	}
+
+	if err := h.mfaUseCase.Disable(r.Context(), user, req.Code); err != nil {
+		respondMFAError(w, err, "Failed to disable two-factor authentication")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Two-factor authentication disabled"})
+}
+
+// mfaUser resolves the user for enrolment from the session or an MFA token
+func (h *AuthHandler) mfaUser(w http.ResponseWriter, r *http.Request, mfaToken string) (*domain.User, bool) {
+	if user := middleware.GetUserFromContext(r.Context()); user != nil {
+		return user, true
+	}
+	if mfaToken == "" {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return nil, false
+	}
+
+	user, err := h.mfaUseCase.ResolveChallenge(r.Context(), mfaToken)
+	if err != nil {
+		respondMFAError(w, err, "Failed to verify MFA token")
+		return nil, false
+	}
+	return user, true
+}
+
+// respondMFAChallenge tells the client that a login needs a second factor
+func respondMFAChallenge(w http.ResponseWriter, status int, user *domain.User, challenge *usecase.MFAChallenge) {
+	respondJSON(w, status, map[string]interface{}{
+		"user": map[string]interface{}{
+			"id":    user.ID,
+			"email": user.Email,
+			"name":  user.Name,
+			"role":  user.Role,
+		},
+		"mfa_required":        true,
+		"mfa_token":           challenge.Token,
+		"expires_in":          challenge.ExpiresIn,
+		"enrollment_required": challenge.EnrollmentRequired,
+	})
+}
+
+// respondMFAError maps MFA errors to HTTP responses
+func respondMFAError(w http.ResponseWriter, err error, fallback string) {
+	var throttled *usecase.LoginThrottledError
+	switch {
+	case errors.As(err, &throttled):
+		respondThrottled(w, throttled)
+	case errors.Is(err, usecase.ErrInvalidMFAToken), errors.Is(err, usecase.ErrInvalidMFACode):
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": err.Error()})
+	case errors.Is(err, usecase.ErrMFAAlreadyEnrolled), errors.Is(err, usecase.ErrMFANotEnrolled):
+		respondJSON(w, http.StatusConflict, map[string]string{"message": err.Error()})
+	case errors.Is(err, usecase.ErrMFARequiredByPolicy):
+		respondJSON(w, http.StatusForbidden, map[string]string{"message": err.Error()})
+	default:
+		logger.Log.Error(fallback, zap.Error(err))
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"message": fallback})
+	}
+}
@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/raufhm/fairflow/services/auth/internal/usecase"
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/middleware"
+)
+
+type OrganizationHandler struct {
+	orgUseCase *usecase.OrganizationUseCase
+}
+
+func NewOrganizationHandler(orgUseCase *usecase.OrganizationUseCase) *OrganizationHandler {
+	return &OrganizationHandler{orgUseCase: orgUseCase}
+}
+
+type CreateOrganizationRequest struct {
+	Name       string `json:"name"`
+	Slug       string `json:"slug"`
+	RequireMFA bool   `json:"requireMfa"`
+}
+
+type UpdateOrganizationRequest struct {
+	Name       *string `json:"name"`
+	RequireMFA *bool   `json:"requireMfa"`
+}
+
+type AddOrganizationUserRequest struct {
+	UserID int64  `json:"userId"`
+	Role   string `json:"role"`
+}
+
+// GetOrganizations lists the organizations visible to the caller
+func (h *OrganizationHandler) GetOrganizations(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	orgs, err := h.orgUseCase.ListOrganizations(r.Context(), user)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"message": "Failed to retrieve organizations"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, orgs)
+}
+
+// CreateOrganization creates a new organization (super admin only)
+func (h *OrganizationHandler) CreateOrganization(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	var req CreateOrganizationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid request body"})
+		return
+	}
+	if req.Name == "" {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Organization name is required"})
+		return
+	}
+
+	org, err := h.orgUseCase.CreateOrganization(r.Context(), user, req.Name, req.Slug, req.RequireMFA, middleware.ClientIP(r))
+	if err != nil {
+		respondOrganizationError(w, err, "Failed to create organization")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, org)
+}
+
+// GetOrganization retrieves an organization the caller belongs to
+func (h *OrganizationHandler) GetOrganization(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	id := getIDFromPath(r, "/api/v1/organizations/")
+	if id == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid organization ID"})
+		return
+	}
+
+	org, err := h.orgUseCase.GetOrganization(r.Context(), user, id)
+	if err != nil {
+		respondOrganizationError(w, err, "Failed to retrieve organization")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, org)
+}
+
+// UpdateOrganization updates an organization's name or MFA policy (org admins only)
+func (h *OrganizationHandler) UpdateOrganization(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	id := getIDFromPath(r, "/api/v1/organizations/")
+	if id == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid organization ID"})
+		return
+	}
+
+	var req UpdateOrganizationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid request body"})
+		return
+	}
+
+	org, err := h.orgUseCase.UpdateOrganization(r.Context(), user, id, req.Name, req.RequireMFA, middleware.ClientIP(r))
+	if err != nil {
+		respondOrganizationError(w, err, "")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, org)
+}
+
+// DeleteOrganization deletes an empty organization (super admin only)
+func (h *OrganizationHandler) DeleteOrganization(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	id := getIDFromPath(r, "/api/v1/organizations/")
+	if id == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid organization ID"})
+		return
+	}
+
+	if err := h.orgUseCase.DeleteOrganization(r.Context(), user, id, middleware.ClientIP(r)); err != nil {
+		respondOrganizationError(w, err, "Failed to delete organization")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Organization deleted successfully"})
+}
+
+// GetOrganizationUsers lists the users in an organization (org admins only)
+func (h *OrganizationHandler) GetOrganizationUsers(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	id := getIDFromPath(r, "/api/v1/organizations/", "/users")
+	if id == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid organization ID"})
+		return
+	}
+
+	users, err := h.orgUseCase.ListUsers(r.Context(), user, id)
+	if err != nil {
+		respondOrganizationError(w, err, "Failed to retrieve users")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, users)
+}
+
+// AddOrganizationUser moves a user into an organization (super admin only)
+func (h *OrganizationHandler) AddOrganizationUser(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	id := getIDFromPath(r, "/api/v1/organizations/", "/users")
+	if id == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid organization ID"})
+		return
+	}
+
+	var req AddOrganizationUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.UserID == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "userId is required"})
+		return
+	}
+
+	member, err := h.orgUseCase.AddUser(r.Context(), user, id, req.UserID, domain.UserRole(req.Role), middleware.ClientIP(r))
+	if err != nil {
+		respondOrganizationError(w, err, "Failed to add user")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, member)
+}
+
+// respondOrganizationError maps organization errors to HTTP responses. An empty
+// fallback reports unexpected errors as bad requests with their message.
+func respondOrganizationError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, usecase.ErrOrganizationNotFound), errors.Is(err, usecase.ErrUserNotFound):
+		respondJSON(w, http.StatusNotFound, map[string]string{"message": err.Error()})
+	case errors.Is(err, usecase.ErrOrganizationForbidden):
+		respondJSON(w, http.StatusForbidden, map[string]string{"message": err.Error()})
+	case errors.Is(err, usecase.ErrOrganizationNotEmpty), errors.Is(err, usecase.ErrSlugTaken):
+		respondJSON(w, http.StatusConflict, map[string]string{"message": err.Error()})
+	case errors.Is(err, usecase.ErrInvalidSlug), errors.Is(err, usecase.ErrInvalidOrgRole):
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+	case fallback == "":
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+	default:
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"message": fallback})
+	}
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/raufhm/fairflow/services/auth/internal/usecase"
+	"github.com/raufhm/fairflow/shared/logger"
+	"github.com/raufhm/fairflow/shared/middleware"
+	"github.com/raufhm/fairflow/shared/oidc"
+	"go.uber.org/zap"
+)
+
+// ssoStateCookie carries the sealed state of an in-progress SSO login
+const ssoStateCookie = "ff_sso_state"
+
+// ssoCookiePath limits the state cookie to the SSO endpoints
+const ssoCookiePath = "/api/v1/auth/oidc"
+
+// OIDCLogin redirects the browser to the identity provider. A link ticket in
+// the "link" parameter links the identity to the account that requested it.
+func (h *AuthHandler) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	if h.ssoUseCase == nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{"message": "Single sign-on is not configured"})
+		return
+	}
+
+	authURL, sealedState, err := h.ssoUseCase.BeginLogin(r.Context(), r.URL.Query().Get("link"))
+	if errors.Is(err, usecase.ErrSSOStateMismatch) {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid or expired link request"})
+		return
+	}
+	if err != nil {
+		logger.Log.Error("Failed to start SSO login", zap.Error(err))
+		respondJSON(w, http.StatusBadGateway, map[string]string{"message": "Identity provider is unavailable"})
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     ssoStateCookie,
+		Value:    sealedState,
+		Path:     ssoCookiePath,
+		MaxAge:   int((10 * time.Minute).Seconds()),
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OIDCLink returns the URL that links the caller's account to their identity
+// at the provider. The browser has to navigate to it within a few minutes.
+func (h *AuthHandler) OIDCLink(w http.ResponseWriter, r *http.Request) {
+	if h.ssoUseCase == nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{"message": "Single sign-on is not configured"})
+		return
+	}
+
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	ticket, err := h.ssoUseCase.BeginLink(r.Context(), user)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"message": "Failed to start linking"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{
+		"url": ssoCookiePath + "/login?" + url.Values{"link": {ticket}}.Encode(),
+	})
+}
+
+// OIDCCallback completes the login and hands the result to the web app
+func (h *AuthHandler) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	if h.ssoUseCase == nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{"message": "Single sign-on is not configured"})
+		return
+	}
+
+	// The state cookie is single use
+	http.SetCookie(w, &http.Cookie{
+		Name:     ssoStateCookie,
+		Value:    "",
+		Path:     ssoCookiePath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	query := r.URL.Query()
+	if providerError := query.Get("error"); providerError != "" {
+		h.redirectSSOResult(w, r, url.Values{"error": {providerError}})
+		return
+	}
+
+	cookie, err := r.Cookie(ssoStateCookie)
+	if err != nil {
+		h.redirectSSOResult(w, r, url.Values{"error": {"invalid_state"}})
+		return
+	}
+
+	_, tokens, challenge, err := h.ssoUseCase.CompleteLogin(r.Context(), cookie.Value, query.Get("state"), query.Get("code"), clientInfo(r))
+	if err != nil {
+		code := "sso_failed"
+		switch {
+		case errors.Is(err, usecase.ErrSSOStateMismatch):
+			code = "invalid_state"
+		case errors.Is(err, usecase.ErrSSOEmailUnverified):
+			code = "email_unverified"
+		case errors.Is(err, usecase.ErrSSONotInvited):
+			code = "not_invited"
+		case errors.Is(err, usecase.ErrSSOAccountExists):
+			code = "account_exists"
+		case errors.Is(err, usecase.ErrSSOIdentityInUse):
+			code = "identity_in_use"
+		case errors.Is(err, usecase.ErrAccountDeactivated):
+			code = "account_deactivated"
+		case errors.Is(err, oidc.ErrInvalidIDToken):
+			code = "invalid_id_token"
+		}
+		logger.Log.Warn("SSO login failed", zap.String("reason", code), zap.Error(err))
+		h.redirectSSOResult(w, r, url.Values{"error": {code}})
+		return
+	}
+
+	if challenge != nil {
+		h.redirectSSOResult(w, r, url.Values{
+			"mfa_token":           {challenge.Token},
+			"expires_in":          {strconv.FormatInt(challenge.ExpiresIn, 10)},
+			"enrollment_required": {strconv.FormatBool(challenge.EnrollmentRequired)},
+		})
+		return
+	}
+
+	h.redirectSSOResult(w, r, url.Values{
+		"token":         {tokens.AccessToken},
+		"refresh_token": {tokens.RefreshToken},
+		"expires_in":    {strconv.FormatInt(tokens.ExpiresIn, 10)},
+	})
+}
+
+func (h *AuthHandler) redirectSSOResult(w http.ResponseWriter, r *http.Request, result url.Values) {
+	http.Redirect(w, r, h.ssoUseCase.CallbackRedirectURL(result), http.StatusFound)
+}
+
+// isHTTPS reports whether the client connected over TLS, directly or through a proxy
+func isHTTPS(r *http.Request) bool {
+	return r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+}
@@ -0,0 +1,258 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/raufhm/fairflow/services/auth/internal/usecase"
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/middleware"
+)
+
+// maxUserPageSize caps how many users one search returns
+const maxUserPageSize = 200
+
+type UserAdminHandler struct {
+	userAdminUseCase     *usecase.UserAdminUseCase
+	impersonationUseCase *usecase.ImpersonationUseCase
+}
+
+func NewUserAdminHandler(userAdminUseCase *usecase.UserAdminUseCase, impersonationUseCase *usecase.ImpersonationUseCase) *UserAdminHandler {
+	return &UserAdminHandler{
+		userAdminUseCase:     userAdminUseCase,
+		impersonationUseCase: impersonationUseCase,
+	}
+}
+
+type ChangeRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// SearchUsers lists users matching the q, role, organization_id and status
+// (active or deactivated) query parameters, paginated with limit and offset
+func (h *UserAdminHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	query := r.URL.Query()
+	filter := domain.UserFilter{
+		Query:            query.Get("q"),
+		Role:             domain.UserRole(query.Get("role")),
+		AllOrganizations: true,
+		Limit:            50,
+	}
+
+	if orgStr := query.Get("organization_id"); orgStr != "" {
+		orgID := parseID(orgStr)
+		if orgID == 0 {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid organization ID"})
+			return
+		}
+		filter.OrganizationID = &orgID
+		filter.AllOrganizations = false
+	}
+
+	switch query.Get("status") {
+	case "":
+	case "active":
+		active := true
+		filter.Active = &active
+	case "deactivated":
+		active := false
+		filter.Active = &active
+	default:
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "status must be active or deactivated"})
+		return
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			filter.Limit = min(l, maxUserPageSize)
+		}
+	}
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			filter.Offset = o
+		}
+	}
+
+	users, total, err := h.userAdminUseCase.SearchUsers(r.Context(), user, filter)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"message": "Failed to retrieve users"})
+		return
+	}
+
+	page := (filter.Offset / filter.Limit) + 1
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"users": users,
+		"total": total,
+		"page":  page,
+		"limit": filter.Limit,
+	})
+}
+
+// GetUser returns a single user
+func (h *UserAdminHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	actor := middleware.GetUserFromContext(r.Context())
+	if actor == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	id := getIDFromPath(r, "/api/v1/admin/users/")
+	if id == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid user ID"})
+		return
+	}
+
+	user, err := h.userAdminUseCase.GetUser(r.Context(), actor, id)
+	if err != nil {
+		respondUserAdminError(w, err, "Failed to retrieve user")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, user)
+}
+
+// ChangeRole sets a user's role
+func (h *UserAdminHandler) ChangeRole(w http.ResponseWriter, r *http.Request) {
+	actor := middleware.GetUserFromContext(r.Context())
+	if actor == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	id := getIDFromPath(r, "/api/v1/admin/users/", "/role")
+	if id == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid user ID"})
+		return
+	}
+
+	var req ChangeRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid request body"})
+		return
+	}
+	if req.Role == "" {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Role is required"})
+		return
+	}
+
+	user, err := h.userAdminUseCase.ChangeRole(r.Context(), actor, id, domain.UserRole(req.Role), middleware.ClientIP(r))
+	if err != nil {
+		respondUserAdminError(w, err, "Failed to change role")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, user)
+}
+
+// DeactivateUser blocks a user and revokes their API keys and sessions
+func (h *UserAdminHandler) DeactivateUser(w http.ResponseWriter, r *http.Request) {
+	actor := middleware.GetUserFromContext(r.Context())
+	if actor == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	id := getIDFromPath(r, "/api/v1/admin/users/", "/deactivate")
+	if id == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid user ID"})
+		return
+	}
+
+	if err := h.userAdminUseCase.DeactivateUser(r.Context(), actor, id, middleware.ClientIP(r)); err != nil {
+		respondUserAdminError(w, err, "Failed to deactivate user")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "User deactivated"})
+}
+
+// ReactivateUser lets a deactivated user sign in again
+func (h *UserAdminHandler) ReactivateUser(w http.ResponseWriter, r *http.Request) {
+	actor := middleware.GetUserFromContext(r.Context())
+	if actor == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	id := getIDFromPath(r, "/api/v1/admin/users/", "/reactivate")
+	if id == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid user ID"})
+		return
+	}
+
+	if err := h.userAdminUseCase.ReactivateUser(r.Context(), actor, id, middleware.ClientIP(r)); err != nil {
+		respondUserAdminError(w, err, "Failed to reactivate user")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "User reactivated"})
+}
+
+// DeleteUser permanently removes a user (super admin only)
+func (h *UserAdminHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	actor := middleware.GetUserFromContext(r.Context())
+	if actor == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	id := getIDFromPath(r, "/api/v1/admin/users/")
+	if id == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid user ID"})
+		return
+	}
+
+	if err := h.userAdminUseCase.DeleteUser(r.Context(), actor, id, middleware.ClientIP(r)); err != nil {
+		respondUserAdminError(w, err, "Failed to delete user")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "User deleted"})
+}
+
+// Impersonate issues a short-lived token acting as the user (super admin only)
+func (h *UserAdminHandler) Impersonate(w http.ResponseWriter, r *http.Request) {
+	actor := middleware.GetUserFromContext(r.Context())
+	if actor == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	id := getIDFromPath(r, "/api/v1/admin/users/", "/impersonate")
+	if id == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid user ID"})
+		return
+	}
+
+	token, err := h.impersonationUseCase.StartImpersonation(r.Context(), actor, id, clientInfo(r))
+	if err != nil {
+		respondUserAdminError(w, err, "Failed to start impersonation")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, token)
+}
+
+// respondUserAdminError maps user management errors to HTTP responses
+func respondUserAdminError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, usecase.ErrUserNotFound):
+		respondJSON(w, http.StatusNotFound, map[string]string{"message": err.Error()})
+	case errors.Is(err, usecase.ErrUserAdminForbidden), errors.Is(err, usecase.ErrCannotModifySelf), errors.Is(err, usecase.ErrImpersonationForbidden):
+		respondJSON(w, http.StatusForbidden, map[string]string{"message": err.Error()})
+	case errors.Is(err, usecase.ErrUserAlreadyDeactivated), errors.Is(err, usecase.ErrUserNotDeactivated), errors.Is(err, usecase.ErrAccountDeactivated):
+		respondJSON(w, http.StatusConflict, map[string]string{"message": err.Error()})
+	case errors.Is(err, usecase.ErrInvalidRole), errors.Is(err, usecase.ErrInvalidOrgRole):
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+	default:
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"message": fallback})
+	}
+}
@@ -0,0 +1,96 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/logger"
+	"github.com/raufhm/fairflow/shared/mailer"
+	"go.uber.org/zap"
+)
+
+// APIKeyExpiryNotifier warns key owners by email before their API keys expire
+type APIKeyExpiryNotifier struct {
+	apiKeyRepo domain.APIKeyRepository
+	userRepo   domain.UserRepository
+	mailer     mailer.Mailer
+	appBaseURL string
+	warning    time.Duration
+}
+
+func NewAPIKeyExpiryNotifier(
+	apiKeyRepo domain.APIKeyRepository,
+	userRepo domain.UserRepository,
+	mailer mailer.Mailer,
+	appBaseURL string,
+	warning time.Duration,
+) *APIKeyExpiryNotifier {
+	return &APIKeyExpiryNotifier{
+		apiKeyRepo: apiKeyRepo,
+		userRepo:   userRepo,
+		mailer:     mailer,
+		appBaseURL: strings.TrimSuffix(appBaseURL, "/"),
+		warning:    warning,
+	}
+}
+
+// Run checks for expiring keys every interval until ctx is cancelled
+func (n *APIKeyExpiryNotifier) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := n.NotifyExpiring(ctx); err != nil {
+			logger.Log.Error("Failed to notify owners of expiring API keys", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// NotifyExpiring emails the owner of every key entering the warning window.
+// Each key is reported once; it returns the number of keys reported.
+func (n *APIKeyExpiryNotifier) NotifyExpiring(ctx context.Context) (int, error) {
+	keys, err := n.apiKeyRepo.GetExpiring(ctx, time.Now().Add(n.warning))
+	if err != nil {
+		return 0, err
+	}
+
+	notified := 0
+	for _, key := range keys {
+		user, err := n.userRepo.GetByID(ctx, key.UserID)
+		if err != nil {
+			return notified, err
+		}
+		if user == nil {
+			continue
+		}
+
+		msg := mailer.Message{
+			To:      user.Email,
+			Subject: fmt.Sprintf("Your FairFlow API key %q expires soon", key.Name),
+			Body: fmt.Sprintf(
+				"Hi %s,\n\nYour API key %q expires on %s. Rotate it before then to avoid interrupting your integrations:\n\n%s/settings/api-keys\n",
+				user.Name, key.Name, key.ExpiresAt.UTC().Format(time.RFC1123), n.appBaseURL,
+			),
+		}
+		if err := n.mailer.Send(ctx, msg); err != nil {
+			logger.Log.Warn("Failed to send API key expiry notice", zap.Int64("api_key_id", key.ID), zap.Error(err))
+			continue
+		}
+
+		if err := n.apiKeyRepo.MarkExpiryNotified(ctx, key.ID); err != nil {
+			return notified, err
+		}
+		notified++
+	}
+
+	return notified, nil
+}
@@ -2,7 +2,10 @@ package usecase
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/raufhm/fairflow/shared/crypto"
 	"github.com/raufhm/fairflow/shared/domain"
@@ -10,91 +13,322 @@ import (
 )
 
 var (
-	ErrInvalidCredentials = errors.New("invalid email or password")
-	ErrUserNotFound       = errors.New("user not found")
-	ErrEmailExists        = errors.New("email already exists")
+	ErrInvalidCredentials  = errors.New("invalid email or password")
+	ErrUserNotFound        = errors.New("user not found")
+	ErrEmailExists         = errors.New("email already exists")
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected, session revoked")
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrInvalidScope        = errors.New("invalid API key scope")
+	ErrAPIKeyNotFound      = errors.New("API key not found or does not belong to user")
+	ErrAPIKeyNotUsable     = errors.New("API key is revoked or expired")
+	ErrAPIKeyRotated       = errors.New("API key has already been rotated; rotate its successor instead")
+	ErrAccountDeactivated  = errors.New("account has been deactivated")
 )
 
+// refreshTokenPrefix identifies refresh tokens issued by FairFlow
+const refreshTokenPrefix = "rr_refresh_"
+
+// AuthTokens is the credential pair returned after login or refresh
+type AuthTokens struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// ClientInfo describes the device a session is created from
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
 type AuthUseCase struct {
-	userRepo     domain.UserRepository
-	apiKeyRepo   domain.APIKeyRepository
-	jwtSecret    string
-	tokenService *crypto.TokenService
+	userRepo         domain.UserRepository
+	apiKeyRepo       domain.APIKeyRepository
+	sessionRepo      domain.SessionRepository
+	refreshTokenRepo domain.RefreshTokenRepository
+	jwtSecret        string
+	refreshTTL       time.Duration
+	tokenService     *crypto.TokenService
+	mfa              *MFAUseCase
+	loginGuard       *LoginGuard
+
+	apiKeyRotationGrace time.Duration
+	apiKeyExpiryWarning time.Duration
 }
 
 func NewAuthUseCase(
 	userRepo domain.UserRepository,
 	apiKeyRepo domain.APIKeyRepository,
+	sessionRepo domain.SessionRepository,
+	refreshTokenRepo domain.RefreshTokenRepository,
+	tokenService *crypto.TokenService,
+	mfa *MFAUseCase,
+	loginGuard *LoginGuard,
 	jwtSecret string,
+	refreshTTL time.Duration,
+	apiKeyRotationGrace time.Duration,
+	apiKeyExpiryWarning time.Duration,
 ) *AuthUseCase {
 	return &AuthUseCase{
-		userRepo:     userRepo,
-		apiKeyRepo:   apiKeyRepo,
-		jwtSecret:    jwtSecret,
-		tokenService: crypto.NewTokenService(jwtSecret),
+		userRepo:         userRepo,
+		apiKeyRepo:       apiKeyRepo,
+		sessionRepo:      sessionRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		jwtSecret:        jwtSecret,
+		refreshTTL:       refreshTTL,
+		tokenService:     tokenService,
+		mfa:              mfa,
+		loginGuard:       loginGuard,
+
+		apiKeyRotationGrace: apiKeyRotationGrace,
+		apiKeyExpiryWarning: apiKeyExpiryWarning,
 	}
 }
 
-// Register creates a new user account
-func (uc *AuthUseCase) Register(ctx context.Context, email, password, name string, role domain.UserRole) (*domain.User, string, error) {
-	// Check if user exists
-	existingUser, err := uc.userRepo.GetByEmail(ctx, email)
+// Login authenticates a user. Users with a second factor, or whose role requires
+// one, get an MFA challenge to complete with CompleteMFALogin instead of tokens.
+// Repeated failures are throttled per account and per IP (see LoginGuard).
+func (uc *AuthUseCase) Login(ctx context.Context, email, password string, client ClientInfo) (*domain.User, *AuthTokens, *MFAChallenge, error) {
+	if err := uc.loginGuard.Check(ctx, email, client.IPAddress); err != nil {
+		return nil, nil, nil, err
+	}
+
+	user, err := uc.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Unknown emails still pay for a bcrypt comparison so timing does not reveal which accounts exist
+	passwordHash := dummyPasswordHash()
+	if user != nil {
+		passwordHash = []byte(user.PasswordHash)
+	}
+
+	// Compare password
+	if err := bcrypt.CompareHashAndPassword(passwordHash, []byte(password)); err != nil || user == nil {
+		if err := uc.loginGuard.RecordFailure(ctx, email, client.IPAddress, user); err != nil {
+			return nil, nil, nil, err
+		}
+		return nil, nil, nil, ErrInvalidCredentials
+	}
+
+	user, tokens, challenge, err := uc.completeLogin(ctx, user, client)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, nil, err
 	}
-	if existingUser != nil {
-		return nil, "", ErrEmailExists
+
+	// Failures are only forgiven once the login fully succeeds, otherwise a known
+	// password would reset the count for guessing the second factor
+	if tokens != nil {
+		if err := uc.loginGuard.RecordSuccess(ctx, email); err != nil {
+			return nil, nil, nil, err
+		}
 	}
 
-	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return user, tokens, challenge, nil
+}
+
+// CompleteMFALogin finishes a login by verifying a TOTP or recovery code against an MFA challenge
+func (uc *AuthUseCase) CompleteMFALogin(ctx context.Context, mfaToken, code string, client ClientInfo) (*domain.User, *AuthTokens, error) {
+	user, err := uc.mfa.ResolveChallenge(ctx, mfaToken)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	// Create user
-	user := &domain.User{
-		Email:        email,
-		PasswordHash: string(hashedPassword),
-		Name:         name,
-		Role:         role,
+	// Second factor guesses count against the same limits as passwords
+	if err := uc.loginGuard.Check(ctx, user.Email, client.IPAddress); err != nil {
+		return nil, nil, err
 	}
 
-	if err := uc.userRepo.Create(ctx, user); err != nil {
-		return nil, "", err
+	if err := uc.mfa.VerifyCode(ctx, user, code); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			if err := uc.loginGuard.RecordFailure(ctx, user.Email, client.IPAddress, user); err != nil {
+				return nil, nil, err
+			}
+		}
+		return nil, nil, err
 	}
 
-	// Generate JWT token
-	token, err := uc.tokenService.GenerateToken(user.ID)
+	if err := uc.loginGuard.RecordSuccess(ctx, user.Email); err != nil {
+		return nil, nil, err
+	}
+
+	tokens, err := uc.startSession(ctx, user, client)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	return user, token, nil
+	return user, tokens, nil
 }
 
-// Login authenticates a user and returns a JWT token
-func (uc *AuthUseCase) Login(ctx context.Context, email, password string) (*domain.User, string, error) {
-	user, err := uc.userRepo.GetByEmail(ctx, email)
+// CompleteMFAEnrollment finishes a login that required enrolment by confirming the
+// new factor. It returns the session tokens and the user's recovery codes.
+func (uc *AuthUseCase) CompleteMFAEnrollment(ctx context.Context, mfaToken, code string, client ClientInfo) (*domain.User, *AuthTokens, []string, error) {
+	user, err := uc.mfa.ResolveChallenge(ctx, mfaToken)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	recoveryCodes, err := uc.mfa.ConfirmEnrollment(ctx, user, code)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	tokens, err := uc.startSession(ctx, user, client)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return user, tokens, recoveryCodes, nil
+}
+
+// completeLogin starts a session for a user who passed the password step,
+// unless a second factor is still needed
+func (uc *AuthUseCase) completeLogin(ctx context.Context, user *domain.User, client ClientInfo) (*domain.User, *AuthTokens, *MFAChallenge, error) {
+	if !user.IsActive() {
+		return nil, nil, nil, ErrAccountDeactivated
+	}
+
+	challenge, err := uc.mfa.Challenge(ctx, user)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if challenge != nil {
+		return user, nil, challenge, nil
+	}
+
+	tokens, err := uc.startSession(ctx, user, client)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return user, tokens, nil, nil
+}
+
+// RefreshSession exchanges a refresh token for a new access and refresh token.
+// Refresh tokens are single use; presenting one twice revokes the whole session.
+func (uc *AuthUseCase) RefreshSession(ctx context.Context, rawToken string, client ClientInfo) (*domain.User, *AuthTokens, error) {
+	refreshToken, err := uc.refreshTokenRepo.GetByHash(ctx, crypto.HashToken(rawToken, uc.jwtSecret))
+	if err != nil {
+		return nil, nil, err
+	}
+	if refreshToken == nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	session, err := uc.sessionRepo.GetByID(ctx, refreshToken.SessionID)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
+	}
+	if session == nil || !session.IsActive() {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	// A token that was already rotated is being replayed: revoke the token family
+	consumed, err := uc.refreshTokenRepo.MarkUsed(ctx, refreshToken.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !consumed {
+		if err := uc.sessionRepo.Revoke(ctx, session.ID); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrRefreshTokenReused
+	}
+
+	if time.Now().After(refreshToken.ExpiresAt) {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, session.UserID)
+	if err != nil {
+		return nil, nil, err
 	}
 	if user == nil {
-		return nil, "", ErrInvalidCredentials
+		return nil, nil, ErrUserNotFound
+	}
+	if !user.IsActive() {
+		return nil, nil, ErrAccountDeactivated
 	}
 
-	// Compare password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		return nil, "", ErrInvalidCredentials
+	if err := uc.sessionRepo.Touch(ctx, session.ID, client.IPAddress); err != nil {
+		return nil, nil, err
+	}
+
+	tokens, err := uc.issueTokens(ctx, user, session)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, tokens, nil
+}
+
+// Logout revokes a single session belonging to the user
+func (uc *AuthUseCase) Logout(ctx context.Context, userID, sessionID int64) error {
+	session, err := uc.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil || session.UserID != userID {
+		return ErrSessionNotFound
+	}
+
+	return uc.sessionRepo.Revoke(ctx, sessionID)
+}
+
+// LogoutAll revokes every session of the user, logging out all devices
+func (uc *AuthUseCase) LogoutAll(ctx context.Context, userID int64) error {
+	return uc.sessionRepo.RevokeAllByUserID(ctx, userID)
+}
+
+// GetSessions returns the active sessions of a user
+func (uc *AuthUseCase) GetSessions(ctx context.Context, userID int64) ([]*domain.Session, error) {
+	return uc.sessionRepo.GetActiveByUserID(ctx, userID)
+}
+
+// startSession creates a new session for the user and issues its first tokens
+func (uc *AuthUseCase) startSession(ctx context.Context, user *domain.User, client ClientInfo) (*AuthTokens, error) {
+	session := &domain.Session{
+		UserID:    user.ID,
+		UserAgent: client.UserAgent,
+		IPAddress: client.IPAddress,
+		ExpiresAt: time.Now().Add(uc.refreshTTL),
+	}
+
+	if err := uc.sessionRepo.Create(ctx, session); err != nil {
+		return nil, err
 	}
 
-	// Generate JWT token
-	token, err := uc.tokenService.GenerateToken(user.ID)
+	return uc.issueTokens(ctx, user, session)
+}
+
+// issueTokens generates an access token and a fresh refresh token for a session
+func (uc *AuthUseCase) issueTokens(ctx context.Context, user *domain.User, session *domain.Session) (*AuthTokens, error) {
+	rawRefresh, err := crypto.GenerateOpaqueToken(refreshTokenPrefix)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
 
-	return user, token, nil
+	refreshToken := &domain.RefreshToken{
+		SessionID: session.ID,
+		TokenHash: crypto.HashToken(rawRefresh, uc.jwtSecret),
+		ExpiresAt: session.ExpiresAt,
+	}
+	if err := uc.refreshTokenRepo.Create(ctx, refreshToken); err != nil {
+		return nil, err
+	}
+
+	accessToken, err := uc.tokenService.GenerateToken(user.ID, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthTokens{
+		AccessToken:  accessToken,
+		RefreshToken: rawRefresh,
+		ExpiresIn:    int64(uc.tokenService.AccessTTL().Seconds()),
+	}, nil
 }
 
 // UpdateUserSettings updates user name and/or password
@@ -144,24 +378,69 @@ func (uc *AuthUseCase) UpdateUserSettings(ctx context.Context, userID int64, nam
 	return user, nil
 }
 
-// CreateAPIKey generates a new API key for a user
-func (uc *AuthUseCase) CreateAPIKey(ctx context.Context, userID int64, name string) (string, int64, error) {
-	// Generate random key
-	rawKey := crypto.GenerateAPIKey()
-	keyHash := crypto.HashAPIKey(rawKey, uc.jwtSecret)
+// CreateAPIKey generates a new API key for a user, limited to the given scopes
+// and groups. Empty scopes or groups leave the key unrestricted in that dimension,
+// and a zero ttl creates a key that never expires.
+func (uc *AuthUseCase) CreateAPIKey(ctx context.Context, userID int64, name string, scopes []string, groupIDs []int64, ttl time.Duration) (string, *domain.APIKey, error) {
+	for _, scope := range scopes {
+		if !domain.IsValidScope(scope) {
+			return "", nil, fmt.Errorf("%w: %s", ErrInvalidScope, scope)
+		}
+	}
+
+	return uc.issueAPIKey(ctx, &domain.APIKey{
+		UserID:   userID,
+		Name:     name,
+		Scopes:   scopes,
+		GroupIDs: groupIDs,
+	}, ttl)
+}
 
-	apiKey := &domain.APIKey{
-		UserID:  userID,
-		Name:    name,
-		KeyHash: keyHash,
-		Active:  true,
+// RotateAPIKey issues a successor with the same name, scopes, groups and lifetime.
+// The old key keeps working for the rotation grace period so clients can switch over.
+// A key can only be rotated once; rotating it again during its grace period
+// would leave two live successors.
+func (uc *AuthUseCase) RotateAPIKey(ctx context.Context, userID, keyID int64) (string, *domain.APIKey, error) {
+	current, err := uc.getOwnAPIKey(ctx, userID, keyID)
+	if err != nil {
+		return "", nil, err
+	}
+	if !current.IsUsable() {
+		return "", nil, ErrAPIKeyNotUsable
+	}
+	existing, err := uc.apiKeyRepo.GetByReplacesID(ctx, current.ID)
+	if err != nil {
+		return "", nil, err
+	}
+	if existing != nil {
+		return "", nil, ErrAPIKeyRotated
 	}
 
-	if err := uc.apiKeyRepo.Create(ctx, apiKey); err != nil {
-		return "", 0, err
+	var ttl time.Duration
+	if current.ExpiresAt != nil {
+		ttl = current.ExpiresAt.Sub(current.CreatedAt)
+	}
+
+	rawKey, successor, err := uc.issueAPIKey(ctx, &domain.APIKey{
+		UserID:     current.UserID,
+		Name:       current.Name,
+		Scopes:     current.Scopes,
+		GroupIDs:   current.GroupIDs,
+		ReplacesID: &current.ID,
+	}, ttl)
+	if err != nil {
+		return "", nil, err
 	}
 
-	return rawKey, apiKey.ID, nil
+	// Never extend the old key's lifetime, only shorten it
+	graceEnd := time.Now().Add(uc.apiKeyRotationGrace)
+	if current.ExpiresAt == nil || graceEnd.Before(*current.ExpiresAt) {
+		if err := uc.apiKeyRepo.UpdateExpiry(ctx, current.ID, graceEnd); err != nil {
+			return "", nil, err
+		}
+	}
+
+	return rawKey, successor, nil
 }
 
 // GetAPIKeys returns all API keys for a user
@@ -169,21 +448,49 @@ func (uc *AuthUseCase) GetAPIKeys(ctx context.Context, userID int64) ([]*domain.
 	return uc.apiKeyRepo.GetByUserID(ctx, userID)
 }
 
-// RevokeAPIKey deletes an API key
+// IsAPIKeyExpiringSoon reports whether a usable key expires within the warning window
+func (uc *AuthUseCase) IsAPIKeyExpiringSoon(apiKey *domain.APIKey) bool {
+	return apiKey.IsUsable() && apiKey.ExpiresAt != nil && time.Until(*apiKey.ExpiresAt) <= uc.apiKeyExpiryWarning
+}
+
+// RevokeAPIKey deactivates an API key. The row is kept for auditing.
 func (uc *AuthUseCase) RevokeAPIKey(ctx context.Context, userID, keyID int64) error {
-	apiKey, err := uc.apiKeyRepo.GetByID(ctx, keyID)
-	if err != nil {
+	if _, err := uc.getOwnAPIKey(ctx, userID, keyID); err != nil {
 		return err
 	}
-	if apiKey == nil || apiKey.UserID != userID {
-		return errors.New("API key not found or does not belong to user")
+	return uc.apiKeyRepo.Deactivate(ctx, keyID)
+}
+
+// issueAPIKey generates the secret for a key and stores its hash
+func (uc *AuthUseCase) issueAPIKey(ctx context.Context, apiKey *domain.APIKey, ttl time.Duration) (string, *domain.APIKey, error) {
+	rawKey := crypto.GenerateAPIKey()
+	apiKey.KeyHash = crypto.HashAPIKey(rawKey, uc.jwtSecret)
+	apiKey.Active = true
+	if ttl > 0 {
+		expiresAt := time.Now().Add(ttl)
+		apiKey.ExpiresAt = &expiresAt
 	}
 
-	if err := uc.apiKeyRepo.Delete(ctx, keyID); err != nil {
-		return err
+	if err := uc.apiKeyRepo.Create(ctx, apiKey); err != nil {
+		return "", nil, err
 	}
 
-	return nil
+	return rawKey, apiKey, nil
+}
+
+// getOwnAPIKey loads a key and checks that it belongs to the user
+func (uc *AuthUseCase) getOwnAPIKey(ctx context.Context, userID, keyID int64) (*domain.APIKey, error) {
+	apiKey, err := uc.apiKeyRepo.GetByID(ctx, keyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if apiKey == nil || apiKey.UserID != userID {
+		return nil, ErrAPIKeyNotFound
+	}
+	return apiKey, nil
 }
 
 // VerifyAPIKey validates an API key and returns the associated user
@@ -193,14 +500,17 @@ func (uc *AuthUseCase) VerifyAPIKey(ctx context.Context, rawKey string) (*domain
 	if err != nil {
 		return nil, err
 	}
-	if apiKey == nil || !apiKey.Active {
-		return nil, errors.New("invalid or revoked API key")
+	if apiKey == nil || !apiKey.IsUsable() {
+		return nil, errors.New("invalid, revoked or expired API key")
 	}
 
 	user, err := uc.userRepo.GetByID(ctx, apiKey.UserID)
 	if err != nil {
 		return nil, err
 	}
+	if user == nil || !user.IsActive() {
+		return nil, ErrAccountDeactivated
+	}
 
 	// Update last used timestamp (async, ignore errors)
 	go uc.apiKeyRepo.UpdateLastUsed(context.Background(), apiKey.ID)
@@ -208,6 +518,11 @@ func (uc *AuthUseCase) VerifyAPIKey(ctx context.Context, rawKey string) (*domain
 	return user, nil
 }
 
+// JWKS returns the public keys that verify access tokens
+func (uc *AuthUseCase) JWKS() crypto.JWKS {
+	return uc.tokenService.JWKS()
+}
+
 // GetUserByID retrieves a user by ID
 func (uc *AuthUseCase) GetUserByID(ctx context.Context, id int64) (*domain.User, error) {
 	return uc.userRepo.GetByID(ctx, id)
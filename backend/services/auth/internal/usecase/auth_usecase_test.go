@@ -0,0 +1,75 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/raufhm/fairflow/services/auth/internal/usecase"
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// apiKeyRepo keeps API keys in memory
+type apiKeyRepo struct {
+	domain.APIKeyRepository
+	keys []*domain.APIKey
+}
+
+func (r *apiKeyRepo) Create(ctx context.Context, apiKey *domain.APIKey) error {
+	apiKey.ID = int64(len(r.keys) + 1)
+	apiKey.CreatedAt = time.Now()
+	r.keys = append(r.keys, apiKey)
+	return nil
+}
+
+func (r *apiKeyRepo) GetByID(ctx context.Context, id int64) (*domain.APIKey, error) {
+	for _, key := range r.keys {
+		if key.ID == id {
+			copied := *key
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *apiKeyRepo) GetByReplacesID(ctx context.Context, id int64) (*domain.APIKey, error) {
+	for _, key := range r.keys {
+		if key.ReplacesID != nil && *key.ReplacesID == id {
+			copied := *key
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *apiKeyRepo) UpdateExpiry(ctx context.Context, id int64, expiresAt time.Time) error {
+	for _, key := range r.keys {
+		if key.ID == id {
+			key.ExpiresAt = &expiresAt
+		}
+	}
+	return nil
+}
+
+func TestRotateAPIKey_OnlyOncePerKey(t *testing.T) {
+	repo := &apiKeyRepo{}
+	uc := usecase.NewAuthUseCase(nil, repo, nil, nil, nil, nil, nil, "secret", time.Hour, time.Hour, time.Hour)
+	ctx := context.Background()
+	require.NoError(t, repo.Create(ctx, &domain.APIKey{UserID: 1, Name: "ci", Active: true}))
+
+	_, successor, err := uc.RotateAPIKey(ctx, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), *successor.ReplacesID)
+
+	// The old key is in its grace period and already has a successor
+	_, _, err = uc.RotateAPIKey(ctx, 1, 1)
+	assert.ErrorIs(t, err, usecase.ErrAPIKeyRotated)
+	assert.Len(t, repo.keys, 2)
+
+	// Rotation carries on from the successor
+	_, next, err := uc.RotateAPIKey(ctx, 1, successor.ID)
+	require.NoError(t, err)
+	assert.Equal(t, successor.ID, *next.ReplacesID)
+}
@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/crypto"
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/logger"
+	"github.com/raufhm/fairflow/shared/mailer"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInvalidVerificationToken = errors.New("invalid or expired email verification token")
+	ErrEmailAlreadyVerified     = errors.New("email address is already verified")
+)
+
+// verificationTokenPrefix identifies email verification tokens issued by FairFlow
+const verificationTokenPrefix = "rr_verify_"
+
+type EmailVerificationUseCase struct {
+	userRepo    domain.UserRepository
+	tokenRepo   domain.EmailVerificationTokenRepository
+	auditRepo   domain.AuditLogRepository
+	mailer      mailer.Mailer
+	tokenSecret string
+	appBaseURL  string
+	tokenTTL    time.Duration
+}
+
+func NewEmailVerificationUseCase(
+	userRepo domain.UserRepository,
+	tokenRepo domain.EmailVerificationTokenRepository,
+	auditRepo domain.AuditLogRepository,
+	mailer mailer.Mailer,
+	tokenSecret string,
+	appBaseURL string,
+	tokenTTL time.Duration,
+) *EmailVerificationUseCase {
+	return &EmailVerificationUseCase{
+		userRepo:    userRepo,
+		tokenRepo:   tokenRepo,
+		auditRepo:   auditRepo,
+		mailer:      mailer,
+		tokenSecret: tokenSecret,
+		appBaseURL:  strings.TrimSuffix(appBaseURL, "/"),
+		tokenTTL:    tokenTTL,
+	}
+}
+
+// SendVerification emails the user a link that verifies their address.
+// Only the most recent link stays valid.
+func (uc *EmailVerificationUseCase) SendVerification(ctx context.Context, user *domain.User) error {
+	if user.IsEmailVerified() {
+		return ErrEmailAlreadyVerified
+	}
+
+	if err := uc.tokenRepo.InvalidateByUserID(ctx, user.ID); err != nil {
+		return err
+	}
+
+	rawToken, err := crypto.GenerateOpaqueToken(verificationTokenPrefix)
+	if err != nil {
+		return err
+	}
+
+	token := &domain.EmailVerificationToken{
+		UserID:    user.ID,
+		TokenHash: crypto.HashToken(rawToken, uc.tokenSecret),
+		ExpiresAt: time.Now().Add(uc.tokenTTL),
+	}
+	if err := uc.tokenRepo.Create(ctx, token); err != nil {
+		return err
+	}
+
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your FairFlow email address",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nPlease confirm that this is your email address by opening the link below:\n\n%s/verify-email?token=%s\n\nThe link expires in %s. Until you verify your address your account has limited access.\n",
+			user.Name, uc.appBaseURL, rawToken, uc.tokenTTL,
+		),
+	}
+	return uc.mailer.Send(ctx, msg)
+}
+
+// VerifyEmail marks the token owner's email address as verified
+func (uc *EmailVerificationUseCase) VerifyEmail(ctx context.Context, rawToken, ipAddress string) error {
+	token, err := uc.tokenRepo.GetByHash(ctx, crypto.HashToken(rawToken, uc.tokenSecret))
+	if err != nil {
+		return err
+	}
+	if token == nil || token.UsedAt != nil || time.Now().After(token.ExpiresAt) {
+		return ErrInvalidVerificationToken
+	}
+
+	consumed, err := uc.tokenRepo.MarkUsed(ctx, token.ID)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidVerificationToken
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, token.UserID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrInvalidVerificationToken
+	}
+
+	if err := uc.userRepo.MarkEmailVerified(ctx, user.ID); err != nil {
+		return err
+	}
+
+	uc.audit(ctx, user, "email_verified", ipAddress)
+	return nil
+}
+
+// audit records a verification event. Failures are logged but do not fail the request.
+func (uc *EmailVerificationUseCase) audit(ctx context.Context, user *domain.User, action, ipAddress string) {
+	resourceType := "user"
+	entry := &domain.AuditLog{
+		UserID:       &user.ID,
+		UserName:     user.Name,
+		Action:       action,
+		ResourceType: &resourceType,
+		ResourceID:   &user.ID,
+		IPAddress:    ipAddress,
+	}
+
+	if err := uc.auditRepo.Create(ctx, entry); err != nil {
+		logger.Log.Error("Failed to write audit log", zap.String("action", action), zap.Error(err))
+	}
+}
@@ -0,0 +1,276 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/crypto"
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/logger"
+	"github.com/raufhm/fairflow/shared/mailer"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrInvalidInvitation   = errors.New("invalid or expired invitation")
+	ErrInvitationNotFound  = errors.New("invitation not found")
+	ErrInvitationForbidden = errors.New("you are not allowed to send this invitation")
+)
+
+// invitationTokenPrefix identifies invitation tokens issued by FairFlow
+const invitationTokenPrefix = "rr_invite_"
+
+// invitableRoles lists the roles each role may invite people with
+var invitableRoles = map[domain.UserRole][]domain.UserRole{
+	domain.RoleSuperAdmin: {domain.RoleSuperAdmin, domain.RoleAdmin, domain.RoleOrgAdmin, domain.RoleManager, domain.RoleUser},
+	domain.RoleAdmin:      {domain.RoleOrgAdmin, domain.RoleManager, domain.RoleUser},
+	domain.RoleOrgAdmin:   {domain.RoleOrgAdmin, domain.RoleManager, domain.RoleUser},
+	domain.RoleManager:    {domain.RoleUser},
+}
+
+type InvitationUseCase struct {
+	authUseCase    *AuthUseCase
+	verification   *EmailVerificationUseCase
+	invitationRepo domain.InvitationRepository
+	userRepo       domain.UserRepository
+	orgRepo        domain.OrganizationRepository
+	auditRepo      domain.AuditLogRepository
+	transactor     domain.Transactor
+	mailer         mailer.Mailer
+	tokenSecret    string
+	appBaseURL     string
+	invitationTTL  time.Duration
+}
+
+func NewInvitationUseCase(
+	authUseCase *AuthUseCase,
+	verification *EmailVerificationUseCase,
+	invitationRepo domain.InvitationRepository,
+	userRepo domain.UserRepository,
+	orgRepo domain.OrganizationRepository,
+	auditRepo domain.AuditLogRepository,
+	transactor domain.Transactor,
+	mailer mailer.Mailer,
+	tokenSecret string,
+	appBaseURL string,
+	invitationTTL time.Duration,
+) *InvitationUseCase {
+	return &InvitationUseCase{
+		authUseCase:    authUseCase,
+		verification:   verification,
+		invitationRepo: invitationRepo,
+		userRepo:       userRepo,
+		orgRepo:        orgRepo,
+		auditRepo:      auditRepo,
+		transactor:     transactor,
+		mailer:         mailer,
+		tokenSecret:    tokenSecret,
+		appBaseURL:     strings.TrimSuffix(appBaseURL, "/"),
+		invitationTTL:  invitationTTL,
+	}
+}
+
+// CreateInvitation emails an invitation to register with the given role. Admins
+// and managers invite into their own organization; super admins may pick any
+// organization. Earlier invitations for the same email are revoked.
+func (uc *InvitationUseCase) CreateInvitation(ctx context.Context, inviter *domain.User, email string, role domain.UserRole, orgID *int64, ipAddress string) (*domain.Invitation, error) {
+	email = normalizeEmail(email)
+	if role == "" {
+		role = domain.RoleUser
+	}
+	if !canInvite(inviter.Role, role) {
+		return nil, ErrInvitationForbidden
+	}
+
+	if !inviter.IsSuperAdmin() {
+		if orgID != nil && (inviter.OrganizationID == nil || *orgID != *inviter.OrganizationID) {
+			return nil, ErrInvitationForbidden
+		}
+		orgID = inviter.OrganizationID
+	}
+	if orgID != nil {
+		org, err := uc.orgRepo.GetByID(ctx, *orgID)
+		if err != nil {
+			return nil, err
+		}
+		if org == nil {
+			return nil, ErrOrganizationNotFound
+		}
+	}
+
+	existing, err := uc.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrEmailExists
+	}
+
+	if err := uc.invitationRepo.RevokePendingByEmail(ctx, email); err != nil {
+		return nil, err
+	}
+
+	rawToken, err := crypto.GenerateOpaqueToken(invitationTokenPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := &domain.Invitation{
+		Email:          email,
+		Role:           role,
+		OrganizationID: orgID,
+		TokenHash:      crypto.HashToken(rawToken, uc.tokenSecret),
+		InvitedBy:      inviter.ID,
+		ExpiresAt:      time.Now().Add(uc.invitationTTL),
+	}
+	if err := uc.invitationRepo.Create(ctx, invitation); err != nil {
+		return nil, err
+	}
+
+	msg := mailer.Message{
+		To:      email,
+		Subject: "You have been invited to FairFlow",
+		Body: fmt.Sprintf(
+			"Hi,\n\n%s has invited you to join FairFlow. Use the link below to create your account:\n\n%s/accept-invitation?token=%s\n\nThe invitation expires in %s.\n",
+			inviter.Name, uc.appBaseURL, rawToken, uc.invitationTTL,
+		),
+	}
+	if err := uc.mailer.Send(ctx, msg); err != nil {
+		return nil, err
+	}
+
+	uc.audit(ctx, inviter, "invitation_created", invitation.ID, ipAddress)
+	return invitation, nil
+}
+
+// ListInvitations returns the pending invitations the user may manage
+func (uc *InvitationUseCase) ListInvitations(ctx context.Context, user *domain.User) ([]*domain.Invitation, error) {
+	if user.IsSuperAdmin() {
+		return uc.invitationRepo.GetPending(ctx)
+	}
+	if len(invitableRoles[user.Role]) == 0 {
+		return nil, ErrInvitationForbidden
+	}
+	if user.OrganizationID == nil {
+		return []*domain.Invitation{}, nil
+	}
+	return uc.invitationRepo.GetPendingByOrganizationID(ctx, *user.OrganizationID)
+}
+
+// RevokeInvitation cancels a pending invitation. Managers may only revoke
+// invitations they sent.
+func (uc *InvitationUseCase) RevokeInvitation(ctx context.Context, user *domain.User, id int64, ipAddress string) error {
+	invitation, err := uc.invitationRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if invitation == nil || !user.BelongsTo(invitation.OrganizationID) {
+		return ErrInvitationNotFound
+	}
+	if len(invitableRoles[user.Role]) == 0 || (user.Role == domain.RoleManager && invitation.InvitedBy != user.ID) {
+		return ErrInvitationForbidden
+	}
+
+	revoked, err := uc.invitationRepo.Revoke(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrInvitationNotFound
+	}
+
+	uc.audit(ctx, user, "invitation_revoked", id, ipAddress)
+	return nil
+}
+
+// Accept registers the invited user and signs them in. The email, role and
+// organization come from the invitation. A verification email is sent and the
+// account stays restricted until the address is verified.
+func (uc *InvitationUseCase) Accept(ctx context.Context, rawToken, password, name string, client ClientInfo) (*domain.User, *AuthTokens, *MFAChallenge, error) {
+	if len(password) < minPasswordLength {
+		return nil, nil, nil, ErrWeakPassword
+	}
+
+	invitation, err := uc.invitationRepo.GetByHash(ctx, crypto.HashToken(rawToken, uc.tokenSecret))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if invitation == nil || !invitation.IsPending() {
+		return nil, nil, nil, ErrInvalidInvitation
+	}
+
+	existing, err := uc.userRepo.GetByEmail(ctx, invitation.Email)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if existing != nil {
+		return nil, nil, nil, ErrEmailExists
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	user := &domain.User{
+		Email:          invitation.Email,
+		PasswordHash:   string(hashedPassword),
+		Name:           name,
+		Role:           invitation.Role,
+		OrganizationID: invitation.OrganizationID,
+	}
+
+	// The invitation is only used up if the account is created with it
+	err = uc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		accepted, err := uc.invitationRepo.MarkAccepted(ctx, invitation.ID)
+		if err != nil {
+			return err
+		}
+		if !accepted {
+			return ErrInvalidInvitation
+		}
+		return uc.userRepo.Create(ctx, user)
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// The user can ask for another link, so a mail failure does not fail registration
+	if err := uc.verification.SendVerification(ctx, user); err != nil {
+		logger.Log.Error("Failed to send verification email", zap.Int64("user_id", user.ID), zap.Error(err))
+	}
+
+	uc.audit(ctx, user, "invitation_accepted", invitation.ID, client.IPAddress)
+	return uc.authUseCase.completeLogin(ctx, user, client)
+}
+
+// audit records an invitation event. Failures are logged but do not fail the request.
+func (uc *InvitationUseCase) audit(ctx context.Context, actor *domain.User, action string, invitationID int64, ipAddress string) {
+	resourceType := "invitation"
+	entry := &domain.AuditLog{
+		UserID:       &actor.ID,
+		UserName:     actor.Name,
+		Action:       action,
+		ResourceType: &resourceType,
+		ResourceID:   &invitationID,
+		IPAddress:    ipAddress,
+	}
+
+	if err := uc.auditRepo.Create(ctx, entry); err != nil {
+		logger.Log.Error("Failed to write audit log", zap.String("action", action), zap.Error(err))
+	}
+}
+
+// canInvite reports whether a user with inviterRole may invite someone as role
+func canInvite(inviterRole, role domain.UserRole) bool {
+	for _, allowed := range invitableRoles[inviterRole] {
+		if allowed == role {
+			return true
+		}
+	}
+	return false
+}
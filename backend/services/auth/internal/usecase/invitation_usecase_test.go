@@ -0,0 +1,92 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/raufhm/fairflow/services/auth/internal/usecase"
+	"github.com/raufhm/fairflow/shared/crypto"
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// txKey marks contexts inside a transaction opened by transactor
+type txKey struct{}
+
+// transactor runs fn directly and calls the registered undo functions when it fails
+type transactor struct {
+	undo []func()
+}
+
+func (t *transactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	t.undo = nil
+	err := fn(context.WithValue(ctx, txKey{}, true))
+	if err != nil {
+		for i := len(t.undo) - 1; i >= 0; i-- {
+			t.undo[i]()
+		}
+	}
+	return err
+}
+
+// invitationRepo serves a single invitation
+type invitationRepo struct {
+	domain.InvitationRepository
+	tx         *transactor
+	invitation *domain.Invitation
+}
+
+func (r *invitationRepo) GetByHash(ctx context.Context, tokenHash string) (*domain.Invitation, error) {
+	if tokenHash != r.invitation.TokenHash {
+		return nil, nil
+	}
+	copied := *r.invitation
+	return &copied, nil
+}
+
+func (r *invitationRepo) MarkAccepted(ctx context.Context, id int64) (bool, error) {
+	if !r.invitation.IsPending() {
+		return false, nil
+	}
+	now := time.Now()
+	r.invitation.AcceptedAt = &now
+	if ctx.Value(txKey{}) != nil {
+		r.tx.undo = append(r.tx.undo, func() { r.invitation.AcceptedAt = nil })
+	}
+	return true, nil
+}
+
+// userRepo fails to create users, as on a unique email race
+type userRepo struct {
+	domain.UserRepository
+	createErr error
+}
+
+func (r *userRepo) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	return nil, nil
+}
+
+func (r *userRepo) Create(ctx context.Context, user *domain.User) error {
+	return r.createErr
+}
+
+func TestAccept_FailedSignUpKeepsTheInvitation(t *testing.T) {
+	tx := &transactor{}
+	invitations := &invitationRepo{tx: tx, invitation: &domain.Invitation{
+		ID:        1,
+		Email:     "ana@example.com",
+		Role:      domain.RoleUser,
+		TokenHash: crypto.HashToken("invite-token", "secret"),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}}
+	users := &userRepo{createErr: errors.New("duplicate key value violates unique constraint")}
+	uc := usecase.NewInvitationUseCase(nil, nil, invitations, users, nil, nil, tx, nil, "secret", "http://localhost", time.Hour)
+
+	_, _, _, err := uc.Accept(context.Background(), "invite-token", "correct horse battery", "Ana", usecase.ClientInfo{})
+
+	require.ErrorIs(t, err, users.createErr)
+	assert.True(t, invitations.invitation.IsPending())
+}
@@ -0,0 +1,250 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/logger"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrLockoutNotFound  = errors.New("no lockout found")
+	ErrLockoutForbidden = errors.New("only super admins can unlock client IPs")
+)
+
+// LoginThrottledError is returned when a login is attempted too soon after
+// previous failures or while the account or client IP is locked out
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+func (e *LoginThrottledError) Error() string {
+	if e.Locked {
+		return "too many failed login attempts, try again later"
+	}
+	return fmt.Sprintf("too many failed login attempts, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// LoginGuardPolicy configures brute-force protection
+type LoginGuardPolicy struct {
+	Window               time.Duration // Failures older than this are forgotten
+	FreeAttempts         int           // Failures allowed before delays apply
+	BaseDelay            time.Duration // First delay, doubled for every further failure
+	MaxDelay             time.Duration
+	AccountLockThreshold int // Failures that lock an account
+	IPLockThreshold      int // Failures that lock a client IP, across all accounts
+	LockoutDuration      time.Duration
+}
+
+// DefaultLoginGuardPolicy returns the policy used when none is configured
+func DefaultLoginGuardPolicy() LoginGuardPolicy {
+	return LoginGuardPolicy{
+		Window:               15 * time.Minute,
+		FreeAttempts:         3,
+		BaseDelay:            time.Second,
+		MaxDelay:             30 * time.Second,
+		AccountLockThreshold: 10,
+		IPLockThreshold:      50,
+		LockoutDuration:      15 * time.Minute,
+	}
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// dummyPasswordHash is compared against when the email is unknown so that a
+// failed login takes as long whether or not the account exists
+func dummyPasswordHash() []byte {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("fairflow-timing-equalizer"), bcrypt.DefaultCost)
+	})
+	return dummyHash
+}
+
+// LoginGuard tracks failed logins per account and per IP, enforcing
+// progressive delays and temporary lockouts
+type LoginGuard struct {
+	throttleRepo domain.LoginThrottleRepository
+	userRepo     domain.UserRepository
+	auditRepo    domain.AuditLogRepository
+	policy       LoginGuardPolicy
+}
+
+func NewLoginGuard(
+	throttleRepo domain.LoginThrottleRepository,
+	userRepo domain.UserRepository,
+	auditRepo domain.AuditLogRepository,
+	policy LoginGuardPolicy,
+) *LoginGuard {
+	return &LoginGuard{
+		throttleRepo: throttleRepo,
+		userRepo:     userRepo,
+		auditRepo:    auditRepo,
+		policy:       policy,
+	}
+}
+
+// Check returns a *LoginThrottledError if the account or IP may not attempt a login yet
+func (g *LoginGuard) Check(ctx context.Context, email, ipAddress string) error {
+	for _, key := range g.keys(email, ipAddress) {
+		throttle, err := g.throttleRepo.Get(ctx, key.scope, key.identifier)
+		if err != nil {
+			return err
+		}
+		if throttle == nil {
+			continue
+		}
+
+		if throttle.IsLocked() {
+			return &LoginThrottledError{RetryAfter: time.Until(*throttle.LockedUntil), Locked: true}
+		}
+		if time.Since(throttle.LastFailureAt) > g.policy.Window {
+			continue
+		}
+		if wait := g.delay(throttle.Failures) - time.Since(throttle.LastFailureAt); wait > 0 {
+			return &LoginThrottledError{RetryAfter: wait}
+		}
+	}
+	return nil
+}
+
+// RecordFailure counts a failed attempt and locks the account or IP once its
+// threshold is reached. user is nil when the email is unknown.
+func (g *LoginGuard) RecordFailure(ctx context.Context, email, ipAddress string, user *domain.User) error {
+	for _, key := range g.keys(email, ipAddress) {
+		throttle, err := g.throttleRepo.RecordFailure(ctx, key.scope, key.identifier, g.policy.Window)
+		if err != nil {
+			return err
+		}
+
+		threshold := g.policy.AccountLockThreshold
+		if key.scope == domain.ThrottleScopeIP {
+			threshold = g.policy.IPLockThreshold
+		}
+		if threshold <= 0 || throttle.Failures < threshold {
+			continue
+		}
+
+		lockedUntil := time.Now().Add(g.policy.LockoutDuration)
+		if err := g.throttleRepo.Lock(ctx, throttle.ID, lockedUntil); err != nil {
+			return err
+		}
+
+		details := fmt.Sprintf("%s %s locked until %s after %d failed logins", key.scope, key.identifier, lockedUntil.UTC().Format(time.RFC3339), throttle.Failures)
+		g.audit(ctx, user, key.scope+"_locked", details, ipAddress)
+	}
+	return nil
+}
+
+// RecordSuccess clears the account's failure count. The IP count is kept so a
+// client cannot reset it by signing in to an account it controls.
+func (g *LoginGuard) RecordSuccess(ctx context.Context, email string) error {
+	return g.throttleRepo.Reset(ctx, domain.ThrottleScopeAccount, normalizeEmail(email))
+}
+
+// GetLockouts returns the active lockouts an admin may see. Super admins see
+// every account and IP lockout; other admins only see accounts in their own
+// organization, since a client IP is not tied to any one tenant.
+func (g *LoginGuard) GetLockouts(ctx context.Context, admin *domain.User) ([]*domain.LoginThrottle, error) {
+	if admin.IsSuperAdmin() {
+		return g.throttleRepo.GetLocked(ctx)
+	}
+	return g.throttleRepo.GetLockedAccounts(ctx, admin.OrganizationID)
+}
+
+// Unlock lifts a lockout on behalf of an admin. Admins other than super admins
+// can only unlock accounts in their own organization.
+func (g *LoginGuard) Unlock(ctx context.Context, scope, identifier string, admin *domain.User, ipAddress string) error {
+	if scope == domain.ThrottleScopeAccount {
+		identifier = normalizeEmail(identifier)
+	}
+
+	if !admin.IsSuperAdmin() {
+		if scope != domain.ThrottleScopeAccount {
+			return ErrLockoutForbidden
+		}
+		user, err := g.userRepo.GetByEmail(ctx, identifier)
+		if err != nil {
+			return err
+		}
+		// Accounts in other organizations are reported as not found
+		if user == nil || !admin.BelongsTo(user.OrganizationID) {
+			return ErrLockoutNotFound
+		}
+	}
+
+	throttle, err := g.throttleRepo.Get(ctx, scope, identifier)
+	if err != nil {
+		return err
+	}
+	if throttle == nil {
+		return ErrLockoutNotFound
+	}
+
+	if err := g.throttleRepo.Reset(ctx, scope, identifier); err != nil {
+		return err
+	}
+
+	g.audit(ctx, admin, scope+"_unlocked", fmt.Sprintf("%s %s unlocked", scope, identifier), ipAddress)
+	return nil
+}
+
+// delay is the minimum time between the last failure and the next attempt
+func (g *LoginGuard) delay(failures int) time.Duration {
+	if failures < g.policy.FreeAttempts {
+		return 0
+	}
+	exponent := float64(failures - g.policy.FreeAttempts)
+	delay := time.Duration(float64(g.policy.BaseDelay) * math.Pow(2, exponent))
+	if delay > g.policy.MaxDelay || delay <= 0 {
+		return g.policy.MaxDelay
+	}
+	return delay
+}
+
+type throttleKey struct {
+	scope      string
+	identifier string
+}
+
+func (g *LoginGuard) keys(email, ipAddress string) []throttleKey {
+	keys := []throttleKey{{domain.ThrottleScopeAccount, normalizeEmail(email)}}
+	if ipAddress != "" {
+		keys = append(keys, throttleKey{domain.ThrottleScopeIP, ipAddress})
+	}
+	return keys
+}
+
+func (g *LoginGuard) audit(ctx context.Context, actor *domain.User, action, details, ipAddress string) {
+	resourceType := "login"
+	entry := &domain.AuditLog{
+		UserName:     "anonymous",
+		Action:       action,
+		ResourceType: &resourceType,
+		Details:      &details,
+		IPAddress:    ipAddress,
+	}
+	if actor != nil {
+		entry.UserID = &actor.ID
+		entry.UserName = actor.Name
+	}
+
+	if err := g.auditRepo.Create(ctx, entry); err != nil {
+		logger.Log.Error("Failed to write audit log", zap.String("action", action), zap.Error(err))
+	}
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,326 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/crypto"
+	"github.com/raufhm/fairflow/shared/domain"
+)
+
+var (
+	ErrInvalidMFAToken     = errors.New("invalid or expired MFA challenge")
+	ErrInvalidMFACode      = errors.New("invalid authentication code")
+	ErrMFAAlreadyEnrolled  = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnrolled      = errors.New("two-factor authentication is not enabled")
+	ErrMFARequiredByPolicy = errors.New("two-factor authentication is required for your role or organization")
+)
+
+// mfaIssuer is the account issuer shown in authenticator apps
+const mfaIssuer = "FairFlow"
+
+// recoveryCodeCount is the number of recovery codes issued per enrolment
+const recoveryCodeCount = 10
+
+// MFAChallenge is returned instead of tokens when a login needs a second factor
+type MFAChallenge struct {
+	Token              string `json:"mfa_token"`
+	ExpiresIn          int64  `json:"expires_in"`
+	EnrollmentRequired bool   `json:"enrollment_required"` // The user must enrol before the login can complete
+}
+
+// TOTPEnrollment holds what an authenticator app needs to register a new factor
+type TOTPEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// MFAStatus summarises a user's two-factor configuration
+type MFAStatus struct {
+	Enabled                bool `json:"enabled"`
+	Required               bool `json:"required"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+type MFAUseCase struct {
+	userRepo         domain.UserRepository
+	totpRepo         domain.TOTPFactorRepository
+	recoveryCodeRepo domain.RecoveryCodeRepository
+	orgRepo          domain.OrganizationRepository
+	tokenService     *crypto.TokenService
+	secret           string // HMAC secret for recovery code hashes
+	encryptionKey    string // Encrypts TOTP secrets at rest
+	requiredRoles    []domain.UserRole
+	challengeTTL     time.Duration
+}
+
+func NewMFAUseCase(
+	userRepo domain.UserRepository,
+	totpRepo domain.TOTPFactorRepository,
+	recoveryCodeRepo domain.RecoveryCodeRepository,
+	orgRepo domain.OrganizationRepository,
+	tokenService *crypto.TokenService,
+	secret string,
+	encryptionKey string,
+	requiredRoles []domain.UserRole,
+	challengeTTL time.Duration,
+) *MFAUseCase {
+	return &MFAUseCase{
+		userRepo:         userRepo,
+		totpRepo:         totpRepo,
+		recoveryCodeRepo: recoveryCodeRepo,
+		orgRepo:          orgRepo,
+		tokenService:     tokenService,
+		secret:           secret,
+		encryptionKey:    encryptionKey,
+		requiredRoles:    requiredRoles,
+		challengeTTL:     challengeTTL,
+	}
+}
+
+// IsRequired reports whether policy forces two-factor authentication for the user,
+// either because of their role or because their organization requires it
+func (uc *MFAUseCase) IsRequired(ctx context.Context, user *domain.User) (bool, error) {
+	for _, role := range uc.requiredRoles {
+		if user.Role == role {
+			return true, nil
+		}
+	}
+
+	if user.OrganizationID == nil {
+		return false, nil
+	}
+	org, err := uc.orgRepo.GetByID(ctx, *user.OrganizationID)
+	if err != nil {
+		return false, err
+	}
+	return org != nil && org.RequireMFA, nil
+}
+
+// Challenge returns the MFA challenge a login must pass before tokens are issued,
+// or nil if the user has no second factor and policy does not require one
+func (uc *MFAUseCase) Challenge(ctx context.Context, user *domain.User) (*MFAChallenge, error) {
+	factor, err := uc.totpRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	enrolled := factor != nil && factor.IsConfirmed()
+	if !enrolled {
+		required, err := uc.IsRequired(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		if !required {
+			return nil, nil
+		}
+	}
+
+	token, err := uc.tokenService.GenerateMFAChallengeToken(user.ID, uc.challengeTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MFAChallenge{
+		Token:              token,
+		ExpiresIn:          int64(uc.challengeTTL.Seconds()),
+		EnrollmentRequired: !enrolled,
+	}, nil
+}
+
+// ResolveChallenge returns the user a challenge token was issued to
+func (uc *MFAUseCase) ResolveChallenge(ctx context.Context, mfaToken string) (*domain.User, error) {
+	claims, err := uc.tokenService.VerifyMFAChallengeToken(mfaToken)
+	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.IsActive() {
+		return nil, ErrInvalidMFAToken
+	}
+	return user, nil
+}
+
+// GetStatus returns the user's two-factor configuration
+func (uc *MFAUseCase) GetStatus(ctx context.Context, user *domain.User) (*MFAStatus, error) {
+	factor, err := uc.totpRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	required, err := uc.IsRequired(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &MFAStatus{
+		Enabled:  factor != nil && factor.IsConfirmed(),
+		Required: required,
+	}
+	if status.Enabled {
+		remaining, err := uc.recoveryCodeRepo.CountUnused(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		status.RecoveryCodesRemaining = remaining
+	}
+	return status, nil
+}
+
+// BeginEnrollment creates a new, unconfirmed TOTP secret for the user.
+// Starting again before confirming replaces the pending secret.
+func (uc *MFAUseCase) BeginEnrollment(ctx context.Context, user *domain.User) (*TOTPEnrollment, error) {
+	factor, err := uc.totpRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if factor != nil {
+		if factor.IsConfirmed() {
+			return nil, ErrMFAAlreadyEnrolled
+		}
+		if err := uc.totpRepo.DeleteByUserID(ctx, user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	secret, err := crypto.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := crypto.EncryptSecret(secret, uc.encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.totpRepo.Create(ctx, &domain.TOTPFactor{UserID: user.ID, Secret: encrypted}); err != nil {
+		return nil, err
+	}
+
+	return &TOTPEnrollment{
+		Secret:          secret,
+		ProvisioningURI: crypto.TOTPProvisioningURI(secret, mfaIssuer, user.Email),
+	}, nil
+}
+
+// ConfirmEnrollment activates the pending factor once the user proves their
+// authenticator produces valid codes. It returns a fresh set of recovery codes.
+func (uc *MFAUseCase) ConfirmEnrollment(ctx context.Context, user *domain.User, code string) ([]string, error) {
+	factor, err := uc.totpRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if factor == nil {
+		return nil, ErrMFANotEnrolled
+	}
+	if factor.IsConfirmed() {
+		return nil, ErrMFAAlreadyEnrolled
+	}
+
+	if err := uc.verifyTOTP(ctx, factor, code); err != nil {
+		return nil, err
+	}
+
+	if err := uc.totpRepo.Confirm(ctx, factor.ID); err != nil {
+		return nil, err
+	}
+
+	return uc.issueRecoveryCodes(ctx, user.ID)
+}
+
+// VerifyCode checks a TOTP code, or failing that a recovery code, for an enrolled user
+func (uc *MFAUseCase) VerifyCode(ctx context.Context, user *domain.User, code string) error {
+	factor, err := uc.totpRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if factor == nil || !factor.IsConfirmed() {
+		return ErrMFANotEnrolled
+	}
+
+	if err := uc.verifyTOTP(ctx, factor, code); err != ErrInvalidMFACode {
+		return err
+	}
+
+	consumed, err := uc.recoveryCodeRepo.Consume(ctx, user.ID, crypto.HashToken(crypto.NormalizeRecoveryCode(code), uc.secret))
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after verifying a current code
+func (uc *MFAUseCase) RegenerateRecoveryCodes(ctx context.Context, user *domain.User, code string) ([]string, error) {
+	if err := uc.VerifyCode(ctx, user, code); err != nil {
+		return nil, err
+	}
+	return uc.issueRecoveryCodes(ctx, user.ID)
+}
+
+// Disable removes the user's second factor after verifying a current code.
+// Users whose role or organization requires MFA cannot disable it.
+func (uc *MFAUseCase) Disable(ctx context.Context, user *domain.User, code string) error {
+	required, err := uc.IsRequired(ctx, user)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrMFARequiredByPolicy
+	}
+	if err := uc.VerifyCode(ctx, user, code); err != nil {
+		return err
+	}
+
+	if err := uc.recoveryCodeRepo.DeleteByUserID(ctx, user.ID); err != nil {
+		return err
+	}
+	return uc.totpRepo.DeleteByUserID(ctx, user.ID)
+}
+
+// verifyTOTP validates a code against the factor and rejects reuse of the same time step
+func (uc *MFAUseCase) verifyTOTP(ctx context.Context, factor *domain.TOTPFactor, code string) error {
+	secret, err := crypto.DecryptSecret(factor.Secret, uc.encryptionKey)
+	if err != nil {
+		return err
+	}
+
+	step, ok := crypto.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return ErrInvalidMFACode
+	}
+
+	fresh, err := uc.totpRepo.UseStep(ctx, factor.ID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+// issueRecoveryCodes generates and stores a new set of recovery codes, returning them in plain text
+func (uc *MFAUseCase) issueRecoveryCodes(ctx context.Context, userID int64) ([]string, error) {
+	plain := make([]string, recoveryCodeCount)
+	codes := make([]*domain.RecoveryCode, recoveryCodeCount)
+	for i := range plain {
+		code, err := crypto.GenerateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		plain[i] = code
+		codes[i] = &domain.RecoveryCode{CodeHash: crypto.HashToken(crypto.NormalizeRecoveryCode(code), uc.secret)}
+	}
+
+	if err := uc.recoveryCodeRepo.Replace(ctx, userID, codes); err != nil {
+		return nil, err
+	}
+	return plain, nil
+}
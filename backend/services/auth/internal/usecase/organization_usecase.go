@@ -0,0 +1,262 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/logger"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrOrganizationNotFound  = errors.New("organization not found")
+	ErrOrganizationForbidden = errors.New("you do not have permission to manage this organization")
+	ErrOrganizationNotEmpty  = errors.New("organization still has users or groups")
+	ErrInvalidSlug           = errors.New("slug must contain only lowercase letters, digits and hyphens")
+	ErrSlugTaken             = errors.New("slug is already in use")
+	ErrInvalidOrgRole        = errors.New("role must be org_admin, manager or user")
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// orgRoles are the roles that can be granted within an organization
+var orgRoles = map[domain.UserRole]bool{
+	domain.RoleOrgAdmin: true,
+	domain.RoleManager:  true,
+	domain.RoleUser:     true,
+}
+
+type OrganizationUseCase struct {
+	orgRepo   domain.OrganizationRepository
+	userRepo  domain.UserRepository
+	groupRepo domain.GroupRepository
+	auditRepo domain.AuditLogRepository
+}
+
+func NewOrganizationUseCase(
+	orgRepo domain.OrganizationRepository,
+	userRepo domain.UserRepository,
+	groupRepo domain.GroupRepository,
+	auditRepo domain.AuditLogRepository,
+) *OrganizationUseCase {
+	return &OrganizationUseCase{
+		orgRepo:   orgRepo,
+		userRepo:  userRepo,
+		groupRepo: groupRepo,
+		auditRepo: auditRepo,
+	}
+}
+
+// CreateOrganization creates a tenant (super admin only). The slug is derived
+// from the name when empty.
+func (uc *OrganizationUseCase) CreateOrganization(ctx context.Context, actor *domain.User, name, slug string, requireMFA bool, ipAddress string) (*domain.Organization, error) {
+	if !actor.IsSuperAdmin() {
+		return nil, ErrOrganizationForbidden
+	}
+
+	if slug == "" {
+		slug = slugify(name)
+	}
+	if !slugPattern.MatchString(slug) {
+		return nil, ErrInvalidSlug
+	}
+
+	existing, err := uc.orgRepo.GetBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrSlugTaken
+	}
+
+	org := &domain.Organization{
+		Name:       name,
+		Slug:       slug,
+		RequireMFA: requireMFA,
+	}
+	if err := uc.orgRepo.Create(ctx, org); err != nil {
+		return nil, err
+	}
+
+	uc.audit(ctx, actor, "organization_created", org.ID, ipAddress)
+	return org, nil
+}
+
+// ListOrganizations returns every organization to super admins and the
+// user's own organization to everyone else
+func (uc *OrganizationUseCase) ListOrganizations(ctx context.Context, user *domain.User) ([]*domain.Organization, error) {
+	if user.IsSuperAdmin() {
+		return uc.orgRepo.GetAll(ctx)
+	}
+
+	orgs := []*domain.Organization{}
+	if user.OrganizationID == nil {
+		return orgs, nil
+	}
+	org, err := uc.orgRepo.GetByID(ctx, *user.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	if org != nil {
+		orgs = append(orgs, org)
+	}
+	return orgs, nil
+}
+
+// GetOrganization returns an organization the user belongs to
+func (uc *OrganizationUseCase) GetOrganization(ctx context.Context, user *domain.User, id int64) (*domain.Organization, error) {
+	if !user.BelongsTo(&id) {
+		return nil, ErrOrganizationNotFound
+	}
+
+	org, err := uc.orgRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, ErrOrganizationNotFound
+	}
+	return org, nil
+}
+
+// UpdateOrganization changes an organization's name or MFA policy (org admins only)
+func (uc *OrganizationUseCase) UpdateOrganization(ctx context.Context, actor *domain.User, id int64, name *string, requireMFA *bool, ipAddress string) (*domain.Organization, error) {
+	org, err := uc.GetOrganization(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+	if !actor.IsOrgAdmin(id) {
+		return nil, ErrOrganizationForbidden
+	}
+
+	updated := false
+	if name != nil && *name != "" {
+		org.Name = *name
+		updated = true
+	}
+	if requireMFA != nil {
+		org.RequireMFA = *requireMFA
+		updated = true
+	}
+	if !updated {
+		return nil, errors.New("no valid fields provided for update")
+	}
+
+	if err := uc.orgRepo.Update(ctx, org); err != nil {
+		return nil, err
+	}
+
+	uc.audit(ctx, actor, "organization_updated", org.ID, ipAddress)
+	return org, nil
+}
+
+// DeleteOrganization removes an empty organization (super admin only)
+func (uc *OrganizationUseCase) DeleteOrganization(ctx context.Context, actor *domain.User, id int64, ipAddress string) error {
+	if !actor.IsSuperAdmin() {
+		return ErrOrganizationForbidden
+	}
+	if _, err := uc.GetOrganization(ctx, actor, id); err != nil {
+		return err
+	}
+
+	users, err := uc.userRepo.GetByOrganizationID(ctx, id)
+	if err != nil {
+		return err
+	}
+	groups, err := uc.groupRepo.GetByOrganizationID(ctx, &id)
+	if err != nil {
+		return err
+	}
+	if len(users) > 0 || len(groups) > 0 {
+		return ErrOrganizationNotEmpty
+	}
+
+	if err := uc.orgRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	uc.audit(ctx, actor, "organization_deleted", id, ipAddress)
+	return nil
+}
+
+// ListUsers returns the users in an organization (org admins only)
+func (uc *OrganizationUseCase) ListUsers(ctx context.Context, actor *domain.User, id int64) ([]*domain.User, error) {
+	if _, err := uc.GetOrganization(ctx, actor, id); err != nil {
+		return nil, err
+	}
+	if !actor.IsOrgAdmin(id) {
+		return nil, ErrOrganizationForbidden
+	}
+	return uc.userRepo.GetByOrganizationID(ctx, id)
+}
+
+// AddUser moves a user into an organization with an organization-level role
+// (super admin only)
+func (uc *OrganizationUseCase) AddUser(ctx context.Context, actor *domain.User, id, userID int64, role domain.UserRole, ipAddress string) (*domain.User, error) {
+	if !actor.IsSuperAdmin() {
+		return nil, ErrOrganizationForbidden
+	}
+	if role == "" {
+		role = domain.RoleUser
+	}
+	if !orgRoles[role] {
+		return nil, ErrInvalidOrgRole
+	}
+	if _, err := uc.GetOrganization(ctx, actor, id); err != nil {
+		return nil, err
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	user.OrganizationID = &id
+	user.Role = role
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	uc.audit(ctx, actor, "organization_user_added", id, ipAddress)
+	return user, nil
+}
+
+// audit records an organization event. Failures are logged but do not fail the request.
+func (uc *OrganizationUseCase) audit(ctx context.Context, actor *domain.User, action string, orgID int64, ipAddress string) {
+	resourceType := "organization"
+	entry := &domain.AuditLog{
+		UserID:       &actor.ID,
+		UserName:     actor.Name,
+		Action:       action,
+		ResourceType: &resourceType,
+		ResourceID:   &orgID,
+		IPAddress:    ipAddress,
+	}
+
+	if err := uc.auditRepo.Create(ctx, entry); err != nil {
+		logger.Log.Error("Failed to write audit log", zap.String("action", action), zap.Error(err))
+	}
+}
+
+// slugify derives a URL-safe slug from a display name
+func slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+			dash = false
+		case b.Len() > 0 && !dash:
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
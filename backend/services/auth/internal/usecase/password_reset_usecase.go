@@ -0,0 +1,172 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/crypto"
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/logger"
+	"github.com/raufhm/fairflow/shared/mailer"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+	ErrWeakPassword      = errors.New("password must be at least 8 characters")
+)
+
+// resetTokenPrefix identifies password reset tokens issued by FairFlow
+const resetTokenPrefix = "rr_reset_"
+
+// minPasswordLength is the shortest password accepted on reset
+const minPasswordLength = 8
+
+type PasswordResetUseCase struct {
+	userRepo       domain.UserRepository
+	resetTokenRepo domain.PasswordResetTokenRepository
+	sessionRepo    domain.SessionRepository
+	auditRepo      domain.AuditLogRepository
+	mailer         mailer.Mailer
+	tokenSecret    string
+	appBaseURL     string
+	tokenTTL       time.Duration
+}
+
+func NewPasswordResetUseCase(
+	userRepo domain.UserRepository,
+	resetTokenRepo domain.PasswordResetTokenRepository,
+	sessionRepo domain.SessionRepository,
+	auditRepo domain.AuditLogRepository,
+	mailer mailer.Mailer,
+	tokenSecret string,
+	appBaseURL string,
+	tokenTTL time.Duration,
+) *PasswordResetUseCase {
+	return &PasswordResetUseCase{
+		userRepo:       userRepo,
+		resetTokenRepo: resetTokenRepo,
+		sessionRepo:    sessionRepo,
+		auditRepo:      auditRepo,
+		mailer:         mailer,
+		tokenSecret:    tokenSecret,
+		appBaseURL:     strings.TrimSuffix(appBaseURL, "/"),
+		tokenTTL:       tokenTTL,
+	}
+}
+
+// RequestReset emails a reset link if the address belongs to a user.
+// It returns nil for unknown addresses so callers cannot enumerate accounts.
+func (uc *PasswordResetUseCase) RequestReset(ctx context.Context, email, ipAddress string) error {
+	user, err := uc.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return nil
+	}
+
+	// Only the most recent link stays valid
+	if err := uc.resetTokenRepo.InvalidateByUserID(ctx, user.ID); err != nil {
+		return err
+	}
+
+	rawToken, err := crypto.GenerateOpaqueToken(resetTokenPrefix)
+	if err != nil {
+		return err
+	}
+
+	resetToken := &domain.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: crypto.HashToken(rawToken, uc.tokenSecret),
+		ExpiresAt: time.Now().Add(uc.tokenTTL),
+	}
+	if err := uc.resetTokenRepo.Create(ctx, resetToken); err != nil {
+		return err
+	}
+
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your FairFlow password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nWe received a request to reset your FairFlow password. Use the link below to choose a new one:\n\n%s/reset-password?token=%s\n\nThe link expires in %s and can only be used once. If you did not request a reset, you can ignore this email.\n",
+			user.Name, uc.appBaseURL, rawToken, uc.tokenTTL,
+		),
+	}
+	if err := uc.mailer.Send(ctx, msg); err != nil {
+		return err
+	}
+
+	uc.audit(ctx, user, "password_reset_requested", ipAddress)
+	return nil
+}
+
+// ResetPassword sets a new password using a reset token and signs the user
+// out of every existing session
+func (uc *PasswordResetUseCase) ResetPassword(ctx context.Context, rawToken, newPassword, ipAddress string) error {
+	if len(newPassword) < minPasswordLength {
+		return ErrWeakPassword
+	}
+
+	resetToken, err := uc.resetTokenRepo.GetByHash(ctx, crypto.HashToken(rawToken, uc.tokenSecret))
+	if err != nil {
+		return err
+	}
+	if resetToken == nil || resetToken.UsedAt != nil || time.Now().After(resetToken.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+
+	consumed, err := uc.resetTokenRepo.MarkUsed(ctx, resetToken.ID)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidResetToken
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, resetToken.UserID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrInvalidResetToken
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	user.PasswordHash = string(hashedPassword)
+
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	if err := uc.sessionRepo.RevokeAllByUserID(ctx, user.ID); err != nil {
+		return err
+	}
+
+	uc.audit(ctx, user, "password_reset", ipAddress)
+	return nil
+}
+
+// audit records a password reset event. Failures are logged but do not fail the request.
+func (uc *PasswordResetUseCase) audit(ctx context.Context, user *domain.User, action, ipAddress string) {
+	resourceType := "user"
+	entry := &domain.AuditLog{
+		UserID:       &user.ID,
+		UserName:     user.Name,
+		Action:       action,
+		ResourceType: &resourceType,
+		ResourceID:   &user.ID,
+		IPAddress:    ipAddress,
+	}
+
+	if err := uc.auditRepo.Create(ctx, entry); err != nil {
+		logger.Log.Error("Failed to write audit log", zap.String("action", action), zap.Error(err))
+	}
+}
@@ -0,0 +1,439 @@
+package usecase
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/crypto"
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/oidc"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrSSOStateMismatch   = errors.New("invalid or expired single sign-on request")
+	ErrSSOEmailUnverified = errors.New("identity provider did not return a verified email address")
+	ErrSSONotInvited      = errors.New("no invitation found for this email address")
+	ErrSSOAccountExists   = errors.New("an account with this email already exists; sign in and link single sign-on from your account")
+	ErrSSOIdentityInUse   = errors.New("this identity is already linked to another account")
+)
+
+// ssoStateTTL bounds how long a user may take at the identity provider
+const ssoStateTTL = 10 * time.Minute
+
+// ssoLinkTTL bounds how long a link ticket can be redeemed
+const ssoLinkTTL = 5 * time.Minute
+
+// maxMappedRole is the most privileged role an IdP group can grant. Platform
+// roles are only ever given out locally.
+const maxMappedRole = domain.RoleOrgAdmin
+
+// rolePriority ranks roles so the most privileged mapped group wins
+var rolePriority = map[domain.UserRole]int{
+	domain.RoleUser:       0,
+	domain.RoleManager:    1,
+	domain.RoleOrgAdmin:   2,
+	domain.RoleAdmin:      3,
+	domain.RoleSuperAdmin: 4,
+}
+
+// ssoState is kept in an encrypted cookie between the redirect to the
+// identity provider and the callback
+type ssoState struct {
+	State     string `json:"state"`
+	Nonce     string `json:"nonce"`
+	Verifier  string `json:"verifier"`
+	ExpiresAt int64  `json:"exp"`
+	// LinkUserID is set when a signed-in user links their identity to their account
+	LinkUserID int64 `json:"link_user_id,omitempty"`
+}
+
+// ssoLinkTicket lets a signed-in user start a login that links the identity
+// to their account. It is sealed so only this service can mint it.
+type ssoLinkTicket struct {
+	UserID    int64 `json:"uid"`
+	ExpiresAt int64 `json:"exp"`
+}
+
+type SSOUseCase struct {
+	authUseCase    *AuthUseCase
+	userRepo       domain.UserRepository
+	identityRepo   domain.UserIdentityRepository
+	invitationRepo domain.InvitationRepository
+	transactor     domain.Transactor
+	provider       *oidc.Provider
+	roleMapping    map[string]domain.UserRole
+	organizationID *int64 // Organization new users join without an invitation; nil requires one
+	encryptionKey  string // Seals login state and pending account links
+	appBaseURL     string
+}
+
+func NewSSOUseCase(
+	authUseCase *AuthUseCase,
+	userRepo domain.UserRepository,
+	identityRepo domain.UserIdentityRepository,
+	invitationRepo domain.InvitationRepository,
+	transactor domain.Transactor,
+	provider *oidc.Provider,
+	roleMapping map[string]domain.UserRole,
+	organizationID *int64,
+	encryptionKey string,
+	appBaseURL string,
+) *SSOUseCase {
+	return &SSOUseCase{
+		authUseCase:    authUseCase,
+		userRepo:       userRepo,
+		identityRepo:   identityRepo,
+		invitationRepo: invitationRepo,
+		transactor:     transactor,
+		provider:       provider,
+		roleMapping:    roleMapping,
+		organizationID: organizationID,
+		encryptionKey:  encryptionKey,
+		appBaseURL:     strings.TrimSuffix(appBaseURL, "/"),
+	}
+}
+
+// ParseRoleMapping converts IdP group to role settings into user roles. Roles
+// above org_admin cannot be mapped.
+func ParseRoleMapping(mapping map[string]string) (map[string]domain.UserRole, error) {
+	roles := make(map[string]domain.UserRole, len(mapping))
+	for group, role := range mapping {
+		userRole := domain.UserRole(role)
+		priority, ok := rolePriority[userRole]
+		if !ok {
+			return nil, fmt.Errorf("unknown role %q for group %q", role, group)
+		}
+		if priority > rolePriority[maxMappedRole] {
+			return nil, fmt.Errorf("role %q for group %q cannot be granted by the identity provider", role, group)
+		}
+		roles[group] = userRole
+	}
+	return roles, nil
+}
+
+// BeginLink issues a ticket that starts a login linking the provider identity
+// to the signed-in user's account
+func (uc *SSOUseCase) BeginLink(ctx context.Context, user *domain.User) (string, error) {
+	payload, err := json.Marshal(ssoLinkTicket{UserID: user.ID, ExpiresAt: time.Now().Add(ssoLinkTTL).Unix()})
+	if err != nil {
+		return "", err
+	}
+	return crypto.EncryptSecret(string(payload), uc.encryptionKey)
+}
+
+// BeginLogin starts an authorization code flow with PKCE. It returns the
+// provider URL to redirect to and the sealed state to keep in a cookie. A
+// link ticket from BeginLink makes the login link the identity instead.
+func (uc *SSOUseCase) BeginLogin(ctx context.Context, linkTicket string) (string, string, error) {
+	state := ssoState{ExpiresAt: time.Now().Add(ssoStateTTL).Unix()}
+	if linkTicket != "" {
+		userID, err := uc.openLinkTicket(linkTicket)
+		if err != nil {
+			return "", "", err
+		}
+		state.LinkUserID = userID
+	}
+	for _, value := range []*string{&state.State, &state.Nonce, &state.Verifier} {
+		random, err := oidc.RandomString()
+		if err != nil {
+			return "", "", err
+		}
+		*value = random
+	}
+
+	authURL, err := uc.provider.AuthCodeURL(ctx, state.State, state.Nonce, oidc.CodeChallenge(state.Verifier))
+	if err != nil {
+		return "", "", err
+	}
+
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return "", "", err
+	}
+	sealed, err := crypto.EncryptSecret(string(payload), uc.encryptionKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	return authURL, sealed, nil
+}
+
+// CompleteLogin handles the provider callback: it checks the state, redeems the
+// code, verifies the ID token, provisions the user and signs them in
+func (uc *SSOUseCase) CompleteLogin(ctx context.Context, sealedState, state, code string, client ClientInfo) (*domain.User, *AuthTokens, *MFAChallenge, error) {
+	payload, err := crypto.DecryptSecret(sealedState, uc.encryptionKey)
+	if err != nil {
+		return nil, nil, nil, ErrSSOStateMismatch
+	}
+
+	var expected ssoState
+	if err := json.Unmarshal([]byte(payload), &expected); err != nil {
+		return nil, nil, nil, ErrSSOStateMismatch
+	}
+	if time.Now().Unix() > expected.ExpiresAt || subtle.ConstantTimeCompare([]byte(expected.State), []byte(state)) != 1 {
+		return nil, nil, nil, ErrSSOStateMismatch
+	}
+
+	rawIDToken, err := uc.provider.Exchange(ctx, code, expected.Verifier)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	claims, err := uc.provider.VerifyIDToken(ctx, rawIDToken, expected.Nonce)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var user *domain.User
+	if expected.LinkUserID != 0 {
+		user, err = uc.link(ctx, claims, expected.LinkUserID)
+	} else {
+		user, err = uc.provision(ctx, claims)
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return uc.authUseCase.completeLogin(ctx, user, client)
+}
+
+// openLinkTicket returns the user a link ticket was issued to
+func (uc *SSOUseCase) openLinkTicket(sealed string) (int64, error) {
+	payload, err := crypto.DecryptSecret(sealed, uc.encryptionKey)
+	if err != nil {
+		return 0, ErrSSOStateMismatch
+	}
+
+	var ticket ssoLinkTicket
+	if err := json.Unmarshal([]byte(payload), &ticket); err != nil || ticket.UserID == 0 {
+		return 0, ErrSSOStateMismatch
+	}
+	if time.Now().Unix() > ticket.ExpiresAt {
+		return 0, ErrSSOStateMismatch
+	}
+	return ticket.UserID, nil
+}
+
+// CallbackRedirectURL is where the browser is sent after the callback. Results
+// travel in the fragment so they never reach server logs.
+func (uc *SSOUseCase) CallbackRedirectURL(result url.Values) string {
+	return uc.appBaseURL + "/auth/sso/callback#" + result.Encode()
+}
+
+// provision finds or creates the user for an external identity (just-in-time
+// provisioning) and keeps their role in sync with the IdP group mapping.
+// Existing accounts are never taken over by email: their owner has to sign in
+// and link the identity.
+func (uc *SSOUseCase) provision(ctx context.Context, claims *oidc.IDTokenClaims) (*domain.User, error) {
+	issuer := uc.provider.Issuer()
+	role, mapped := uc.mapRole(claims.Groups)
+
+	identity, err := uc.identityRepo.GetBySubject(ctx, issuer, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	if identity != nil {
+		user, err := uc.userRepo.GetByID(ctx, identity.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, ErrUserNotFound
+		}
+		if err := uc.syncRole(ctx, user, role, mapped); err != nil {
+			return nil, err
+		}
+		if err := uc.identityRepo.UpdateLastLogin(ctx, identity.ID, claims.Email); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+
+	// Accounts are only matched or created by email the IdP vouches for
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, ErrSSOEmailUnverified
+	}
+
+	existing, err := uc.userRepo.GetByEmail(ctx, claims.Email)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrSSOAccountExists
+	}
+
+	user, err := uc.createUser(ctx, claims, role, mapped)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.identityRepo.Create(ctx, &domain.UserIdentity{
+		UserID:  user.ID,
+		Issuer:  issuer,
+		Subject: claims.Subject,
+		Email:   claims.Email,
+	}); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// link attaches the provider identity to the account of the user who started
+// the login with a link ticket
+func (uc *SSOUseCase) link(ctx context.Context, claims *oidc.IDTokenClaims, userID int64) (*domain.User, error) {
+	issuer := uc.provider.Issuer()
+
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	identity, err := uc.identityRepo.GetBySubject(ctx, issuer, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if identity != nil && identity.UserID != user.ID {
+		return nil, ErrSSOIdentityInUse
+	}
+
+	if identity == nil {
+		if err := uc.identityRepo.Create(ctx, &domain.UserIdentity{
+			UserID:  user.ID,
+			Issuer:  issuer,
+			Subject: claims.Subject,
+			Email:   claims.Email,
+		}); err != nil {
+			return nil, err
+		}
+	} else if err := uc.identityRepo.UpdateLastLogin(ctx, identity.ID, claims.Email); err != nil {
+		return nil, err
+	}
+
+	role, mapped := uc.mapRole(claims.Groups)
+	if err := uc.syncRole(ctx, user, role, mapped); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// createUser provisions an SSO-only account. Its password is random and unknown,
+// so it can only sign in through the provider until a reset is requested.
+//
+// New users need a pending invitation, which decides their organization, or
+// join the organization configured for the provider. Without either they are
+// turned away rather than landing outside every organization.
+func (uc *SSOUseCase) createUser(ctx context.Context, claims *oidc.IDTokenClaims, role domain.UserRole, mapped bool) (*domain.User, error) {
+	organizationID := uc.organizationID
+	invitation, err := uc.invitationRepo.GetPendingByEmail(ctx, claims.Email)
+	if err != nil {
+		return nil, err
+	}
+	if invitation != nil {
+		organizationID = invitation.OrganizationID
+		// The IdP decides roles when a mapping is configured
+		if !mapped {
+			role = invitation.Role
+		}
+	} else if organizationID == nil {
+		return nil, ErrSSONotInvited
+	}
+
+	randomPassword, err := oidc.RandomString()
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(randomPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = claims.Email
+	}
+
+	// Only emails the IdP has verified are provisioned
+	now := time.Now()
+	user := &domain.User{
+		Email:           claims.Email,
+		PasswordHash:    string(hashedPassword),
+		Name:            name,
+		Role:            role,
+		OrganizationID:  organizationID,
+		EmailVerifiedAt: &now,
+	}
+	if mapped {
+		user.Role = mappedRoleFor(user, role)
+	}
+
+	// The invitation is only used up if the account is created with it
+	err = uc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if invitation != nil {
+			accepted, err := uc.invitationRepo.MarkAccepted(ctx, invitation.ID)
+			if err != nil {
+				return err
+			}
+			if !accepted {
+				return ErrSSONotInvited
+			}
+		}
+		return uc.userRepo.Create(ctx, user)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// mapRole returns the most privileged role mapped from the user's IdP groups.
+// mapped is false when no mapping is configured, leaving roles managed locally.
+func (uc *SSOUseCase) mapRole(groups []string) (domain.UserRole, bool) {
+	role := domain.RoleUser
+	if len(uc.roleMapping) == 0 {
+		return role, false
+	}
+
+	for _, group := range groups {
+		if mappedRole, ok := uc.roleMapping[group]; ok && rolePriority[mappedRole] > rolePriority[role] {
+			role = mappedRole
+		}
+	}
+	return role, true
+}
+
+// mappedRoleFor adjusts a mapped role to the user: org admin needs an organization
+func mappedRoleFor(user *domain.User, role domain.UserRole) domain.UserRole {
+	if role == domain.RoleOrgAdmin && user.OrganizationID == nil {
+		return domain.RoleManager
+	}
+	return role
+}
+
+// syncRole applies the mapped role when the IdP is the source of truth for
+// roles. Users above the mappable roles are managed locally and left alone.
+func (uc *SSOUseCase) syncRole(ctx context.Context, user *domain.User, role domain.UserRole, mapped bool) error {
+	if !mapped || rolePriority[user.Role] > rolePriority[maxMappedRole] {
+		return nil
+	}
+	role = mappedRoleFor(user, role)
+	if user.Role == role {
+		return nil
+	}
+	if err := uc.userRepo.UpdateRole(ctx, user.ID, role); err != nil {
+		return err
+	}
+	user.Role = role
+	return nil
+}
@@ -0,0 +1,31 @@
+package usecase_test
+
+import (
+	"testing"
+
+	"github.com/raufhm/fairflow/services/auth/internal/usecase"
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRoleMapping(t *testing.T) {
+	roles, err := usecase.ParseRoleMapping(map[string]string{
+		"engineering": "user",
+		"leads":       "manager",
+		"it-admins":   "org_admin",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]domain.UserRole{
+		"engineering": domain.RoleUser,
+		"leads":       domain.RoleManager,
+		"it-admins":   domain.RoleOrgAdmin,
+	}, roles)
+}
+
+func TestParseRoleMapping_RejectsPlatformRoles(t *testing.T) {
+	for _, role := range []string{"admin", "super_admin", "owner"} {
+		_, err := usecase.ParseRoleMapping(map[string]string{"platform": role})
+		assert.Error(t, err, role)
+	}
+}
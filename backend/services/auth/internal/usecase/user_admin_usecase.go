@@ -0,0 +1,199 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/logger"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrUserAdminForbidden     = errors.New("you do not have permission to manage this user")
+	ErrCannotModifySelf       = errors.New("you cannot change your own account")
+	ErrInvalidRole            = errors.New("invalid role")
+	ErrUserAlreadyDeactivated = errors.New("user is already deactivated")
+	ErrUserNotDeactivated     = errors.New("user is not deactivated")
+)
+
+// UserAdminUseCase lets admins search and manage user accounts. Admins manage
+// the users of their own organization; super admins manage everyone.
+type UserAdminUseCase struct {
+	userRepo    domain.UserRepository
+	apiKeyRepo  domain.APIKeyRepository
+	sessionRepo domain.SessionRepository
+	auditRepo   domain.AuditLogRepository
+}
+
+func NewUserAdminUseCase(
+	userRepo domain.UserRepository,
+	apiKeyRepo domain.APIKeyRepository,
+	sessionRepo domain.SessionRepository,
+	auditRepo domain.AuditLogRepository,
+) *UserAdminUseCase {
+	return &UserAdminUseCase{
+		userRepo:    userRepo,
+		apiKeyRepo:  apiKeyRepo,
+		sessionRepo: sessionRepo,
+		auditRepo:   auditRepo,
+	}
+}
+
+// SearchUsers returns a page of users matching the filter. Admins only see
+// their own organization, whatever the filter asks for.
+func (uc *UserAdminUseCase) SearchUsers(ctx context.Context, actor *domain.User, filter domain.UserFilter) ([]*domain.User, int, error) {
+	if !actor.IsSuperAdmin() {
+		filter.OrganizationID = actor.OrganizationID
+		filter.AllOrganizations = false
+	}
+	return uc.userRepo.Search(ctx, filter)
+}
+
+// GetUser returns a user the actor may manage
+func (uc *UserAdminUseCase) GetUser(ctx context.Context, actor *domain.User, id int64) (*domain.User, error) {
+	user, err := uc.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !actor.BelongsTo(user.OrganizationID) {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// ChangeRole sets a user's role. Only super admins can grant or revoke super admin.
+func (uc *UserAdminUseCase) ChangeRole(ctx context.Context, actor *domain.User, id int64, role domain.UserRole, ipAddress string) (*domain.User, error) {
+	if _, ok := rolePriority[role]; !ok {
+		return nil, ErrInvalidRole
+	}
+
+	user, err := uc.manageableUser(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+	if role == domain.RoleSuperAdmin && !actor.IsSuperAdmin() {
+		return nil, ErrUserAdminForbidden
+	}
+	if role == domain.RoleOrgAdmin && user.OrganizationID == nil {
+		return nil, ErrInvalidOrgRole
+	}
+	if user.Role == role {
+		return user, nil
+	}
+
+	if err := uc.userRepo.UpdateRole(ctx, user.ID, role); err != nil {
+		return nil, err
+	}
+
+	uc.audit(ctx, actor, "user_role_changed", user.ID, fmt.Sprintf("%s -> %s", user.Role, role), ipAddress)
+	user.Role = role
+	return user, nil
+}
+
+// DeactivateUser blocks a user from signing in. Their API keys are revoked and
+// their sessions ended so existing credentials stop working immediately.
+func (uc *UserAdminUseCase) DeactivateUser(ctx context.Context, actor *domain.User, id int64, ipAddress string) error {
+	user, err := uc.manageableUser(ctx, actor, id)
+	if err != nil {
+		return err
+	}
+	if !user.IsActive() {
+		return ErrUserAlreadyDeactivated
+	}
+
+	if err := uc.userRepo.Deactivate(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := uc.apiKeyRepo.DeactivateByUserID(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := uc.sessionRepo.RevokeAllByUserID(ctx, user.ID); err != nil {
+		return err
+	}
+
+	uc.audit(ctx, actor, "user_deactivated", user.ID, "", ipAddress)
+	return nil
+}
+
+// ReactivateUser lets a deactivated user sign in again. API keys revoked on
+// deactivation stay revoked.
+func (uc *UserAdminUseCase) ReactivateUser(ctx context.Context, actor *domain.User, id int64, ipAddress string) error {
+	user, err := uc.manageableUser(ctx, actor, id)
+	if err != nil {
+		return err
+	}
+	if user.IsActive() {
+		return ErrUserNotDeactivated
+	}
+
+	if err := uc.userRepo.Reactivate(ctx, user.ID); err != nil {
+		return err
+	}
+
+	uc.audit(ctx, actor, "user_reactivated", user.ID, "", ipAddress)
+	return nil
+}
+
+// DeleteUser permanently removes a user (super admin only)
+func (uc *UserAdminUseCase) DeleteUser(ctx context.Context, actor *domain.User, id int64, ipAddress string) error {
+	if !actor.IsSuperAdmin() {
+		return ErrUserAdminForbidden
+	}
+
+	user, err := uc.manageableUser(ctx, actor, id)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.apiKeyRepo.DeactivateByUserID(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := uc.sessionRepo.RevokeAllByUserID(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := uc.userRepo.Delete(ctx, user.ID); err != nil {
+		return err
+	}
+
+	uc.audit(ctx, actor, "user_deleted", user.ID, user.Email, ipAddress)
+	return nil
+}
+
+// manageableUser loads a user the actor may change. Admins cannot change
+// themselves or super admins.
+func (uc *UserAdminUseCase) manageableUser(ctx context.Context, actor *domain.User, id int64) (*domain.User, error) {
+	if actor.ID == id {
+		return nil, ErrCannotModifySelf
+	}
+
+	user, err := uc.GetUser(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsSuperAdmin() && !actor.IsSuperAdmin() {
+		return nil, ErrUserAdminForbidden
+	}
+	return user, nil
+}
+
+// audit records a user management event. Failures are logged but do not fail the request.
+func (uc *UserAdminUseCase) audit(ctx context.Context, actor *domain.User, action string, userID int64, details, ipAddress string) {
+	resourceType := "user"
+	entry := &domain.AuditLog{
+		UserID:       &actor.ID,
+		UserName:     actor.Name,
+		Action:       action,
+		ResourceType: &resourceType,
+		ResourceID:   &userID,
+		IPAddress:    ipAddress,
+	}
+	if details != "" {
+		entry.Details = &details
+	}
+
+	if err := uc.auditRepo.Create(ctx, entry); err != nil {
+		logger.Log.Error("Failed to write audit log", zap.String("action", action), zap.Error(err))
+	}
+}
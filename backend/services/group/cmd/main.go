@@ -13,11 +13,15 @@ import (
 	"github.com/raufhm/fairflow/services/group/internal/handler"
 	"github.com/raufhm/fairflow/services/group/internal/usecase"
 	"github.com/raufhm/fairflow/shared/config"
+	"github.com/raufhm/fairflow/shared/crypto"
 	"github.com/raufhm/fairflow/shared/database"
+	"github.com/raufhm/fairflow/shared/domain"
 	"github.com/raufhm/fairflow/shared/health"
+	"github.com/raufhm/fairflow/shared/httpclient"
 	"github.com/raufhm/fairflow/shared/logger"
 	"github.com/raufhm/fairflow/shared/middleware"
 	"github.com/raufhm/fairflow/shared/repository/postgres"
+	"github.com/raufhm/fairflow/shared/strategy"
 	"go.uber.org/zap"
 )
 
@@ -45,13 +49,31 @@ func main() {
 	// Initialize repositories
 	groupRepo := postgres.NewGroupRepository(db)
 	memberRepo := postgres.NewMemberRepository(db)
+	userRepo := postgres.NewUserRepository(db)
+	apiKeyRepo := postgres.NewAPIKeyRepository(db)
+	sessionRepo := postgres.NewSessionRepository(db)
+	auditRepo := postgres.NewAuditLogRepository(db)
+	grantRepo := postgres.NewGroupGrantRepository(db)
 
 	// Initialize use case
-	groupUseCase := usecase.NewGroupUseCase(groupRepo, memberRepo)
+	groupUseCase := usecase.NewGroupUseCase(groupRepo, memberRepo, grantRepo, userRepo, strategy.DefaultRegistry())
 
 	// Initialize handler
 	groupHandler := handler.NewGroupHandler(groupUseCase)
 
+	// Calls to other services are signed with this service's identity
+	serviceTokens, err := crypto.LoadServiceTokenService(crypto.GroupService, cfg.ServicePrivateKey, cfg.ServicePublicKeys, cfg.ServiceDevSecret, cfg.ServiceTokenTTL)
+	if err != nil {
+		logger.Log.Fatal("Failed to load service keys", zap.Error(err))
+	}
+	authClient := httpclient.NewServiceClient(cfg.AuthServiceURL, crypto.AuthService).WithServiceAuth(serviceTokens)
+
+	// Initialize authentication middleware, verifying tokens against the auth service's JWKS
+	jwksCache := httpclient.NewJWKSCache(authClient, cfg.JWKSCacheTTL)
+	tokenService := crypto.NewVerifyingTokenService(jwksCache)
+	authenticator := middleware.NewAuthenticator(userRepo, apiKeyRepo, sessionRepo, auditRepo, tokenService, cfg.JWTSecret)
+	groupAuthorizer := middleware.NewGroupAuthorizer(groupRepo, grantRepo)
+
 	// Setup HTTP router
 	mux := http.NewServeMux()
 
@@ -59,8 +81,28 @@ func main() {
 	healthChecker := health.NewHealthChecker(db)
 	mux.HandleFunc("/health", healthChecker.Handler("group-service", "1.0.0"))
 
+	// API key restrictions
+	groupPolicy := middleware.APIKeyPolicy{
+		ReadScope:  domain.ScopeGroupsRead,
+		WriteScope: domain.ScopeGroupsWrite,
+		GroupID:    middleware.GroupIDFromPath("/api/v1/groups/"),
+	}
+
+	// Group roles needed on each route
+	groupAccess := middleware.GroupAccessPolicy{
+		Read:    domain.GroupRoleViewer,
+		Write:   domain.GroupRoleManager,
+		Delete:  domain.GroupRoleOwner,
+		GroupID: groupPolicy.GroupID,
+	}
+	roleAccess := middleware.GroupAccessPolicy{
+		Read:    domain.GroupRoleManager,
+		Write:   domain.GroupRoleOwner,
+		GroupID: groupPolicy.GroupID,
+	}
+
 	// Group endpoints
-	mux.HandleFunc("/api/v1/groups", func(w http.ResponseWriter, r *http.Request) {
+	mux.Handle("/api/v1/groups", authenticator.OptionalAuth(middleware.RequireVerifiedEmail(middleware.EnforceAPIKeyPolicy(groupPolicy, groupAuthorizer.Enforce(groupAccess, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodGet {
 			groupHandler.GetAllGroups(w, r)
 		} else if r.Method == http.MethodPost {
@@ -68,13 +110,17 @@ func main() {
 		} else {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
-	})
-	mux.HandleFunc("/api/v1/groups/", func(w http.ResponseWriter, r *http.Request) {
+	}))))))
+	groupRoutes := groupAuthorizer.Enforce(groupAccess, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Check for pause/resume actions
-		if strings.HasSuffix(r.URL.Path, "/pause") {
-			groupHandler.PauseGroup(w, r)
-		} else if strings.HasSuffix(r.URL.Path, "/resume") {
-			groupHandler.ResumeGroup(w, r)
+		if strings.HasSuffix(r.URL.Path, "/pause") || strings.HasSuffix(r.URL.Path, "/resume") {
+			if r.Method != http.MethodPost {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			} else if strings.HasSuffix(r.URL.Path, "/pause") {
+				groupHandler.PauseGroup(w, r)
+			} else {
+				groupHandler.ResumeGroup(w, r)
+			}
 		} else if r.Method == http.MethodGet {
 			groupHandler.GetGroup(w, r)
 		} else if r.Method == http.MethodPut {
@@ -84,10 +130,34 @@ func main() {
 		} else {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
-	})
+	}))
+	roleRoutes := middleware.DenyAPIKeys(groupAuthorizer.Enforce(roleAccess, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/roles") && r.Method == http.MethodGet {
+			groupHandler.GetGroupRoles(w, r)
+		} else if !strings.HasSuffix(r.URL.Path, "/roles") && r.Method == http.MethodPut {
+			groupHandler.GrantGroupRole(w, r)
+		} else if !strings.HasSuffix(r.URL.Path, "/roles") && r.Method == http.MethodDelete {
+			groupHandler.RevokeGroupRole(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+	mux.Handle("/api/v1/groups/", authenticator.OptionalAuth(middleware.RequireVerifiedEmail(middleware.EnforceAPIKeyPolicy(groupPolicy, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/roles") {
+			roleRoutes.ServeHTTP(w, r)
+		} else {
+			groupRoutes.ServeHTTP(w, r)
+		}
+	})))))
+
+	// Client addresses come from X-Forwarded-For only behind trusted proxies
+	trustedProxies, err := middleware.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		logger.Log.Fatal("Invalid TRUSTED_PROXIES", zap.Error(err))
+	}
 
 	// Apply middleware
-	handlerWithMiddleware := middleware.CORS(mux)
+	handlerWithMiddleware := middleware.CORS(middleware.ResolveClientIP(trustedProxies, mux))
 
 	// Start HTTP server
 	port := 3002
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -9,6 +10,7 @@ import (
 	"github.com/raufhm/fairflow/services/group/internal/usecase"
 	"github.com/raufhm/fairflow/shared/domain"
 	"github.com/raufhm/fairflow/shared/middleware"
+	"github.com/raufhm/fairflow/shared/strategy"
 )
 
 type GroupHandler struct {
@@ -23,28 +25,56 @@ type CreateGroupRequest struct {
 	Name        string  `json:"name"`
 	Description *string `json:"description"`
 	Strategy    string  `json:"strategy"`
+
+	// Strategy and strategy parameters, see domain.GroupSettings
+	Settings json.RawMessage `json:"settings"`
+
+	// Only honoured for super admins; other users create groups in their own organization
+	OrganizationID *int64 `json:"organizationId"`
 }
 
 type UpdateGroupRequest struct {
 	Name        *string `json:"name"`
 	Description *string `json:"description"`
 	Active      *bool   `json:"active"`
+	Strategy    *string `json:"strategy"`
+
+	// Replaces the group's settings, see domain.GroupSettings
+	Settings json.RawMessage `json:"settings"`
+}
+
+type GrantGroupRoleRequest struct {
+	Role string `json:"role"`
 }
 
 type PauseGroupRequest struct {
 	Reason *string `json:"reason"`
 }
 
-// GetAllGroups retrieves all groups
+// GetAllGroups retrieves all groups in the caller's organization
 func (h *GroupHandler) GetAllGroups(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
 	ctx := r.Context()
-	groups, err := h.groupUseCase.GetAllGroups(ctx)
+	groups, err := h.groupUseCase.GetAllGroups(ctx, user)
 	if err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"message": "Failed to retrieve groups"})
 		return
 	}
 
-	respondJSON(w, http.StatusOK, groups)
+	// Group-restricted API keys only see the groups they were granted
+	visible := groups[:0]
+	for _, group := range groups {
+		if middleware.CanAccessGroup(ctx, group.ID) {
+			visible = append(visible, group)
+		}
+	}
+
+	respondJSON(w, http.StatusOK, visible)
 }
 
 // CreateGroup creates a new group
@@ -57,6 +87,11 @@ func (h *GroupHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 
+	if apiKey := middleware.GetAPIKeyFromContext(ctx); apiKey != nil && len(apiKey.GroupIDs) > 0 {
+		respondJSON(w, http.StatusForbidden, map[string]string{"message": "Group-restricted API keys cannot create groups"})
+		return
+	}
+
 	var req CreateGroupRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid request body"})
@@ -68,13 +103,17 @@ func (h *GroupHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	strategy := domain.AssignmentStrategy(req.Strategy)
-	if strategy == "" {
-		strategy = domain.StrategyWeightedRoundRobin
+	organizationID := user.OrganizationID
+	if user.IsSuperAdmin() && req.OrganizationID != nil {
+		organizationID = req.OrganizationID
 	}
 
-	group, err := h.groupUseCase.CreateGroup(ctx, user.ID, user.Name, req.Name, req.Description, strategy)
+	group, err := h.groupUseCase.CreateGroup(ctx, user.ID, user.Name, organizationID, req.Name, req.Description, domain.AssignmentStrategy(req.Strategy), rawSettings(req.Settings))
 	if err != nil {
+		if isStrategyError(err) {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+			return
+		}
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"message": "Failed to create group"})
 		return
 	}
@@ -119,24 +158,19 @@ func (h *GroupHandler) UpdateGroup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if user can modify group
-	canModify, err := h.groupUseCase.CanModifyGroup(ctx, id, user.ID, user.Role)
-	if err != nil {
-		respondJSON(w, http.StatusNotFound, map[string]string{"message": "Group not found"})
-		return
-	}
-	if !canModify {
-		respondJSON(w, http.StatusForbidden, map[string]string{"message": "Forbidden: You do not have permission to modify this group"})
-		return
-	}
-
 	var req UpdateGroupRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid request body"})
 		return
 	}
 
-	group, err := h.groupUseCase.UpdateGroup(ctx, id, user.ID, user.Name, req.Name, req.Description, req.Active)
+	var strategyName *domain.AssignmentStrategy
+	if req.Strategy != nil {
+		name := domain.AssignmentStrategy(*req.Strategy)
+		strategyName = &name
+	}
+
+	group, err := h.groupUseCase.UpdateGroup(ctx, id, user.ID, user.Name, req.Name, req.Description, req.Active, strategyName, rawSettings(req.Settings))
 	if err != nil {
 		respondJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
 		return
@@ -160,17 +194,6 @@ func (h *GroupHandler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if user can modify group
-	canModify, err := h.groupUseCase.CanModifyGroup(ctx, id, user.ID, user.Role)
-	if err != nil {
-		respondJSON(w, http.StatusNotFound, map[string]string{"message": "Group not found"})
-		return
-	}
-	if !canModify {
-		respondJSON(w, http.StatusForbidden, map[string]string{"message": "Forbidden: You do not have permission to modify this group"})
-		return
-	}
-
 	if err := h.groupUseCase.DeleteGroup(ctx, id, user.ID, user.Name); err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{"message": "Failed to delete group"})
 		return
@@ -194,17 +217,6 @@ func (h *GroupHandler) PauseGroup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if user can modify group
-	canModify, err := h.groupUseCase.CanModifyGroup(ctx, id, user.ID, user.Role)
-	if err != nil {
-		respondJSON(w, http.StatusNotFound, map[string]string{"message": "Group not found"})
-		return
-	}
-	if !canModify {
-		respondJSON(w, http.StatusForbidden, map[string]string{"message": "Forbidden: You do not have permission to modify this group"})
-		return
-	}
-
 	var req PauseGroupRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		// If body is empty, that's okay, just use nil reason
@@ -234,27 +246,125 @@ func (h *GroupHandler) ResumeGroup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if user can modify group
-	canModify, err := h.groupUseCase.CanModifyGroup(ctx, id, user.ID, user.Role)
+	if err := h.groupUseCase.ResumeGroup(ctx, id, user.ID, user.Name); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Group assignments resumed successfully"})
+}
+
+// GetGroupRoles lists the roles granted in a group
+func (h *GroupHandler) GetGroupRoles(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := getIDFromPath(r, "/api/v1/groups/", "/roles")
+	if id == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid group ID"})
+		return
+	}
+
+	grants, err := h.groupUseCase.GetRoles(ctx, id)
 	if err != nil {
-		respondJSON(w, http.StatusNotFound, map[string]string{"message": "Group not found"})
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"message": "Failed to retrieve group roles"})
 		return
 	}
-	if !canModify {
-		respondJSON(w, http.StatusForbidden, map[string]string{"message": "Forbidden: You do not have permission to modify this group"})
+
+	respondJSON(w, http.StatusOK, grants)
+}
+
+// GrantGroupRole gives a user a role in a group
+func (h *GroupHandler) GrantGroupRole(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	group := middleware.GetGroupFromContext(r.Context())
+	if user == nil || group == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
 		return
 	}
 
-	if err := h.groupUseCase.ResumeGroup(ctx, id, user.ID, user.Name); err != nil {
+	userID := getRoleUserIDFromPath(r)
+	if userID == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid user ID"})
+		return
+	}
+
+	var req GrantGroupRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid request body"})
+		return
+	}
+
+	grant, err := h.groupUseCase.GrantRole(r.Context(), group, user.ID, userID, domain.GroupRole(req.Role))
+	if errors.Is(err, usecase.ErrInvalidGroupRole) || errors.Is(err, usecase.ErrUserNotInOrganization) {
 		respondJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
 		return
 	}
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"message": "Failed to grant group role"})
+		return
+	}
 
-	respondJSON(w, http.StatusOK, map[string]string{"message": "Group assignments resumed successfully"})
+	respondJSON(w, http.StatusOK, grant)
+}
+
+// RevokeGroupRole removes a user's role in a group
+func (h *GroupHandler) RevokeGroupRole(w http.ResponseWriter, r *http.Request) {
+	group := middleware.GetGroupFromContext(r.Context())
+	if group == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	userID := getRoleUserIDFromPath(r)
+	if userID == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid user ID"})
+		return
+	}
+
+	err := h.groupUseCase.RevokeRole(r.Context(), group.ID, userID)
+	if errors.Is(err, usecase.ErrGrantNotFound) {
+		respondJSON(w, http.StatusNotFound, map[string]string{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"message": "Failed to revoke group role"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Group role revoked successfully"})
 }
 
 // Helper functions
 
+// getRoleUserIDFromPath extracts the user ID from /api/v1/groups/{id}/roles/{userId}
+func getRoleUserIDFromPath(r *http.Request) int64 {
+	_, userID, found := strings.Cut(r.URL.Path, "/roles/")
+	if !found {
+		return 0
+	}
+	return parseID(strings.Trim(userID, "/"))
+}
+
+// rawSettings converts a settings document from a request body for storage.
+// It returns nil when the field was omitted or null.
+func rawSettings(raw json.RawMessage) *string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	settings := string(raw)
+	return &settings
+}
+
+// isStrategyError reports whether err rejects the requested strategy or settings
+func isStrategyError(err error) bool {
+	return errors.Is(err, usecase.ErrInvalidSettings) ||
+		errors.Is(err, usecase.ErrStrategyConflict) ||
+		errors.Is(err, usecase.ErrInvalidFallback) ||
+		errors.Is(err, usecase.ErrInvalidOverflowGroup) ||
+		errors.Is(err, usecase.ErrInvalidAcceptanceWindow) ||
+		errors.Is(err, strategy.ErrUnknownStrategy) ||
+		errors.Is(err, strategy.ErrInvalidParams)
+}
+
 // respondJSON writes a JSON response
 func respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -2,35 +2,64 @@ package usecase
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"time"
 
 	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/strategy"
+)
+
+var (
+	ErrInvalidGroupRole        = errors.New("role must be owner, manager, dispatcher or viewer")
+	ErrUserNotInOrganization   = errors.New("user does not belong to the group's organization")
+	ErrGrantNotFound           = errors.New("user has no role in this group")
+	ErrInvalidSettings         = errors.New("settings must be a JSON object")
+	ErrStrategyConflict        = errors.New("strategy does not match the strategy in settings")
+	ErrInvalidFallback         = errors.New("off_hours_fallback must be queue, ignore_hours or overflow")
+	ErrInvalidOverflowGroup    = errors.New("overflow_group_id must name another group in the same organization")
+	ErrInvalidAcceptanceWindow = errors.New("acceptance_window must be a positive duration such as 30m")
 )
 
 type GroupUseCase struct {
 	groupRepo  domain.GroupRepository
 	memberRepo domain.MemberRepository
+	grantRepo  domain.GroupGrantRepository
+	userRepo   domain.UserRepository
+	strategies *strategy.Registry
 }
 
 func NewGroupUseCase(
 	groupRepo domain.GroupRepository,
 	memberRepo domain.MemberRepository,
+	grantRepo domain.GroupGrantRepository,
+	userRepo domain.UserRepository,
+	strategies *strategy.Registry,
 ) *GroupUseCase {
 	return &GroupUseCase{
 		groupRepo:  groupRepo,
 		memberRepo: memberRepo,
+		grantRepo:  grantRepo,
+		userRepo:   userRepo,
+		strategies: strategies,
 	}
 }
 
-// CreateGroup creates a new group
-func (uc *GroupUseCase) CreateGroup(ctx context.Context, userID int64, userName, name string, description *string, strategy domain.AssignmentStrategy) (*domain.Group, error) {
+// CreateGroup creates a new group owned by organizationID. settings may pick the
+// strategy and its parameters instead of strategyName.
+func (uc *GroupUseCase) CreateGroup(ctx context.Context, userID int64, userName string, organizationID *int64, name string, description *string, strategyName domain.AssignmentStrategy, settings *string) (*domain.Group, error) {
 	group := &domain.Group{
-		UserID:      userID,
-		Name:        name,
-		Description: description,
-		Strategy:    strategy,
-		Active:      true,
+		UserID:         userID,
+		OrganizationID: organizationID,
+		Name:           name,
+		Description:    description,
+		Strategy:       strategyName,
+		Settings:       settings,
+		Active:         true,
+	}
+
+	if err := uc.applySettings(ctx, group, false); err != nil {
+		return nil, err
 	}
 
 	if err := uc.groupRepo.Create(ctx, group); err != nil {
@@ -45,9 +74,35 @@ func (uc *GroupUseCase) GetGroup(ctx context.Context, id int64) (*domain.Group,
 	return uc.groupRepo.GetByID(ctx, id)
 }
 
-// GetAllGroups retrieves all groups
-func (uc *GroupUseCase) GetAllGroups(ctx context.Context) ([]*domain.Group, error) {
-	return uc.groupRepo.GetAll(ctx)
+// GetAllGroups retrieves the groups a user has a role in: every group for super
+// admins, otherwise groups in the user's organization they own, administer or
+// were granted a role in
+func (uc *GroupUseCase) GetAllGroups(ctx context.Context, user *domain.User) ([]*domain.Group, error) {
+	if user.IsSuperAdmin() {
+		return uc.groupRepo.GetAll(ctx)
+	}
+
+	groups, err := uc.groupRepo.GetByOrganizationID(ctx, user.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	grants, err := uc.grantRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	grantsByGroup := make(map[int64]*domain.GroupGrant, len(grants))
+	for _, grant := range grants {
+		grantsByGroup[grant.GroupID] = grant
+	}
+
+	visible := make([]*domain.Group, 0, len(groups))
+	for _, group := range groups {
+		if domain.EffectiveGroupRole(user, group, grantsByGroup[group.ID]) != "" {
+			visible = append(visible, group)
+		}
+	}
+	return visible, nil
 }
 
 // GetUserGroups retrieves groups belonging to a user
@@ -56,7 +111,7 @@ func (uc *GroupUseCase) GetUserGroups(ctx context.Context, userID int64) ([]*dom
 }
 
 // UpdateGroup updates a group
-func (uc *GroupUseCase) UpdateGroup(ctx context.Context, id, userID int64, userName string, name *string, description *string, active *bool) (*domain.Group, error) {
+func (uc *GroupUseCase) UpdateGroup(ctx context.Context, id, userID int64, userName string, name *string, description *string, active *bool, strategyName *domain.AssignmentStrategy, settings *string) (*domain.Group, error) {
 	group, err := uc.groupRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
@@ -78,11 +133,25 @@ func (uc *GroupUseCase) UpdateGroup(ctx context.Context, id, userID int64, userN
 		group.Active = *active
 		updated = true
 	}
+	if strategyName != nil {
+		group.Strategy = *strategyName
+		updated = true
+	}
+	if settings != nil {
+		group.Settings = settings
+		updated = true
+	}
 
 	if !updated {
 		return nil, errors.New("no valid fields provided for update")
 	}
 
+	if strategyName != nil || settings != nil {
+		if err := uc.applySettings(ctx, group, strategyName != nil); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := uc.groupRepo.Update(ctx, group); err != nil {
 		return nil, err
 	}
@@ -104,24 +173,11 @@ func (uc *GroupUseCase) DeleteGroup(ctx context.Context, id, userID int64, userN
 		return err
 	}
 
-	return nil
-}
-
-// CanModifyGroup checks if a user can modify a group
-func (uc *GroupUseCase) CanModifyGroup(ctx context.Context, groupID, userID int64, userRole domain.UserRole) (bool, error) {
-	group, err := uc.groupRepo.GetByID(ctx, groupID)
-	if err != nil {
-		return false, err
-	}
-	if group == nil {
-		return false, errors.New("group not found")
+	if err := uc.grantRepo.DeleteByGroupID(ctx, id); err != nil {
+		return err
 	}
 
-	// User is owner or admin/super_admin
-	isOwner := group.UserID == userID
-	isAdmin := userRole == domain.RoleAdmin || userRole == domain.RoleSuperAdmin
-
-	return isOwner || isAdmin, nil
+	return nil
 }
 
 // PauseGroup pauses assignments for a group
@@ -178,3 +234,108 @@ func (uc *GroupUseCase) ResumeGroup(ctx context.Context, groupID, userID int64,
 
 	return nil
 }
+
+// GetRoles lists the roles granted in a group
+func (uc *GroupUseCase) GetRoles(ctx context.Context, groupID int64) ([]*domain.GroupGrant, error) {
+	return uc.grantRepo.GetByGroupID(ctx, groupID)
+}
+
+// GrantRole gives a user of the group's organization a role in the group,
+// replacing any role they already had
+func (uc *GroupUseCase) GrantRole(ctx context.Context, group *domain.Group, grantedBy, userID int64, role domain.GroupRole) (*domain.GroupGrant, error) {
+	if !domain.IsValidGroupRole(role) {
+		return nil, ErrInvalidGroupRole
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.IsSuperAdmin() || !user.BelongsTo(group.OrganizationID) {
+		return nil, ErrUserNotInOrganization
+	}
+
+	grant := &domain.GroupGrant{
+		GroupID:   group.ID,
+		UserID:    userID,
+		Role:      role,
+		GrantedBy: grantedBy,
+	}
+	if err := uc.grantRepo.Upsert(ctx, grant); err != nil {
+		return nil, err
+	}
+
+	return grant, nil
+}
+
+// RevokeRole removes a user's role in a group
+func (uc *GroupUseCase) RevokeRole(ctx context.Context, groupID, userID int64) error {
+	deleted, err := uc.grantRepo.Delete(ctx, groupID, userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrGrantNotFound
+	}
+	return nil
+}
+
+// applySettings validates the group's settings. The strategy and its
+// parameters are checked against the strategy registry; a strategy named in the
+// settings takes precedence and is copied to the group's strategy. explicit
+// reports whether the caller also set the strategy directly, in which case the
+// two must agree.
+func (uc *GroupUseCase) applySettings(ctx context.Context, group *domain.Group, explicit bool) error {
+	settings, err := group.ParseSettings()
+	if err != nil {
+		return ErrInvalidSettings
+	}
+
+	if settings.Strategy != "" {
+		if explicit && group.Strategy != settings.Strategy {
+			return ErrStrategyConflict
+		}
+		group.Strategy = settings.Strategy
+	}
+	if group.Strategy == "" {
+		group.Strategy = domain.StrategyWeightedRoundRobin
+	}
+
+	if _, err := uc.strategies.Build(group.Strategy, settings.StrategyParams); err != nil {
+		return err
+	}
+
+	switch settings.Fallback() {
+	case domain.OffHoursQueue, domain.OffHoursIgnoreHours:
+	case domain.OffHoursOverflow:
+		if settings.OverflowGroupID == nil || *settings.OverflowGroupID == group.ID {
+			return ErrInvalidOverflowGroup
+		}
+		overflow, err := uc.groupRepo.GetByID(ctx, *settings.OverflowGroupID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidOverflowGroup
+		}
+		if err != nil {
+			return err
+		}
+		if overflow == nil || !sameOrganization(overflow.OrganizationID, group.OrganizationID) {
+			return ErrInvalidOverflowGroup
+		}
+	default:
+		return ErrInvalidFallback
+	}
+
+	if _, err := settings.AcceptanceTimeout(); err != nil {
+		return ErrInvalidAcceptanceWindow
+	}
+
+	return nil
+}
+
+// sameOrganization compares two optional organization IDs
+func sameOrganization(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
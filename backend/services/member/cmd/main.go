@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -9,15 +11,20 @@ import (
 	"strings"
 	"syscall"
 	"time"
+	_ "time/tzdata" // Working hours need IANA zones; the runtime image has none
 
 	"github.com/raufhm/fairflow/services/member/internal/handler"
 	"github.com/raufhm/fairflow/services/member/internal/usecase"
 	"github.com/raufhm/fairflow/shared/config"
+	"github.com/raufhm/fairflow/shared/crypto"
 	"github.com/raufhm/fairflow/shared/database"
+	"github.com/raufhm/fairflow/shared/domain"
 	"github.com/raufhm/fairflow/shared/health"
+	"github.com/raufhm/fairflow/shared/httpclient"
 	"github.com/raufhm/fairflow/shared/logger"
 	"github.com/raufhm/fairflow/shared/middleware"
 	"github.com/raufhm/fairflow/shared/repository/postgres"
+	"github.com/raufhm/fairflow/shared/webhook"
 	"go.uber.org/zap"
 )
 
@@ -45,13 +52,37 @@ func main() {
 	// Initialize repositories
 	memberRepo := postgres.NewMemberRepository(db)
 	groupRepo := postgres.NewGroupRepository(db)
+	userRepo := postgres.NewUserRepository(db)
+	apiKeyRepo := postgres.NewAPIKeyRepository(db)
+	sessionRepo := postgres.NewSessionRepository(db)
+	auditRepo := postgres.NewAuditLogRepository(db)
+	grantRepo := postgres.NewGroupGrantRepository(db)
+	timeOffRepo := postgres.NewTimeOffRepository(db)
+	webhookRepo := postgres.NewWebhookRepository(db)
+
+	// Availability changes are delivered to the group's webhooks
+	notifier := webhook.NewNotifier(webhookRepo)
 
 	// Initialize use case
-	memberUseCase := usecase.NewMemberUseCase(memberRepo, groupRepo)
+	memberUseCase := usecase.NewMemberUseCase(memberRepo, groupRepo, timeOffRepo, notifier)
+	timeOffWatcher := usecase.NewTimeOffWatcher(memberUseCase)
 
 	// Initialize handler
 	memberHandler := handler.NewMemberHandler(memberUseCase)
 
+	// Calls to other services are signed with this service's identity
+	serviceTokens, err := crypto.LoadServiceTokenService(crypto.MemberService, cfg.ServicePrivateKey, cfg.ServicePublicKeys, cfg.ServiceDevSecret, cfg.ServiceTokenTTL)
+	if err != nil {
+		logger.Log.Fatal("Failed to load service keys", zap.Error(err))
+	}
+	authClient := httpclient.NewServiceClient(cfg.AuthServiceURL, crypto.AuthService).WithServiceAuth(serviceTokens)
+
+	// Initialize authentication middleware, verifying tokens against the auth service's JWKS
+	jwksCache := httpclient.NewJWKSCache(authClient, cfg.JWKSCacheTTL)
+	tokenService := crypto.NewVerifyingTokenService(jwksCache)
+	authenticator := middleware.NewAuthenticator(userRepo, apiKeyRepo, sessionRepo, auditRepo, tokenService, cfg.JWTSecret)
+	groupAuthorizer := middleware.NewGroupAuthorizer(groupRepo, grantRepo)
+
 	// Setup HTTP router
 	mux := http.NewServeMux()
 
@@ -59,8 +90,45 @@ func main() {
 	healthChecker := health.NewHealthChecker(db)
 	mux.HandleFunc("/health", healthChecker.Handler("member-service", "1.0.0"))
 
+	// API key restrictions
+	groupMembersPolicy := middleware.APIKeyPolicy{
+		ReadScope:  domain.ScopeMembersRead,
+		WriteScope: domain.ScopeMembersWrite,
+		GroupID:    middleware.GroupIDFromPath("/api/v1/groups/"),
+	}
+	memberPolicy := middleware.APIKeyPolicy{
+		ReadScope:  domain.ScopeMembersRead,
+		WriteScope: domain.ScopeMembersWrite,
+		GroupID: func(r *http.Request) (int64, error) {
+			memberID := middleware.PathID(r, "/api/v1/members/")
+			if memberID == 0 {
+				return 0, nil
+			}
+			member, err := memberRepo.GetByID(r.Context(), memberID)
+			if errors.Is(err, sql.ErrNoRows) {
+				return 0, nil
+			}
+			if err != nil {
+				return 0, err
+			}
+			return member.GroupID, nil
+		},
+	}
+
+	// Group roles needed on each route
+	groupMembersAccess := middleware.GroupAccessPolicy{
+		Read:    domain.GroupRoleViewer,
+		Write:   domain.GroupRoleManager,
+		GroupID: groupMembersPolicy.GroupID,
+	}
+	memberAccess := middleware.GroupAccessPolicy{
+		Read:    domain.GroupRoleViewer,
+		Write:   domain.GroupRoleManager,
+		GroupID: memberPolicy.GroupID,
+	}
+
 	// Member endpoints
-	mux.HandleFunc("/api/v1/groups/", func(w http.ResponseWriter, r *http.Request) {
+	mux.Handle("/api/v1/groups/", authenticator.OptionalAuth(middleware.RequireVerifiedEmail(middleware.EnforceAPIKeyPolicy(groupMembersPolicy, groupAuthorizer.Enforce(groupMembersAccess, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if strings.Contains(r.URL.Path, "/members") {
 			if r.Method == http.MethodGet {
 				memberHandler.GetMembers(w, r)
@@ -72,10 +140,24 @@ func main() {
 		} else {
 			http.Error(w, "Not found", http.StatusNotFound)
 		}
-	})
+	}))))))
 
-	mux.HandleFunc("/api/v1/members/", func(w http.ResponseWriter, r *http.Request) {
-		if strings.HasSuffix(r.URL.Path, "/capacity") {
+	mux.Handle("/api/v1/members/", authenticator.OptionalAuth(middleware.RequireVerifiedEmail(middleware.EnforceAPIKeyPolicy(memberPolicy, groupAuthorizer.Enforce(memberAccess, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/time-off/") {
+			if r.Method == http.MethodDelete {
+				memberHandler.CancelTimeOff(w, r)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/time-off") {
+			if r.Method == http.MethodGet {
+				memberHandler.GetTimeOff(w, r)
+			} else if r.Method == http.MethodPost {
+				memberHandler.ScheduleTimeOff(w, r)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(r.URL.Path, "/capacity") {
 			memberHandler.GetMemberCapacity(w, r)
 		} else if r.Method == http.MethodGet {
 			memberHandler.GetMember(w, r)
@@ -86,10 +168,16 @@ func main() {
 		} else {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
-	})
+	}))))))
+
+	// Client addresses come from X-Forwarded-For only behind trusted proxies
+	trustedProxies, err := middleware.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		logger.Log.Fatal("Invalid TRUSTED_PROXIES", zap.Error(err))
+	}
 
 	// Apply middleware
-	handlerWithMiddleware := middleware.CORS(mux)
+	handlerWithMiddleware := middleware.CORS(middleware.ResolveClientIP(trustedProxies, mux))
 
 	// Start HTTP server
 	port := 3003
@@ -103,6 +191,10 @@ func main() {
 		Handler: handlerWithMiddleware,
 	}
 
+	// Announce availability changes as time off starts and ends
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	go timeOffWatcher.Run(workersCtx, time.Minute)
+
 	go func() {
 		logger.Log.Info("Member Service is running on " + addr)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -116,6 +208,7 @@ func main() {
 	<-quit
 
 	logger.Log.Info("Shutting down Member Service...")
+	stopWorkers()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
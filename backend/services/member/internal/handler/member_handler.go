@@ -2,11 +2,14 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/raufhm/fairflow/services/member/internal/usecase"
+	"github.com/raufhm/fairflow/shared/domain"
 	"github.com/raufhm/fairflow/shared/middleware"
 )
 
@@ -21,16 +24,32 @@ func NewMemberHandler(memberUseCase *usecase.MemberUseCase) *MemberHandler {
 }
 
 type CreateMemberRequest struct {
-	Name   string  `json:"name"`
-	Email  *string `json:"email"`
-	Weight int     `json:"weight"`
+	Name     string  `json:"name"`
+	Email    *string `json:"email"`
+	Weight   int     `json:"weight"`
+	Timezone *string `json:"timezone"`
+
+	// Weekdays to time ranges, e.g. {"monday": "09:00-17:00", "friday": ["08:00-12:00", "22:00-02:00"]}
+	WorkingHours json.RawMessage `json:"working_hours"`
 }
 
 type UpdateMemberRequest struct {
-	Name   *string `json:"name"`
-	Email  *string `json:"email"`
-	Weight *int    `json:"weight"`
-	Active *bool   `json:"active"`
+	Name      *string `json:"name"`
+	Email     *string `json:"email"`
+	Weight    *int    `json:"weight"`
+	Active    *bool   `json:"active"`
+	Available *bool   `json:"available"`
+	Timezone  *string `json:"timezone"`
+
+	// Replaces the member's working hours; null clears them
+	WorkingHours json.RawMessage `json:"working_hours"`
+}
+
+type ScheduleTimeOffRequest struct {
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+	Reason   string    `json:"reason"`
+	Note     *string   `json:"note"`
 }
 
 // GetMembers retrieves all members of a group
@@ -81,8 +100,12 @@ func (h *MemberHandler) CreateMember(w http.ResponseWriter, r *http.Request) {
 		req.Weight = 100
 	}
 
-	member, err := h.memberUseCase.CreateMember(ctx, groupID, user.ID, user.Name, req.Name, req.Email, req.Weight)
+	member, err := h.memberUseCase.CreateMember(ctx, groupID, user.ID, user.Name, req.Name, req.Email, req.Weight, workingHours(req.WorkingHours), req.Timezone)
 	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidWorkingHours) || errors.Is(err, usecase.ErrInvalidTimezone) {
+			respondJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+			return
+		}
 		respondJSON(w, http.StatusInternalServerError, map[string]string{
 			"message": "Failed to add member",
 			"error":   err.Error(),
@@ -139,7 +162,7 @@ func (h *MemberHandler) UpdateMember(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.memberUseCase.UpdateMember(ctx, memberID, user.ID, user.Name, req.Name, req.Email, req.Weight, req.Active); err != nil {
+	if err := h.memberUseCase.UpdateMember(ctx, memberID, user.ID, user.Name, req.Name, req.Email, req.Weight, req.Active, req.Available, workingHours(req.WorkingHours), req.Timezone); err != nil {
 		respondJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
 		return
 	}
@@ -188,8 +211,115 @@ func (h *MemberHandler) GetMemberCapacity(w http.ResponseWriter, r *http.Request
 	respondJSON(w, http.StatusOK, capacity)
 }
 
+// GetTimeOff lists a member's current and upcoming time off
+func (h *MemberHandler) GetTimeOff(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	memberID := getIDFromPath(r, "/api/v1/members/")
+	if memberID == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid member ID"})
+		return
+	}
+
+	windows, err := h.memberUseCase.ListTimeOff(ctx, memberID)
+	if err != nil {
+		respondTimeOffError(w, err, "Failed to retrieve time off")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, windows)
+}
+
+// ScheduleTimeOff books an out-of-office window for a member
+func (h *MemberHandler) ScheduleTimeOff(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	ctx := r.Context()
+	memberID := getIDFromPath(r, "/api/v1/members/")
+	if memberID == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid member ID"})
+		return
+	}
+
+	var req ScheduleTimeOffRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid request body"})
+		return
+	}
+
+	window, err := h.memberUseCase.ScheduleTimeOff(ctx, memberID, user.ID, req.StartsAt, req.EndsAt, domain.TimeOffReason(req.Reason), req.Note)
+	if err != nil {
+		respondTimeOffError(w, err, "Failed to schedule time off")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, window)
+}
+
+// CancelTimeOff removes one of a member's time off windows
+func (h *MemberHandler) CancelTimeOff(w http.ResponseWriter, r *http.Request) {
+	user := middleware.GetUserFromContext(r.Context())
+	if user == nil {
+		respondJSON(w, http.StatusUnauthorized, map[string]string{"message": "Authentication required"})
+		return
+	}
+
+	ctx := r.Context()
+	memberID := getIDFromPath(r, "/api/v1/members/")
+	windowID := getTimeOffIDFromPath(r)
+	if memberID == 0 || windowID == 0 {
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": "Invalid member or time off ID"})
+		return
+	}
+
+	if err := h.memberUseCase.CancelTimeOff(ctx, memberID, windowID); err != nil {
+		respondTimeOffError(w, err, "Failed to cancel time off")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Time off cancelled successfully"})
+}
+
 // Helper functions
 
+// respondTimeOffError maps time off errors to HTTP responses
+func respondTimeOffError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, usecase.ErrMemberNotFound), errors.Is(err, usecase.ErrTimeOffNotFound):
+		respondJSON(w, http.StatusNotFound, map[string]string{"message": err.Error()})
+	case errors.Is(err, usecase.ErrInvalidTimeOffRange), errors.Is(err, usecase.ErrInvalidTimeOffReason):
+		respondJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+	default:
+		respondJSON(w, http.StatusInternalServerError, map[string]string{"message": fallback})
+	}
+}
+
+// getTimeOffIDFromPath extracts the window ID from /api/v1/members/{id}/time-off/{timeOffId}
+func getTimeOffIDFromPath(r *http.Request) int64 {
+	_, windowID, found := strings.Cut(r.URL.Path, "/time-off/")
+	if !found {
+		return 0
+	}
+	return parseID(strings.Trim(windowID, "/"))
+}
+
+// workingHours converts working hours from a request body for storage. It
+// returns nil when the field was omitted and an empty string when it was null,
+// which clears the member's hours on update.
+func workingHours(raw json.RawMessage) *string {
+	if len(raw) == 0 {
+		return nil
+	}
+	hours := ""
+	if string(raw) != "null" {
+		hours = string(raw)
+	}
+	return &hours
+}
+
 // respondJSON writes a JSON response
 func respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -2,34 +2,62 @@ package usecase
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/logger"
+	"github.com/raufhm/fairflow/shared/webhook"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInvalidWorkingHours  = errors.New("invalid working hours")
+	ErrInvalidTimezone      = errors.New("timezone must be an IANA name such as Europe/Berlin")
+	ErrMemberNotFound       = errors.New("member not found")
+	ErrTimeOffNotFound      = errors.New("time off not found")
+	ErrInvalidTimeOffRange  = errors.New("ends_at must be after starts_at and in the future")
+	ErrInvalidTimeOffReason = errors.New("reason must be vacation, sick_leave, personal, training or other")
 )
 
 type MemberUseCase struct {
-	memberRepo domain.MemberRepository
-	groupRepo  domain.GroupRepository
+	memberRepo  domain.MemberRepository
+	groupRepo   domain.GroupRepository
+	timeOffRepo domain.TimeOffRepository
+	notifier    *webhook.Notifier
 }
 
 func NewMemberUseCase(
 	memberRepo domain.MemberRepository,
 	groupRepo domain.GroupRepository,
+	timeOffRepo domain.TimeOffRepository,
+	notifier *webhook.Notifier,
 ) *MemberUseCase {
 	return &MemberUseCase{
-		memberRepo: memberRepo,
-		groupRepo:  groupRepo,
+		memberRepo:  memberRepo,
+		groupRepo:   groupRepo,
+		timeOffRepo: timeOffRepo,
+		notifier:    notifier,
 	}
 }
 
 // CreateMember creates a new member in a group
-func (uc *MemberUseCase) CreateMember(ctx context.Context, groupID, userID int64, userName, name string, email *string, weight int) (*domain.Member, error) {
+func (uc *MemberUseCase) CreateMember(ctx context.Context, groupID, userID int64, userName, name string, email *string, weight int, workingHours, timezone *string) (*domain.Member, error) {
 	member := &domain.Member{
-		GroupID: groupID,
-		Name:    name,
-		Email:   email,
-		Weight:  weight,
-		Active:  true,
+		GroupID:      groupID,
+		Name:         name,
+		Email:        email,
+		Weight:       weight,
+		Active:       true,
+		Available:    true,
+		WorkingHours: emptyToNil(workingHours),
+		Timezone:     emptyToNil(timezone),
+	}
+
+	if err := validateSchedule(member); err != nil {
+		return nil, err
 	}
 
 	if err := uc.memberRepo.Create(ctx, member); err != nil {
@@ -50,7 +78,7 @@ func (uc *MemberUseCase) GetMember(ctx context.Context, id int64) (*domain.Membe
 }
 
 // UpdateMember updates a member
-func (uc *MemberUseCase) UpdateMember(ctx context.Context, id, userID int64, userName string, name *string, email *string, weight *int, active *bool) error {
+func (uc *MemberUseCase) UpdateMember(ctx context.Context, id, userID int64, userName string, name *string, email *string, weight *int, active, available *bool, workingHours, timezone *string) error {
 	member, err := uc.memberRepo.GetByID(ctx, id)
 	if err != nil {
 		return err
@@ -76,15 +104,40 @@ func (uc *MemberUseCase) UpdateMember(ctx context.Context, id, userID int64, use
 		member.Active = *active
 		updated = true
 	}
+	availabilityChanged := available != nil && *available != member.Available
+	if available != nil {
+		member.Available = *available
+		updated = true
+	}
+	// An empty string clears the working hours or timezone
+	if workingHours != nil {
+		member.WorkingHours = emptyToNil(workingHours)
+		updated = true
+	}
+	if timezone != nil {
+		member.Timezone = emptyToNil(timezone)
+		updated = true
+	}
 
 	if !updated {
 		return errors.New("no valid fields provided for update")
 	}
 
+	if err := validateSchedule(member); err != nil {
+		return err
+	}
+
 	if err := uc.memberRepo.Update(ctx, member); err != nil {
 		return err
 	}
 
+	if availabilityChanged {
+		uc.emit(ctx, member, webhook.EventMemberAvailabilityChanged, map[string]interface{}{
+			"available":  member.Available,
+			"changed_by": userID,
+		})
+	}
+
 	return nil
 }
 
@@ -105,6 +158,173 @@ func (uc *MemberUseCase) DeleteMember(ctx context.Context, id, userID int64, use
 	return nil
 }
 
+// ListTimeOff returns the member's current and upcoming time off
+func (uc *MemberUseCase) ListTimeOff(ctx context.Context, memberID int64) ([]*domain.TimeOffWindow, error) {
+	if _, err := uc.findMember(ctx, memberID); err != nil {
+		return nil, err
+	}
+
+	return uc.timeOffRepo.GetByMemberID(ctx, memberID, time.Now())
+}
+
+// ScheduleTimeOff books an out-of-office window for a member
+func (uc *MemberUseCase) ScheduleTimeOff(ctx context.Context, memberID, userID int64, startsAt, endsAt time.Time, reason domain.TimeOffReason, note *string) (*domain.TimeOffWindow, error) {
+	member, err := uc.findMember(ctx, memberID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !endsAt.After(startsAt) || !endsAt.After(time.Now()) {
+		return nil, ErrInvalidTimeOffRange
+	}
+	if !reason.IsValid() {
+		return nil, ErrInvalidTimeOffReason
+	}
+
+	window := &domain.TimeOffWindow{
+		MemberID:  memberID,
+		StartsAt:  startsAt,
+		EndsAt:    endsAt,
+		Reason:    reason,
+		Note:      note,
+		CreatedBy: userID,
+	}
+
+	// A window that has already started takes the member out of office right
+	// away, so its start is announced here rather than by the TimeOffWatcher
+	now := time.Now()
+	startsNow := !startsAt.After(now)
+	wasOut := false
+	if startsNow {
+		if wasOut, err = uc.outOfOffice(ctx, member, now); err != nil {
+			return nil, err
+		}
+		window.StartNotifiedAt = &now
+	}
+
+	if err := uc.timeOffRepo.Create(ctx, window); err != nil {
+		return nil, err
+	}
+
+	uc.emit(ctx, member, webhook.EventMemberTimeOffScheduled, timeOffEventData(window))
+	if startsNow && member.Available && !wasOut {
+		uc.emitTimeOffAvailability(ctx, member, window, false)
+	}
+
+	return window, nil
+}
+
+// CancelTimeOff removes one of the member's time off windows
+func (uc *MemberUseCase) CancelTimeOff(ctx context.Context, memberID, windowID int64) error {
+	member, err := uc.findMember(ctx, memberID)
+	if err != nil {
+		return err
+	}
+
+	window, err := uc.timeOffRepo.GetByID(ctx, windowID)
+	if err != nil {
+		return err
+	}
+	if window == nil || window.MemberID != memberID {
+		return ErrTimeOffNotFound
+	}
+
+	if err := uc.timeOffRepo.Delete(ctx, windowID); err != nil {
+		return err
+	}
+
+	uc.emit(ctx, member, webhook.EventMemberTimeOffCancelled, timeOffEventData(window))
+
+	// Cancelling a window whose start was announced brings the member back early
+	now := time.Now()
+	if window.StartNotifiedAt != nil && window.Covers(now) && member.Available {
+		stillOut, err := uc.outOfOffice(ctx, member, now)
+		if err != nil {
+			return err
+		}
+		if !stillOut {
+			uc.emitTimeOffAvailability(ctx, member, window, true)
+		}
+	}
+
+	return nil
+}
+
+// findMember loads a member, mapping a missing row to ErrMemberNotFound
+func (uc *MemberUseCase) findMember(ctx context.Context, id int64) (*domain.Member, error) {
+	member, err := uc.memberRepo.GetByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && member == nil) {
+		return nil, ErrMemberNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// timeOffEventData describes a time off window in an event payload
+func timeOffEventData(window *domain.TimeOffWindow) map[string]interface{} {
+	return map[string]interface{}{
+		"time_off_id": window.ID,
+		"starts_at":   window.StartsAt,
+		"ends_at":     window.EndsAt,
+		"reason":      window.Reason,
+		"active":      window.Covers(time.Now()),
+	}
+}
+
+// outOfOffice reports whether any of the member's time off windows covers the instant
+func (uc *MemberUseCase) outOfOffice(ctx context.Context, member *domain.Member, at time.Time) (bool, error) {
+	outOfOffice, err := uc.timeOffRepo.GetOutOfOfficeMemberIDs(ctx, []int64{member.ID}, at)
+	if err != nil {
+		return false, err
+	}
+	return outOfOffice[member.ID], nil
+}
+
+// emitTimeOffAvailability announces that a time off window took the member out
+// of office or brought them back
+func (uc *MemberUseCase) emitTimeOffAvailability(ctx context.Context, member *domain.Member, window *domain.TimeOffWindow, available bool) {
+	uc.emit(ctx, member, webhook.EventMemberAvailabilityChanged, map[string]interface{}{
+		"available":   available,
+		"time_off_id": window.ID,
+	})
+}
+
+// emit notifies the group's webhooks about a change to one of its members.
+// Delivery outlives the request, so the request's cancellation is dropped.
+func (uc *MemberUseCase) emit(ctx context.Context, member *domain.Member, eventType string, data map[string]interface{}) {
+	data["member_id"] = member.ID
+	data["group_id"] = member.GroupID
+
+	if err := uc.notifier.Notify(context.WithoutCancel(ctx), member.GroupID, eventType, data); err != nil {
+		logger.Log.Error("Failed to emit member event", zap.String("event", eventType), zap.Error(err))
+	}
+}
+
+// validateSchedule checks the member's working hours and timezone
+func validateSchedule(member *domain.Member) error {
+	if member.WorkingHours != nil {
+		if _, err := domain.ParseWorkingHours(*member.WorkingHours); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidWorkingHours, err)
+		}
+	}
+	if member.Timezone != nil {
+		if _, err := time.LoadLocation(*member.Timezone); err != nil {
+			return ErrInvalidTimezone
+		}
+	}
+	return nil
+}
+
+// emptyToNil maps an empty string to nil
+func emptyToNil(value *string) *string {
+	if value == nil || *value == "" {
+		return nil
+	}
+	return value
+}
+
 // CapacityStatus represents the current capacity status of a member
 type CapacityStatus struct {
 	MemberID                    int64  `json:"member_id"`
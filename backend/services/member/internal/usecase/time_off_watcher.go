@@ -0,0 +1,119 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/logger"
+	"go.uber.org/zap"
+)
+
+// timeOffBatchSize bounds how many window starts or ends are announced per pass
+const timeOffBatchSize = 100
+
+// TimeOffWatcher emits member.availability_changed when scheduled time off
+// starts or ends, which is when the member's availability actually changes
+type TimeOffWatcher struct {
+	members *MemberUseCase
+}
+
+func NewTimeOffWatcher(members *MemberUseCase) *TimeOffWatcher {
+	return &TimeOffWatcher{members: members}
+}
+
+// Run announces time off transitions every interval until ctx is cancelled
+func (w *TimeOffWatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.Announce(ctx); err != nil {
+			logger.Log.Error("Failed to announce time off changes", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Announce handles every window that has started or ended since the last pass
+// and returns how many transitions it processed. Starts are handled before ends
+// so a window that began and finished between passes is reported in order.
+// A transition is only emitted when it changes whether the member can take
+// work: not for members marked unavailable, and not while another window
+// keeps them out of office. Each transition is claimed before it is emitted,
+// so it is announced at most once across replicas.
+func (w *TimeOffWatcher) Announce(ctx context.Context) (int, error) {
+	now := time.Now()
+	repo := w.members.timeOffRepo
+
+	starts, err := repo.GetStartsToNotify(ctx, now, timeOffBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	processed := 0
+	for _, window := range starts {
+		claimed, err := repo.MarkStartNotified(ctx, window.ID)
+		if err != nil {
+			return processed, err
+		}
+		if !claimed {
+			continue
+		}
+		// Whether the member was already out just before this window began
+		if err := w.announce(ctx, window, window.StartsAt.Add(-time.Nanosecond), false); err != nil {
+			return processed, err
+		}
+		processed++
+	}
+
+	ends, err := repo.GetEndsToNotify(ctx, now, timeOffBatchSize)
+	if err != nil {
+		return processed, err
+	}
+	for _, window := range ends {
+		claimed, err := repo.MarkEndNotified(ctx, window.ID)
+		if err != nil {
+			return processed, err
+		}
+		if !claimed {
+			continue
+		}
+		// Whether another window still covers the member once this one is over
+		if err := w.announce(ctx, window, window.EndsAt, true); err != nil {
+			return processed, err
+		}
+		processed++
+	}
+
+	return processed, nil
+}
+
+// announce emits the transition unless the member was out of office anyway at
+// the instant checked
+func (w *TimeOffWatcher) announce(ctx context.Context, window *domain.TimeOffWindow, check time.Time, available bool) error {
+	member, err := w.members.findMember(ctx, window.MemberID)
+	if errors.Is(err, ErrMemberNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !member.Available {
+		return nil
+	}
+
+	out, err := w.members.outOfOffice(ctx, member, check)
+	if err != nil {
+		return err
+	}
+	if !out {
+		w.members.emitTimeOffAvailability(ctx, member, window, available)
+	}
+	return nil
+}
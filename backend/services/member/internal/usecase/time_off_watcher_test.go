@@ -0,0 +1,166 @@
+package usecase_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/raufhm/fairflow/services/member/internal/usecase"
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memberRepo serves members by ID
+type memberRepo struct {
+	domain.MemberRepository
+	members map[int64]*domain.Member
+}
+
+func (r memberRepo) GetByID(ctx context.Context, id int64) (*domain.Member, error) {
+	return r.members[id], nil
+}
+
+// timeOffRepo keeps time off windows in memory
+type timeOffRepo struct {
+	domain.TimeOffRepository
+	mu      sync.Mutex
+	windows []*domain.TimeOffWindow
+}
+
+func (r *timeOffRepo) GetOutOfOfficeMemberIDs(ctx context.Context, memberIDs []int64, at time.Time) (map[int64]bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	outOfOffice := make(map[int64]bool)
+	for _, w := range r.windows {
+		if w.Covers(at) {
+			outOfOffice[w.MemberID] = true
+		}
+	}
+	return outOfOffice, nil
+}
+
+func (r *timeOffRepo) GetStartsToNotify(ctx context.Context, at time.Time, limit int) ([]*domain.TimeOffWindow, error) {
+	return r.pending(func(w *domain.TimeOffWindow) bool { return !w.StartsAt.After(at) && w.StartNotifiedAt == nil }), nil
+}
+
+func (r *timeOffRepo) GetEndsToNotify(ctx context.Context, at time.Time, limit int) ([]*domain.TimeOffWindow, error) {
+	return r.pending(func(w *domain.TimeOffWindow) bool { return !w.EndsAt.After(at) && w.EndNotifiedAt == nil }), nil
+}
+
+func (r *timeOffRepo) MarkStartNotified(ctx context.Context, id int64) (bool, error) {
+	return r.mark(id, func(w *domain.TimeOffWindow) **time.Time { return &w.StartNotifiedAt }), nil
+}
+
+func (r *timeOffRepo) MarkEndNotified(ctx context.Context, id int64) (bool, error) {
+	return r.mark(id, func(w *domain.TimeOffWindow) **time.Time { return &w.EndNotifiedAt }), nil
+}
+
+func (r *timeOffRepo) pending(match func(w *domain.TimeOffWindow) bool) []*domain.TimeOffWindow {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var windows []*domain.TimeOffWindow
+	for _, w := range r.windows {
+		if match(w) {
+			copied := *w
+			windows = append(windows, &copied)
+		}
+	}
+	return windows
+}
+
+func (r *timeOffRepo) mark(id int64, field func(w *domain.TimeOffWindow) **time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, w := range r.windows {
+		if w.ID == id && *field(w) == nil {
+			now := time.Now()
+			*field(w) = &now
+			return true
+		}
+	}
+	return false
+}
+
+// webhookRepo subscribes one webhook per group to availability changes
+type webhookRepo struct {
+	domain.WebhookRepository
+	url string
+}
+
+func (r webhookRepo) GetActiveByGroupID(ctx context.Context, groupID int64) ([]*domain.Webhook, error) {
+	return []*domain.Webhook{{ID: groupID, GroupID: groupID, URL: r.url, Events: []string{webhook.EventMemberAvailabilityChanged}, Active: true}}, nil
+}
+
+// receiver collects the webhook events delivered to it
+func receiver(t *testing.T) (*httptest.Server, <-chan webhook.Event) {
+	events := make(chan webhook.Event, 16)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event webhook.Event
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		events <- event
+	}))
+	t.Cleanup(server.Close)
+	return server, events
+}
+
+func TestTimeOffWatcher_AnnouncesStartsAndEnds(t *testing.T) {
+	now := time.Now()
+	ago := func(m int) time.Time { return now.Add(-time.Duration(m) * time.Minute) }
+	later := func(m int) time.Time { return now.Add(time.Duration(m) * time.Minute) }
+	announced := ago(30)
+
+	members := memberRepo{members: map[int64]*domain.Member{
+		1: {ID: 1, GroupID: 1, Active: true, Available: true},
+		2: {ID: 2, GroupID: 2, Active: true, Available: true},
+		3: {ID: 3, GroupID: 3, Active: true, Available: true},
+		4: {ID: 4, GroupID: 4, Active: true, Available: false},
+	}}
+	windows := &timeOffRepo{windows: []*domain.TimeOffWindow{
+		// Member 1 goes on leave
+		{ID: 1, MemberID: 1, StartsAt: ago(10), EndsAt: later(60)},
+		// Member 2 comes back
+		{ID: 2, MemberID: 2, StartsAt: ago(60), EndsAt: ago(5), StartNotifiedAt: &announced},
+		// Member 3's leave ends but a back-to-back window keeps them out
+		{ID: 3, MemberID: 3, StartsAt: ago(60), EndsAt: ago(1), StartNotifiedAt: &announced},
+		{ID: 4, MemberID: 3, StartsAt: ago(1), EndsAt: later(60)},
+		// Member 4 is marked unavailable regardless of time off
+		{ID: 5, MemberID: 4, StartsAt: ago(10), EndsAt: later(60)},
+	}}
+
+	server, events := receiver(t)
+	uc := usecase.NewMemberUseCase(members, nil, windows, webhook.NewNotifier(webhookRepo{url: server.URL}))
+	watcher := usecase.NewTimeOffWatcher(uc)
+
+	processed, err := watcher.Announce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 5, processed)
+
+	got := make(map[float64]bool)
+	for i := 0; i < 2; i++ {
+		select {
+		case event := <-events:
+			assert.Equal(t, webhook.EventMemberAvailabilityChanged, event.Type)
+			got[event.Data["member_id"].(float64)] = event.Data["available"].(bool)
+		case <-time.After(2 * time.Second):
+			t.Fatal("expected an availability event")
+		}
+	}
+	assert.Equal(t, map[float64]bool{1: false, 2: true}, got)
+
+	// Each transition is announced once
+	processed, err = watcher.Announce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, processed)
+
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected event for member %v", event.Data["member_id"])
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -12,8 +14,11 @@ import (
 	"github.com/raufhm/fairflow/services/webhook/internal/handler"
 	"github.com/raufhm/fairflow/services/webhook/internal/usecase"
 	"github.com/raufhm/fairflow/shared/config"
+	"github.com/raufhm/fairflow/shared/crypto"
 	"github.com/raufhm/fairflow/shared/database"
+	"github.com/raufhm/fairflow/shared/domain"
 	"github.com/raufhm/fairflow/shared/health"
+	"github.com/raufhm/fairflow/shared/httpclient"
 	"github.com/raufhm/fairflow/shared/logger"
 	"github.com/raufhm/fairflow/shared/middleware"
 	"github.com/raufhm/fairflow/shared/repository/postgres"
@@ -41,8 +46,14 @@ func main() {
 
 	logger.Log.Info("Database connected successfully")
 
-	// Initialize repository
+	// Initialize repositories
 	webhookRepo := postgres.NewWebhookRepository(db)
+	groupRepo := postgres.NewGroupRepository(db)
+	userRepo := postgres.NewUserRepository(db)
+	apiKeyRepo := postgres.NewAPIKeyRepository(db)
+	sessionRepo := postgres.NewSessionRepository(db)
+	auditRepo := postgres.NewAuditLogRepository(db)
+	grantRepo := postgres.NewGroupGrantRepository(db)
 
 	// Initialize use case
 	webhookUseCase := usecase.NewWebhookUseCase(webhookRepo)
@@ -50,6 +61,19 @@ func main() {
 	// Initialize handler
 	webhookHandler := handler.NewWebhookHandler(webhookUseCase)
 
+	// Calls to other services are signed with this service's identity
+	serviceTokens, err := crypto.LoadServiceTokenService(crypto.WebhookService, cfg.ServicePrivateKey, cfg.ServicePublicKeys, cfg.ServiceDevSecret, cfg.ServiceTokenTTL)
+	if err != nil {
+		logger.Log.Fatal("Failed to load service keys", zap.Error(err))
+	}
+	authClient := httpclient.NewServiceClient(cfg.AuthServiceURL, crypto.AuthService).WithServiceAuth(serviceTokens)
+
+	// Initialize authentication middleware, verifying tokens against the auth service's JWKS
+	jwksCache := httpclient.NewJWKSCache(authClient, cfg.JWKSCacheTTL)
+	tokenService := crypto.NewVerifyingTokenService(jwksCache)
+	authenticator := middleware.NewAuthenticator(userRepo, apiKeyRepo, sessionRepo, auditRepo, tokenService, cfg.JWTSecret)
+	groupAuthorizer := middleware.NewGroupAuthorizer(groupRepo, grantRepo)
+
 	// Setup HTTP router
 	mux := http.NewServeMux()
 
@@ -57,8 +81,45 @@ func main() {
 	healthChecker := health.NewHealthChecker(db)
 	mux.HandleFunc("/health", healthChecker.Handler("webhook-service", "1.0.0"))
 
+	// API key restrictions
+	groupWebhooksPolicy := middleware.APIKeyPolicy{
+		ReadScope:  domain.ScopeWebhooksManage,
+		WriteScope: domain.ScopeWebhooksManage,
+		GroupID:    middleware.GroupIDFromPath("/api/v1/groups/"),
+	}
+	webhookPolicy := middleware.APIKeyPolicy{
+		ReadScope:  domain.ScopeWebhooksManage,
+		WriteScope: domain.ScopeWebhooksManage,
+		GroupID: func(r *http.Request) (int64, error) {
+			webhookID := middleware.PathID(r, "/api/v1/webhooks/")
+			if webhookID == 0 {
+				return 0, nil
+			}
+			webhook, err := webhookRepo.GetByID(r.Context(), webhookID)
+			if errors.Is(err, sql.ErrNoRows) {
+				return 0, nil
+			}
+			if err != nil {
+				return 0, err
+			}
+			return webhook.GroupID, nil
+		},
+	}
+
+	// Group roles needed on each route
+	groupWebhooksAccess := middleware.GroupAccessPolicy{
+		Read:    domain.GroupRoleManager,
+		Write:   domain.GroupRoleManager,
+		GroupID: groupWebhooksPolicy.GroupID,
+	}
+	webhookAccess := middleware.GroupAccessPolicy{
+		Read:    domain.GroupRoleManager,
+		Write:   domain.GroupRoleManager,
+		GroupID: webhookPolicy.GroupID,
+	}
+
 	// Webhook endpoints
-	mux.HandleFunc("/api/v1/groups/", func(w http.ResponseWriter, r *http.Request) {
+	mux.Handle("/api/v1/groups/", authenticator.Authenticate(middleware.RequireVerifiedEmail(middleware.EnforceAPIKeyPolicy(groupWebhooksPolicy, groupAuthorizer.Enforce(groupWebhooksAccess, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodGet {
 			webhookHandler.GetWebhooks(w, r)
 		} else if r.Method == http.MethodPost {
@@ -66,18 +127,24 @@ func main() {
 		} else {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
-	})
+	}))))))
 
-	mux.HandleFunc("/api/v1/webhooks/", func(w http.ResponseWriter, r *http.Request) {
+	mux.Handle("/api/v1/webhooks/", authenticator.Authenticate(middleware.RequireVerifiedEmail(middleware.EnforceAPIKeyPolicy(webhookPolicy, groupAuthorizer.Enforce(webhookAccess, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodDelete {
 			webhookHandler.DeleteWebhook(w, r)
 		} else {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
-	})
+	}))))))
+
+	// Client addresses come from X-Forwarded-For only behind trusted proxies
+	trustedProxies, err := middleware.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		logger.Log.Fatal("Invalid TRUSTED_PROXIES", zap.Error(err))
+	}
 
 	// Apply middleware
-	handlerWithMiddleware := middleware.CORS(mux)
+	handlerWithMiddleware := middleware.CORS(middleware.ResolveClientIP(trustedProxies, mux))
 
 	// Start HTTP server
 	port := 3005
@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -12,7 +14,69 @@ type Config struct {
 	Port int
 
 	// Security
-	JWTSecret string
+	JWTSecret       string // HMAC secret for API key and refresh token hashes
+	JWTKeysDir      string // Directory of PEM signing keys (auth service only)
+	JWTSigningKeyID string // kid of the key used to sign new tokens
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	JWKSCacheTTL    time.Duration
+	// Auth service only: encrypts TOTP seeds and sealed SSO state. Unlike
+	// JWTSecret it is never given to the other services.
+	SecretEncryptionKey string
+
+	// API keys
+	APIKeyRotationGrace time.Duration // How long a rotated key keeps working
+	APIKeyExpiryWarning time.Duration // How far ahead owners are warned about expiring keys
+
+	// Two-factor authentication
+	MFARequiredRoles []string // Roles that must enrol a second factor
+	MFAChallengeTTL  time.Duration
+
+	// Support tooling
+	ImpersonationTTL time.Duration // Lifetime of super admin impersonation tokens
+
+	// Brute-force protection
+	LoginLockoutThreshold   int // Failed logins before an account is locked
+	LoginIPLockoutThreshold int // Failed logins before a client IP is locked
+	LoginLockoutDuration    time.Duration
+
+	// Single sign-on (OpenID Connect). Enabled when OIDCIssuerURL is set.
+	OIDCIssuerURL    string
+	OIDCClientID     string
+	OIDCClientSecret string
+	OIDCRedirectURL  string // Must point at /api/v1/auth/oidc/callback on the auth service
+	OIDCScopes       []string
+	OIDCGroupsClaim  string
+	OIDCRoleMapping  map[string]string // IdP group => FairFlow role, at most org_admin
+	// Organization users join on their first SSO login; without it they need an invitation
+	OIDCOrganizationID int64
+
+	// Proxies whose X-Forwarded-For headers are believed, as IPs or CIDR ranges
+	TrustedProxies []string
+
+	// Services
+	AuthServiceURL    string
+	AppBaseURL        string            // Public URL of the web app, used in email links
+	ServicePrivateKey string            // This service's Ed25519 seed for signing service tokens, base64
+	ServicePublicKeys map[string]string // Calling service => its Ed25519 public key, base64
+	ServiceDevSecret  string            // Development only: derives every service's keys when none are configured
+	ServiceTokenTTL   time.Duration
+
+	// Email
+	SMTPHost         string
+	SMTPPort         int
+	SMTPUsername     string
+	SMTPPassword     string
+	MailFrom         string
+	MailDir          string // Where development emails are written when SMTP is not configured
+	PasswordResetTTL time.Duration
+
+	// Onboarding
+	InvitationTTL        time.Duration
+	EmailVerificationTTL time.Duration
+
+	// Assignments
+	IdempotencyKeyTTL time.Duration // How long retries of POST /assign are answered from the first result
 
 	// Database
 	DatabaseURL string
@@ -48,13 +112,35 @@ func Load() *Config {
 	viper.SetDefault("ENVIRONMENT", "development")
 	viper.SetDefault("LOG_LEVEL", "info")
 	viper.SetDefault("DATA_DIR", "./data")
+	viper.SetDefault("ACCESS_TOKEN_TTL", "15m")
+	viper.SetDefault("REFRESH_TOKEN_TTL", "720h")
+	viper.SetDefault("JWKS_CACHE_TTL", "10m")
+	viper.SetDefault("API_KEY_ROTATION_GRACE", "24h")
+	viper.SetDefault("API_KEY_EXPIRY_WARNING", "168h")
+	viper.SetDefault("MFA_REQUIRED_ROLES", "admin,org_admin,super_admin")
+	viper.SetDefault("MFA_CHALLENGE_TTL", "5m")
+	viper.SetDefault("IMPERSONATION_TTL", "15m")
+	viper.SetDefault("LOGIN_LOCKOUT_THRESHOLD", 10)
+	viper.SetDefault("LOGIN_IP_LOCKOUT_THRESHOLD", 50)
+	viper.SetDefault("LOGIN_LOCKOUT_DURATION", "15m")
+	viper.SetDefault("OIDC_SCOPES", "openid,email,profile")
+	viper.SetDefault("OIDC_GROUPS_CLAIM", "groups")
+	viper.SetDefault("AUTH_SERVICE_URL", "http://auth-service:3001")
+	viper.SetDefault("APP_BASE_URL", "http://localhost:3000")
+	viper.SetDefault("SERVICE_TOKEN_TTL", "1m")
+	viper.SetDefault("SMTP_PORT", 587)
+	viper.SetDefault("MAIL_FROM", "FairFlow <no-reply@fairflow.io>")
+	viper.SetDefault("PASSWORD_RESET_TTL", "1h")
+	viper.SetDefault("INVITATION_TTL", "168h")
+	viper.SetDefault("EMAIL_VERIFICATION_TTL", "48h")
+	viper.SetDefault("IDEMPOTENCY_KEY_TTL", "24h")
 
 	// Configure Viper for .env file loading
 	viper.SetConfigType("env")
 	viper.SetConfigName(".env")
-	viper.AddConfigPath(".")                // Look in current directory
-	viper.AddConfigPath("..")               // Look in parent directory (for backend/)
-	viper.AddConfigPath("../..")            // Look two levels up
+	viper.AddConfigPath(".")     // Look in current directory
+	viper.AddConfigPath("..")    // Look in parent directory (for backend/)
+	viper.AddConfigPath("../..") // Look two levels up
 
 	// Try to load .env file (optional for local development)
 	if err := viper.ReadInConfig(); err != nil {
@@ -65,13 +151,57 @@ func Load() *Config {
 
 	// Build config struct
 	cfg := &Config{
-		Port:        viper.GetInt("PORT"),
-		Environment: viper.GetString("ENVIRONMENT"),
-		LogLevel:    viper.GetString("LOG_LEVEL"),
-		JWTSecret:   viper.GetString("JWT_SECRET"),
-		DatabaseURL: viper.GetString("DATABASE_URL"),
-		RabbitMQURL: viper.GetString("RABBITMQ_URL"),
-		DataDir:     viper.GetString("DATA_DIR"),
+		Port:                    viper.GetInt("PORT"),
+		Environment:             viper.GetString("ENVIRONMENT"),
+		LogLevel:                viper.GetString("LOG_LEVEL"),
+		JWTSecret:               viper.GetString("JWT_SECRET"),
+		JWTKeysDir:              viper.GetString("JWT_KEYS_DIR"),
+		JWTSigningKeyID:         viper.GetString("JWT_SIGNING_KID"),
+		SecretEncryptionKey:     viper.GetString("SECRET_ENCRYPTION_KEY"),
+		AccessTokenTTL:          viper.GetDuration("ACCESS_TOKEN_TTL"),
+		RefreshTokenTTL:         viper.GetDuration("REFRESH_TOKEN_TTL"),
+		JWKSCacheTTL:            viper.GetDuration("JWKS_CACHE_TTL"),
+		APIKeyRotationGrace:     viper.GetDuration("API_KEY_ROTATION_GRACE"),
+		APIKeyExpiryWarning:     viper.GetDuration("API_KEY_EXPIRY_WARNING"),
+		MFARequiredRoles:        splitList(viper.GetString("MFA_REQUIRED_ROLES")),
+		MFAChallengeTTL:         viper.GetDuration("MFA_CHALLENGE_TTL"),
+		ImpersonationTTL:        viper.GetDuration("IMPERSONATION_TTL"),
+		LoginLockoutThreshold:   viper.GetInt("LOGIN_LOCKOUT_THRESHOLD"),
+		LoginIPLockoutThreshold: viper.GetInt("LOGIN_IP_LOCKOUT_THRESHOLD"),
+		LoginLockoutDuration:    viper.GetDuration("LOGIN_LOCKOUT_DURATION"),
+		OIDCIssuerURL:           viper.GetString("OIDC_ISSUER_URL"),
+		OIDCClientID:            viper.GetString("OIDC_CLIENT_ID"),
+		OIDCClientSecret:        viper.GetString("OIDC_CLIENT_SECRET"),
+		OIDCRedirectURL:         viper.GetString("OIDC_REDIRECT_URL"),
+		OIDCScopes:              splitList(viper.GetString("OIDC_SCOPES")),
+		OIDCGroupsClaim:         viper.GetString("OIDC_GROUPS_CLAIM"),
+		OIDCRoleMapping:         splitPairs(viper.GetString("OIDC_ROLE_MAPPING")),
+		OIDCOrganizationID:      viper.GetInt64("OIDC_ORGANIZATION_ID"),
+		TrustedProxies:          splitList(viper.GetString("TRUSTED_PROXIES")),
+		AuthServiceURL:          viper.GetString("AUTH_SERVICE_URL"),
+		AppBaseURL:              viper.GetString("APP_BASE_URL"),
+		ServicePrivateKey:       viper.GetString("SERVICE_PRIVATE_KEY"),
+		ServicePublicKeys:       splitPairs(viper.GetString("SERVICE_PUBLIC_KEYS")),
+		ServiceTokenTTL:         viper.GetDuration("SERVICE_TOKEN_TTL"),
+		SMTPHost:                viper.GetString("SMTP_HOST"),
+		SMTPPort:                viper.GetInt("SMTP_PORT"),
+		SMTPUsername:            viper.GetString("SMTP_USERNAME"),
+		SMTPPassword:            viper.GetString("SMTP_PASSWORD"),
+		MailFrom:                viper.GetString("MAIL_FROM"),
+		PasswordResetTTL:        viper.GetDuration("PASSWORD_RESET_TTL"),
+		InvitationTTL:           viper.GetDuration("INVITATION_TTL"),
+		EmailVerificationTTL:    viper.GetDuration("EMAIL_VERIFICATION_TTL"),
+		IdempotencyKeyTTL:       viper.GetDuration("IDEMPOTENCY_KEY_TTL"),
+		DatabaseURL:             viper.GetString("DATABASE_URL"),
+		RabbitMQURL:             viper.GetString("RABBITMQ_URL"),
+		DataDir:                 viper.GetString("DATA_DIR"),
+	}
+
+	// Set mail directory with fallback
+	if mailDir := viper.GetString("MAIL_DIR"); mailDir != "" {
+		cfg.MailDir = mailDir
+	} else {
+		cfg.MailDir = cfg.DataDir + "/mail"
 	}
 
 	// Set backup directory with fallback
@@ -86,6 +216,14 @@ func Load() *Config {
 		panic("JWT_SECRET is required. Set it in .env.development or as environment variable")
 	}
 
+	// Development setups may derive every service's keys from the JWT secret
+	if cfg.ServicePrivateKey == "" {
+		if cfg.Environment != "development" {
+			panic("SERVICE_PRIVATE_KEY is required outside development")
+		}
+		cfg.ServiceDevSecret = cfg.JWTSecret
+	}
+
 	if cfg.DatabaseURL == "" {
 		panic("DATABASE_URL is required. Set it in .env.development or as environment variable")
 	}
@@ -119,8 +257,36 @@ func (c *Config) Validate() error {
 	if c.DatabaseURL == "" {
 		return fmt.Errorf("DATABASE_URL is required")
 	}
+	if c.AccessTokenTTL <= 0 || c.RefreshTokenTTL <= 0 {
+		return fmt.Errorf("ACCESS_TOKEN_TTL and REFRESH_TOKEN_TTL must be positive durations")
+	}
+	if c.OIDCIssuerURL != "" && (c.OIDCClientID == "" || c.OIDCRedirectURL == "") {
+		return fmt.Errorf("OIDC_CLIENT_ID and OIDC_REDIRECT_URL are required when OIDC_ISSUER_URL is set")
+	}
 	if c.Port <= 0 || c.Port > 65535 {
 		return fmt.Errorf("PORT must be between 1 and 65535")
 	}
 	return nil
 }
+
+// splitList parses a comma-separated setting, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// splitPairs parses a comma-separated list of key=value settings
+func splitPairs(value string) map[string]string {
+	pairs := make(map[string]string)
+	for _, item := range splitList(value) {
+		if key, val, ok := strings.Cut(item, "="); ok {
+			pairs[strings.TrimSpace(key)] = strings.TrimSpace(val)
+		}
+	}
+	return pairs
+}
@@ -0,0 +1,110 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// JWK is a single public key in JSON Web Key format
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set as served from /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// newJWK encodes a public key as a JWK
+func newJWK(kid, alg string, public interface{}) JWK {
+	jwk := JWK{KeyID: kid, Algorithm: alg, Use: "sig"}
+
+	switch key := public.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(key)
+	}
+
+	return jwk
+}
+
+// ParseJWKS decodes a JSON Web Key Set into public keys indexed by kid
+func ParseJWKS(data []byte) (map[string]interface{}, error) {
+	var set JWKS
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		switch jwk.KeyType {
+		case "RSA":
+			n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+			if err != nil {
+				return nil, fmt.Errorf("invalid modulus for key %s: %w", jwk.KeyID, err)
+			}
+			e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+			if err != nil {
+				return nil, fmt.Errorf("invalid exponent for key %s: %w", jwk.KeyID, err)
+			}
+			keys[jwk.KeyID] = &rsa.PublicKey{
+				N: new(big.Int).SetBytes(n),
+				E: int(new(big.Int).SetBytes(e).Int64()),
+			}
+		case "OKP":
+			if jwk.Curve != "Ed25519" {
+				continue
+			}
+			x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+			if err != nil || len(x) != ed25519.PublicKeySize {
+				return nil, fmt.Errorf("invalid Ed25519 key %s", jwk.KeyID)
+			}
+			keys[jwk.KeyID] = ed25519.PublicKey(x)
+		case "EC":
+			var curve elliptic.Curve
+			switch jwk.Curve {
+			case "P-256":
+				curve = elliptic.P256()
+			case "P-384":
+				curve = elliptic.P384()
+			default:
+				continue
+			}
+			x, errX := base64.RawURLEncoding.DecodeString(jwk.X)
+			y, errY := base64.RawURLEncoding.DecodeString(jwk.Y)
+			if errX != nil || errY != nil {
+				return nil, fmt.Errorf("invalid EC key %s", jwk.KeyID)
+			}
+			keys[jwk.KeyID] = &ecdsa.PublicKey{
+				Curve: curve,
+				X:     new(big.Int).SetBytes(x),
+				Y:     new(big.Int).SetBytes(y),
+			}
+		}
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,167 @@
+package crypto
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// SigningKey is a private key used to sign access tokens, identified by its kid
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	signer    crypto.Signer
+}
+
+// Public returns the public half of the key
+func (k *SigningKey) Public() crypto.PublicKey {
+	return k.signer.Public()
+}
+
+// KeySet holds every key that is valid for verification and the one used for signing.
+// Keys are rotated by adding a new key, switching the signing kid, and removing the
+// old key once the tokens it signed have expired.
+type KeySet struct {
+	keys    map[string]*SigningKey
+	signing *SigningKey
+}
+
+// LoadKeySet loads PEM encoded RSA or Ed25519 private keys from dir. Each file
+// "<kid>.pem" becomes a key with that kid. If signingKID is empty, the
+// lexicographically last kid signs new tokens.
+func LoadKeySet(dir, signingKID string) (*KeySet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no signing keys found in %s", dir)
+	}
+	sort.Strings(paths)
+
+	ks := &KeySet{keys: make(map[string]*SigningKey)}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read signing key %s: %w", path, err)
+		}
+
+		kid := strings.TrimSuffix(filepath.Base(path), ".pem")
+		key, err := parseSigningKey(kid, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse signing key %s: %w", path, err)
+		}
+		ks.keys[kid] = key
+		ks.signing = key
+	}
+
+	if signingKID != "" {
+		key, ok := ks.keys[signingKID]
+		if !ok {
+			return nil, fmt.Errorf("signing key %q not found in %s", signingKID, dir)
+		}
+		ks.signing = key
+	}
+
+	return ks, nil
+}
+
+// NewEphemeralKeySet generates a single in-memory Ed25519 key.
+// Tokens signed with it do not survive a restart, so it is meant for local development only.
+func NewEphemeralKeySet() (*KeySet, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+
+	kidBytes := make([]byte, 8)
+	if _, err := rand.Read(kidBytes); err != nil {
+		return nil, fmt.Errorf("failed to generate key id: %w", err)
+	}
+
+	key := &SigningKey{
+		ID:        "dev-" + hex.EncodeToString(kidBytes),
+		Algorithm: AlgorithmEdDSA,
+		signer:    private,
+	}
+
+	return &KeySet{
+		keys:    map[string]*SigningKey{key.ID: key},
+		signing: key,
+	}, nil
+}
+
+// SigningKey returns the key used to sign new tokens
+func (ks *KeySet) SigningKey() *SigningKey {
+	return ks.signing
+}
+
+// PublicKey returns the verification key for a kid
+func (ks *KeySet) PublicKey(kid string) (interface{}, error) {
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id: %s", kid)
+	}
+	return key.Public(), nil
+}
+
+// JWKS returns the public keys of the set as a JSON Web Key Set
+func (ks *KeySet) JWKS() JWKS {
+	kids := make([]string, 0, len(ks.keys))
+	for kid := range ks.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	set := JWKS{Keys: make([]JWK, 0, len(kids))}
+	for _, kid := range kids {
+		key := ks.keys[kid]
+		set.Keys = append(set.Keys, newJWK(key.ID, key.Algorithm, key.Public()))
+	}
+	return set
+}
+
+// parseSigningKey decodes a PKCS#8 or PKCS#1 PEM private key
+func parseSigningKey(kid string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+
+	var parsed interface{}
+	var err error
+	if block.Type == "RSA PRIVATE KEY" {
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	} else {
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch private := parsed.(type) {
+	case *rsa.PrivateKey:
+		if private.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("RSA keys must be at least 2048 bits")
+		}
+		return &SigningKey{ID: kid, Algorithm: AlgorithmRS256, signer: private}, nil
+	case ed25519.PrivateKey:
+		return &SigningKey{ID: kid, Algorithm: AlgorithmEdDSA, signer: private}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+}
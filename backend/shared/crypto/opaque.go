@@ -0,0 +1,25 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// GenerateOpaqueToken generates a random, URL-safe token with the given prefix
+func GenerateOpaqueToken(prefix string) (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return prefix + hex.EncodeToString(bytes), nil
+}
+
+// HashToken hashes an opaque token using HMAC-SHA256 so only the hash is stored
+func HashToken(token, secret string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(token))
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,133 @@
+package crypto_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/raufhm/fairflow/shared/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newServiceKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return public, private
+}
+
+func TestServiceTokenService_IssueAndVerify(t *testing.T) {
+	groupPublic, groupPrivate := newServiceKey(t)
+	_, authPrivate := newServiceKey(t)
+
+	caller := crypto.NewServiceTokenService(crypto.GroupService, groupPrivate, nil, time.Minute)
+	auth := crypto.NewServiceTokenService(crypto.AuthService, authPrivate, map[string]ed25519.PublicKey{
+		crypto.GroupService: groupPublic,
+	}, time.Minute)
+
+	token, err := caller.Generate(crypto.AuthService)
+	require.NoError(t, err)
+
+	claims, err := auth.Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, crypto.GroupService, claims.Service())
+}
+
+func TestServiceTokenService_RejectsWrongAudience(t *testing.T) {
+	groupPublic, groupPrivate := newServiceKey(t)
+	_, memberPrivate := newServiceKey(t)
+
+	caller := crypto.NewServiceTokenService(crypto.GroupService, groupPrivate, nil, time.Minute)
+	member := crypto.NewServiceTokenService(crypto.MemberService, memberPrivate, map[string]ed25519.PublicKey{
+		crypto.GroupService: groupPublic,
+	}, time.Minute)
+
+	token, err := caller.Generate(crypto.AuthService)
+	require.NoError(t, err)
+
+	_, err = member.Verify(token)
+	assert.ErrorIs(t, err, jwt.ErrTokenInvalidAudience)
+}
+
+func TestServiceTokenService_RejectsExpiredToken(t *testing.T) {
+	groupPublic, groupPrivate := newServiceKey(t)
+	_, authPrivate := newServiceKey(t)
+
+	auth := crypto.NewServiceTokenService(crypto.AuthService, authPrivate, map[string]ed25519.PublicKey{
+		crypto.GroupService: groupPublic,
+	}, time.Minute)
+
+	issuedAt := time.Now().Add(-time.Hour)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodEdDSA, crypto.ServiceClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    crypto.GroupService,
+			Audience:  jwt.ClaimStrings{crypto.AuthService},
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(issuedAt.Add(time.Minute)),
+		},
+	}).SignedString(groupPrivate)
+	require.NoError(t, err)
+
+	_, err = auth.Verify(token)
+	assert.ErrorIs(t, err, jwt.ErrTokenExpired)
+}
+
+func TestServiceTokenService_RejectsForgedIssuer(t *testing.T) {
+	groupPublic, _ := newServiceKey(t)
+	memberPublic, memberPrivate := newServiceKey(t)
+	_, authPrivate := newServiceKey(t)
+
+	auth := crypto.NewServiceTokenService(crypto.AuthService, authPrivate, map[string]ed25519.PublicKey{
+		crypto.GroupService:  groupPublic,
+		crypto.MemberService: memberPublic,
+	}, time.Minute)
+
+	// The member service signs with its own key but claims to be the group service
+	impostor := crypto.NewServiceTokenService(crypto.GroupService, memberPrivate, nil, time.Minute)
+	token, err := impostor.Generate(crypto.AuthService)
+	require.NoError(t, err)
+
+	_, err = auth.Verify(token)
+	assert.ErrorIs(t, err, jwt.ErrTokenSignatureInvalid)
+}
+
+func TestServiceTokenService_RejectsUnknownService(t *testing.T) {
+	_, webhookPrivate := newServiceKey(t)
+	_, authPrivate := newServiceKey(t)
+
+	auth := crypto.NewServiceTokenService(crypto.AuthService, authPrivate, map[string]ed25519.PublicKey{}, time.Minute)
+	caller := crypto.NewServiceTokenService(crypto.WebhookService, webhookPrivate, nil, time.Minute)
+
+	token, err := caller.Generate(crypto.AuthService)
+	require.NoError(t, err)
+
+	_, err = auth.Verify(token)
+	assert.Error(t, err)
+}
+
+func TestLoadServiceTokenService_ConfiguredKeys(t *testing.T) {
+	groupPublic, groupPrivate := newServiceKey(t)
+	_, authPrivate := newServiceKey(t)
+	encode := base64.StdEncoding.EncodeToString
+
+	caller, err := crypto.LoadServiceTokenService(crypto.GroupService, encode(groupPrivate.Seed()), nil, "", time.Minute)
+	require.NoError(t, err)
+	auth, err := crypto.LoadServiceTokenService(crypto.AuthService, encode(authPrivate.Seed()), map[string]string{
+		crypto.GroupService: encode(groupPublic),
+	}, "", time.Minute)
+	require.NoError(t, err)
+
+	token, err := caller.Generate(crypto.AuthService)
+	require.NoError(t, err)
+	claims, err := auth.Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, crypto.GroupService, claims.Service())
+
+	_, err = crypto.LoadServiceTokenService(crypto.AuthService, "", nil, "", time.Minute)
+	assert.Error(t, err)
+	_, err = crypto.LoadServiceTokenService(crypto.AuthService, encode([]byte("short")), nil, "", time.Minute)
+	assert.Error(t, err)
+}
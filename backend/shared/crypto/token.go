@@ -1,55 +1,196 @@
 package crypto
 
 import (
+	"crypto/ed25519"
+	"crypto/rsa"
 	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// DefaultAccessTokenTTL is the lifetime of access tokens when none is configured
+const DefaultAccessTokenTTL = 15 * time.Minute
+
+// PurposeMFAChallenge marks tokens that only prove the password step of a login.
+// They are rejected everywhere an access token is expected.
+const PurposeMFAChallenge = "mfa_challenge"
+
+// KeyResolver looks up the public key that verifies tokens signed with a kid
+type KeyResolver interface {
+	PublicKey(kid string) (interface{}, error)
+}
+
 type TokenService struct {
-	secret string
+	keys      *KeySet
+	resolver  KeyResolver
+	accessTTL time.Duration
 }
 
 type Claims struct {
-	UserID int64 `json:"userId"`
+	UserID         int64  `json:"userId"`
+	SessionID      int64  `json:"sid,omitempty"`
+	Purpose        string `json:"purpose,omitempty"`
+	ImpersonatorID int64  `json:"impersonatorId,omitempty"` // Real actor when UserID is being impersonated
 	jwt.RegisteredClaims
 }
 
-func NewTokenService(secret string) *TokenService {
-	return &TokenService{secret: secret}
+// IsImpersonation reports whether the token lets one user act as another
+func (c *Claims) IsImpersonation() bool {
+	return c.ImpersonatorID != 0
+}
+
+// NewTokenService creates a token service that signs with the key set's signing key
+func NewTokenService(keys *KeySet) *TokenService {
+	return &TokenService{keys: keys, resolver: keys, accessTTL: DefaultAccessTokenTTL}
+}
+
+// NewVerifyingTokenService creates a token service that can only verify tokens,
+// for services that do not hold signing keys
+func NewVerifyingTokenService(resolver KeyResolver) *TokenService {
+	return &TokenService{resolver: resolver, accessTTL: DefaultAccessTokenTTL}
+}
+
+// WithAccessTTL overrides the lifetime of generated access tokens
+func (s *TokenService) WithAccessTTL(ttl time.Duration) *TokenService {
+	if ttl > 0 {
+		s.accessTTL = ttl
+	}
+	return s
+}
+
+// AccessTTL returns the lifetime of generated access tokens
+func (s *TokenService) AccessTTL() time.Duration {
+	return s.accessTTL
+}
+
+// JWKS returns the public verification keys of the service
+func (s *TokenService) JWKS() JWKS {
+	if s.keys == nil {
+		return JWKS{Keys: []JWK{}}
+	}
+	return s.keys.JWKS()
+}
+
+// GenerateToken generates a short-lived JWT access token bound to a session
+func (s *TokenService) GenerateToken(userID, sessionID int64) (string, error) {
+	now := time.Now()
+	return s.sign(Claims{
+		UserID:    userID,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.accessTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	})
 }
 
-// GenerateToken generates a JWT token for a user
-func (s *TokenService) GenerateToken(userID int64) (string, error) {
-	claims := Claims{
-		UserID: userID,
+// GenerateImpersonationToken issues an access token that acts as userID on
+// behalf of impersonatorID. The session belongs to the impersonator.
+func (s *TokenService) GenerateImpersonationToken(userID, impersonatorID, sessionID int64, ttl time.Duration) (string, error) {
+	now := time.Now()
+	return s.sign(Claims{
+		UserID:         userID,
+		SessionID:      sessionID,
+		ImpersonatorID: impersonatorID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(720 * time.Hour)), // 30 days
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
+	})
+}
+
+// GenerateMFAChallengeToken issues a token proving that the user passed the
+// password step of a login and still has to present a second factor
+func (s *TokenService) GenerateMFAChallengeToken(userID int64, ttl time.Duration) (string, error) {
+	now := time.Now()
+	return s.sign(Claims{
+		UserID:  userID,
+		Purpose: PurposeMFAChallenge,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	})
+}
+
+// VerifyToken verifies an access token and returns its claims
+func (s *TokenService) VerifyToken(tokenString string) (*Claims, error) {
+	claims, err := s.parse(tokenString)
+	if err != nil {
+		return nil, err
 	}
+	if claims.Purpose != "" {
+		return nil, fmt.Errorf("token is not an access token")
+	}
+	return claims, nil
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.secret))
+// VerifyMFAChallengeToken verifies a token issued by GenerateMFAChallengeToken
+func (s *TokenService) VerifyMFAChallengeToken(tokenString string) (*Claims, error) {
+	claims, err := s.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != PurposeMFAChallenge {
+		return nil, fmt.Errorf("token is not an MFA challenge token")
+	}
+	return claims, nil
 }
 
-// VerifyToken verifies a JWT token and returns the user ID
-func (s *TokenService) VerifyToken(tokenString string) (int64, error) {
+// sign signs claims with the current signing key
+func (s *TokenService) sign(claims Claims) (string, error) {
+	if s.keys == nil {
+		return "", fmt.Errorf("token service has no signing key")
+	}
+
+	key := s.keys.SigningKey()
+	var method jwt.SigningMethod = jwt.SigningMethodEdDSA
+	if key.Algorithm == AlgorithmRS256 {
+		method = jwt.SigningMethodRS256
+	}
+
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.signer)
+}
+
+// parse verifies a token's signature and expiry and returns its claims
+func (s *TokenService) parse(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, fmt.Errorf("token has no key id")
 		}
-		return []byte(s.secret), nil
-	})
+
+		key, err := s.resolver.PublicKey(kid)
+		if err != nil {
+			return nil, err
+		}
+
+		// The key type must match the algorithm in the header
+		switch key.(type) {
+		case *rsa.PublicKey:
+			if token.Method.Alg() != AlgorithmRS256 {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+		case ed25519.PublicKey:
+			if token.Method.Alg() != AlgorithmEdDSA {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+		default:
+			return nil, fmt.Errorf("unsupported key type for key id %s", kid)
+		}
+		return key, nil
+	}, jwt.WithValidMethods([]string{AlgorithmRS256, AlgorithmEdDSA}))
 
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims.UserID, nil
+		return claims, nil
 	}
 
-	return 0, fmt.Errorf("invalid token")
-}
\ No newline at end of file
+	return nil, fmt.Errorf("invalid token")
+}
@@ -0,0 +1,99 @@
+package crypto_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+// rfc6238Secret is the SHA-1 seed of the RFC 6238 test vectors, "12345678901234567890", in base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidateTOTP_RFC6238Vectors(t *testing.T) {
+	// RFC 6238 Appendix B lists 8 digit codes; 6 digit codes are their last six digits
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, v := range vectors {
+		code := v.code[len(v.code)-crypto.TOTPDigits:]
+
+		step, ok := crypto.ValidateTOTP(rfc6238Secret, code, time.Unix(v.unix, 0))
+
+		assert.True(t, ok, "code %s at %d", code, v.unix)
+		assert.Equal(t, v.unix/30, step)
+	}
+}
+
+func TestValidateTOTP_ClockSkew(t *testing.T) {
+	issuedAt := time.Unix(1111111111, 0)
+	code := "050471"
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want bool
+	}{
+		{"one period late", issuedAt.Add(crypto.TOTPPeriod), true},
+		{"one period early", issuedAt.Add(-crypto.TOTPPeriod), true},
+		{"two periods late", issuedAt.Add(2 * crypto.TOTPPeriod), false},
+		{"two periods early", issuedAt.Add(-2 * crypto.TOTPPeriod), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := crypto.ValidateTOTP(rfc6238Secret, code, tt.at)
+			assert.Equal(t, tt.want, ok)
+			if tt.want {
+				// The matched step is the one the code was issued for, so replays can be spotted
+				assert.Equal(t, issuedAt.Unix()/30, step)
+			}
+		})
+	}
+}
+
+func TestValidateTOTP_Input(t *testing.T) {
+	at := time.Unix(59, 0)
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		want   bool
+	}{
+		{"spaced code", rfc6238Secret, " 287 082 ", true},
+		{"lowercase secret", strings.ToLower(rfc6238Secret), "287082", true},
+		{"wrong code", rfc6238Secret, "287083", false},
+		{"too short", rfc6238Secret, "28708", false},
+		{"eight digits", rfc6238Secret, "94287082", false},
+		{"invalid secret", "not base32!", "287082", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ok := crypto.ValidateTOTP(tt.secret, tt.code, at)
+			assert.Equal(t, tt.want, ok)
+		})
+	}
+}
+
+func TestGenerateTOTPSecret_RoundTrips(t *testing.T) {
+	secret, err := crypto.GenerateTOTPSecret()
+	assert.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	uri := crypto.TOTPProvisioningURI(secret, "FairFlow", "ana@example.com")
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/FairFlow:ana@example.com?"))
+	assert.Contains(t, uri, "secret="+secret)
+}
@@ -5,16 +5,85 @@ import (
 	"time"
 )
 
+// API key scopes
+const (
+	ScopeGroupsRead       = "groups:read"
+	ScopeGroupsWrite      = "groups:write"
+	ScopeMembersRead      = "members:read"
+	ScopeMembersWrite     = "members:write"
+	ScopeAssignmentsRead  = "assignments:read"
+	ScopeAssignmentsWrite = "assignments:write"
+	ScopeWebhooksManage   = "webhooks:manage"
+)
+
+// ValidScopes lists every scope an API key can be granted
+var ValidScopes = []string{
+	ScopeGroupsRead,
+	ScopeGroupsWrite,
+	ScopeMembersRead,
+	ScopeMembersWrite,
+	ScopeAssignmentsRead,
+	ScopeAssignmentsWrite,
+	ScopeWebhooksManage,
+}
+
 // APIKey represents an API key for authentication
 type APIKey struct {
 	ID         int64      `bun:",pk,autoincrement" json:"id"`
 	UserID     int64      `bun:"user_id" json:"user_id"`
 	Name       string     `bun:"name" json:"name"`
 	KeyHash    string     `bun:"key_hash" json:"-"`
+	Scopes     []string   `bun:"scopes,array" json:"scopes"`       // Empty means unrestricted (legacy keys)
+	GroupIDs   []int64    `bun:"group_ids,array" json:"group_ids"` // Empty means every group the owner can access
 	ExpiresAt  *time.Time `bun:"expires_at" json:"expires_at,omitempty"`
 	LastUsedAt *time.Time `bun:"last_used_at" json:"last_used_at,omitempty"`
 	Active     bool       `bun:"active" json:"active"`
+	ReplacesID *int64     `bun:"replaces_id,unique" json:"replaces_id,omitempty"` // Key this one was rotated from; a key has at most one successor
+	RevokedAt  *time.Time `bun:"revoked_at" json:"revoked_at,omitempty"`
 	CreatedAt  time.Time  `bun:"created_at" json:"created_at"`
+
+	ExpiryNotifiedAt *time.Time `bun:"expiry_notified_at" json:"-"`
+}
+
+// IsUsable reports whether the key is active and not expired
+func (k *APIKey) IsUsable() bool {
+	return k.Active && (k.ExpiresAt == nil || time.Now().Before(*k.ExpiresAt))
+}
+
+// HasScope reports whether the key grants a scope
+func (k *APIKey) HasScope(scope string) bool {
+	if len(k.Scopes) == 0 {
+		return true
+	}
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAccessGroup reports whether the key is allowed to act on a group
+func (k *APIKey) CanAccessGroup(groupID int64) bool {
+	if len(k.GroupIDs) == 0 {
+		return true
+	}
+	for _, id := range k.GroupIDs {
+		if id == groupID {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidScope reports whether scope is a known API key scope
+func IsValidScope(scope string) bool {
+	for _, s := range ValidScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
 }
 
 // APIKeyRepository defines the interface for API key data access
@@ -23,6 +92,12 @@ type APIKeyRepository interface {
 	GetByID(ctx context.Context, id int64) (*APIKey, error)
 	GetByHash(ctx context.Context, hash string) (*APIKey, error)
 	GetByUserID(ctx context.Context, userID int64) ([]*APIKey, error)
+	GetByReplacesID(ctx context.Context, id int64) (*APIKey, error) // The key rotated from the given one, or nil
 	Delete(ctx context.Context, id int64) error
+	Deactivate(ctx context.Context, id int64) error
+	DeactivateByUserID(ctx context.Context, userID int64) error
+	UpdateExpiry(ctx context.Context, id int64, expiresAt time.Time) error
 	UpdateLastUsed(ctx context.Context, id int64) error
-}
\ No newline at end of file
+	GetExpiring(ctx context.Context, before time.Time) ([]*APIKey, error) // Active keys expiring before the cutoff whose owner has not been warned
+	MarkExpiryNotified(ctx context.Context, id int64) error
+}
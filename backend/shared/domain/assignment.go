@@ -12,26 +12,66 @@ const (
 	AssignmentStatusOpen      AssignmentStatus = "open"
 	AssignmentStatusCompleted AssignmentStatus = "completed"
 	AssignmentStatusCancelled AssignmentStatus = "cancelled"
+	// AssignmentStatusPendingAcceptance waits for the member to accept or decline
+	AssignmentStatusPendingAcceptance AssignmentStatus = "pending_acceptance"
 )
 
+// assignmentTransitions lists the statuses each status may move to. Closed
+// assignments can only be reopened; nothing moves back to pending acceptance.
+var assignmentTransitions = map[AssignmentStatus][]AssignmentStatus{
+	AssignmentStatusPendingAcceptance: {AssignmentStatusOpen, AssignmentStatusCancelled},
+	AssignmentStatusOpen:              {AssignmentStatusCompleted, AssignmentStatusCancelled},
+	AssignmentStatusCompleted:         {AssignmentStatusOpen},
+	AssignmentStatusCancelled:         {AssignmentStatusOpen},
+}
+
+// IsValid reports whether the status is one of the known statuses
+func (s AssignmentStatus) IsValid() bool {
+	_, ok := assignmentTransitions[s]
+	return ok
+}
+
+// HoldsCapacity reports whether an assignment in this status counts toward
+// the member's open assignments
+func (s AssignmentStatus) HoldsCapacity() bool {
+	return s == AssignmentStatusOpen || s == AssignmentStatusPendingAcceptance
+}
+
+// CanTransitionTo reports whether an assignment may move from s to next
+func (s AssignmentStatus) CanTransitionTo(next AssignmentStatus) bool {
+	for _, allowed := range assignmentTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
 // Assignment represents a recorded assignment
 type Assignment struct {
 	ID          int64            `bun:",pk,autoincrement" json:"id"`
-	GroupID     int64            `bun:"group_id" json:"group_id"`
+	GroupID     int64            `bun:"group_id,unique:group_external_ref" json:"group_id"`
 	MemberID    int64            `bun:"member_id" json:"member_id"`
 	Metadata    *string          `bun:"metadata" json:"metadata,omitempty"`
+	ExternalRef *string          `bun:"external_ref,unique:group_external_ref" json:"external_ref,omitempty"`
 	Status      AssignmentStatus `bun:"status" json:"status"`
+	AcceptBy    *time.Time       `bun:"accept_by" json:"accept_by,omitempty"`
 	CompletedAt *time.Time       `bun:"completed_at" json:"completed_at,omitempty"`
-	CreatedAt   time.Time        `bun:"created_at" json:"created_at"`
+	// ReassignedAt is when the current member was handed the assignment, if it moved
+	ReassignedAt *time.Time `bun:"reassigned_at" json:"reassigned_at,omitempty"`
+	CreatedAt    time.Time  `bun:"created_at" json:"created_at"`
 }
 
 // AssignmentWithMember represents an assignment with member details
 type AssignmentWithMember struct {
-	ID         int64     `json:"id"`
-	MemberID   int64     `json:"member_id"`
-	MemberName string    `json:"member_name"`
-	Metadata   *string   `json:"metadata,omitempty"`
-	CreatedAt  time.Time `json:"created_at"`
+	ID          int64            `json:"id"`
+	MemberID    int64            `json:"member_id"`
+	MemberName  string           `json:"member_name"`
+	Metadata    *string          `json:"metadata,omitempty"`
+	ExternalRef *string          `json:"external_ref,omitempty"`
+	Status      AssignmentStatus `json:"status"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
 }
 
 // AssignmentStats represents statistics for a group
@@ -54,8 +94,16 @@ type MemberDistribution struct {
 type AssignmentRepository interface {
 	Create(ctx context.Context, assignment *Assignment) error
 	GetByID(ctx context.Context, id int64) (*Assignment, error)
+	GetByExternalRef(ctx context.Context, groupID int64, externalRef string) (*Assignment, error)
 	GetByGroupID(ctx context.Context, groupID int64, limit, offset int) ([]*AssignmentWithMember, error)
 	GetCountByGroupID(ctx context.Context, groupID int64) (int, error)
 	GetCountsByMemberIDs(ctx context.Context, memberIDs []int64) (map[int64]int, error)
+	GetLastAssignedAtByMemberIDs(ctx context.Context, memberIDs []int64) (map[int64]time.Time, error)
 	UpdateStatus(ctx context.Context, id int64, status AssignmentStatus) error
+	// Reassign hands the assignment to another member of its group and records when
+	Reassign(ctx context.Context, id, memberID int64) error
+	// SetAcceptBy moves the acceptance deadline of a pending assignment
+	SetAcceptBy(ctx context.Context, id int64, acceptBy time.Time) error
+	// GetExpiredPending returns pending assignments whose deadline is not after the given time, oldest deadline first
+	GetExpiredPending(ctx context.Context, at time.Time, limit int) ([]*Assignment, error)
 }
\ No newline at end of file
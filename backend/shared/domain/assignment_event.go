@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// AssignmentEventType identifies what happened to an assignment
+type AssignmentEventType string
+
+const (
+	AssignmentEventReassigned    AssignmentEventType = "reassigned"
+	AssignmentEventStatusChanged AssignmentEventType = "status_changed"
+	AssignmentEventAccepted      AssignmentEventType = "accepted"
+	AssignmentEventDeclined      AssignmentEventType = "declined"
+)
+
+// AssignmentEvent is one entry in an assignment's history: who moved it to
+// whom, or changed its status, when and why
+type AssignmentEvent struct {
+	ID           int64               `bun:"id,pk,autoincrement" json:"id"`
+	AssignmentID int64               `bun:"assignment_id,notnull" json:"assignment_id"`
+	Type         AssignmentEventType `bun:"type,notnull" json:"type"`
+	FromMemberID *int64              `bun:"from_member_id" json:"from_member_id,omitempty"`
+	ToMemberID   *int64              `bun:"to_member_id" json:"to_member_id,omitempty"`
+	FromStatus   *AssignmentStatus   `bun:"from_status" json:"from_status,omitempty"`
+	ToStatus     *AssignmentStatus   `bun:"to_status" json:"to_status,omitempty"`
+	Reason       *string             `bun:"reason" json:"reason,omitempty"`
+	ActorID      *int64              `bun:"actor_id" json:"actor_id,omitempty"` // Nil for system changes
+	CreatedAt    time.Time           `bun:"created_at,nullzero,notnull,default:current_timestamp" json:"created_at"`
+}
+
+// AssignmentEventRepository defines the interface for assignment history data access
+type AssignmentEventRepository interface {
+	Create(ctx context.Context, event *AssignmentEvent) error
+	// GetByAssignmentID returns an assignment's history, oldest first
+	GetByAssignmentID(ctx context.Context, assignmentID int64) ([]*AssignmentEvent, error)
+	// GetDeclinedMemberIDs returns the members who declined the assignment
+	GetDeclinedMemberIDs(ctx context.Context, assignmentID int64) ([]int64, error)
+}
@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// QueuedAssignment is an assignment request held back because nobody in the
+// group was on shift. It is dispatched once a member comes on shift.
+type QueuedAssignment struct {
+	ID          int64     `bun:"id,pk,autoincrement" json:"id"`
+	GroupID     int64     `bun:"group_id,notnull,unique:group_external_ref" json:"group_id"`
+	Metadata    *string   `bun:"metadata" json:"metadata,omitempty"`
+	ExternalRef *string   `bun:"external_ref,unique:group_external_ref" json:"external_ref,omitempty"`
+	QueuedBy    int64     `bun:"queued_by,notnull" json:"queued_by"`
+	CreatedAt   time.Time `bun:"created_at,nullzero,notnull,default:current_timestamp" json:"created_at"`
+}
+
+// AssignmentQueueRepository defines the interface for queued assignment data access
+type AssignmentQueueRepository interface {
+	Enqueue(ctx context.Context, item *QueuedAssignment) error
+	// GetQueuedGroupIDs returns the groups with queued items, the group with the oldest item first
+	GetQueuedGroupIDs(ctx context.Context) ([]int64, error)
+	// GetPendingByGroupID returns a group's queued items oldest first
+	GetPendingByGroupID(ctx context.Context, groupID int64, limit int) ([]*QueuedAssignment, error)
+	GetByExternalRef(ctx context.Context, groupID int64, externalRef string) (*QueuedAssignment, error)
+	Delete(ctx context.Context, id int64) error
+}
@@ -7,19 +7,37 @@ import (
 
 // AuditLog represents an audit log entry
 type AuditLog struct {
-	ID           int64     `bun:",pk,autoincrement" json:"id"`
-	UserID       *int64    `bun:"user_id" json:"user_id,omitempty"`
-	UserName     string    `bun:"user_name" json:"user_name"`
-	Action       string    `bun:"action" json:"action"`
-	ResourceType *string   `bun:"resource_type" json:"resource_type,omitempty"`
-	ResourceID   *int64    `bun:"resource_id" json:"resource_id,omitempty"`
-	Details      *string   `bun:"details" json:"details,omitempty"`
-	IPAddress    string    `bun:"ip_address" json:"ip_address"`
-	CreatedAt    time.Time `bun:"created_at" json:"created_at"`
+	ID             int64     `bun:",pk,autoincrement" json:"id"`
+	UserID         *int64    `bun:"user_id" json:"user_id,omitempty"`
+	UserName       string    `bun:"user_name" json:"user_name"`
+	Action         string    `bun:"action" json:"action"`
+	ResourceType   *string   `bun:"resource_type" json:"resource_type,omitempty"`
+	ResourceID     *int64    `bun:"resource_id" json:"resource_id,omitempty"`
+	Details        *string   `bun:"details" json:"details,omitempty"`
+	IPAddress      string    `bun:"ip_address" json:"ip_address"`
+	ImpersonatorID *int64    `bun:"impersonator_id" json:"impersonator_id,omitempty"` // Real actor when UserID was being impersonated
+	CreatedAt      time.Time `bun:"created_at" json:"created_at"`
 }
 
 // AuditLogRepository defines the interface for audit log data access
 type AuditLogRepository interface {
 	Create(ctx context.Context, log *AuditLog) error
 	GetRecent(ctx context.Context, limit int) ([]*AuditLog, error)
-}
\ No newline at end of file
+}
+
+type impersonatorContextKey struct{}
+
+// WithImpersonator marks a context as acting on behalf of impersonatorID.
+// Audit entries created with it are tagged with the real actor.
+func WithImpersonator(ctx context.Context, impersonatorID int64) context.Context {
+	return context.WithValue(ctx, impersonatorContextKey{}, impersonatorID)
+}
+
+// ImpersonatorIDFromContext returns the real actor of an impersonated request, or nil
+func ImpersonatorIDFromContext(ctx context.Context) *int64 {
+	impersonatorID, ok := ctx.Value(impersonatorContextKey{}).(int64)
+	if !ok {
+		return nil
+	}
+	return &impersonatorID
+}
@@ -2,6 +2,8 @@ package domain
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"time"
 )
 
@@ -9,10 +11,64 @@ import (
 type AssignmentStrategy string
 
 const (
-	StrategyWeightedRoundRobin AssignmentStrategy = "weighted_round_robin"
-	StrategyStrictRotation     AssignmentStrategy = "strict_rotation"
+	StrategyWeightedRoundRobin    AssignmentStrategy = "weighted_round_robin"
+	StrategyStrictRotation        AssignmentStrategy = "strict_rotation"
+	StrategyLeastOpenLoad         AssignmentStrategy = "least_open_load"
+	StrategyWeightedRandom        AssignmentStrategy = "weighted_random"
+	StrategyLeastRecentlyAssigned AssignmentStrategy = "least_recently_assigned"
 )
 
+// OffHoursFallback decides what happens when no member is on shift
+type OffHoursFallback string
+
+const (
+	// OffHoursQueue holds the item until a member comes on shift
+	OffHoursQueue OffHoursFallback = "queue"
+	// OffHoursIgnoreHours assigns as if working hours were not set
+	OffHoursIgnoreHours OffHoursFallback = "ignore_hours"
+	// OffHoursOverflow assigns to a member of the overflow group
+	OffHoursOverflow OffHoursFallback = "overflow"
+)
+
+// GroupSettings is the JSON document stored in Group.Settings
+type GroupSettings struct {
+	// Strategy, when set, selects the group's assignment strategy
+	Strategy AssignmentStrategy `json:"strategy,omitempty"`
+	// StrategyParams are passed to the selected strategy
+	StrategyParams json.RawMessage `json:"strategy_params,omitempty"`
+	// OffHoursFallback applies when nobody is on shift. Defaults to queue.
+	OffHoursFallback OffHoursFallback `json:"off_hours_fallback,omitempty"`
+	// OverflowGroupID receives work under the overflow fallback
+	OverflowGroupID *int64 `json:"overflow_group_id,omitempty"`
+	// AcceptanceWindow, when set, starts assignments in pending_acceptance;
+	// members must accept within it (e.g. "30m") or the work moves on
+	AcceptanceWindow string `json:"acceptance_window,omitempty"`
+}
+
+// AcceptanceTimeout returns how long members have to accept an assignment.
+// It returns zero when assignments need no acceptance.
+func (s *GroupSettings) AcceptanceTimeout() (time.Duration, error) {
+	if s.AcceptanceWindow == "" {
+		return 0, nil
+	}
+	window, err := time.ParseDuration(s.AcceptanceWindow)
+	if err != nil {
+		return 0, err
+	}
+	if window <= 0 {
+		return 0, errors.New("acceptance window must be positive")
+	}
+	return window, nil
+}
+
+// Fallback returns the off-hours fallback, applying the default
+func (s *GroupSettings) Fallback() OffHoursFallback {
+	if s.OffHoursFallback == "" {
+		return OffHoursQueue
+	}
+	return s.OffHoursFallback
+}
+
 // Group represents a group for round-robin assignments
 type Group struct {
 	ID               int64              `bun:"id,pk,autoincrement" json:"id"`
@@ -31,12 +87,27 @@ type Group struct {
 	UpdatedAt        time.Time          `bun:"updated_at,nullzero,notnull,default:current_timestamp" json:"updated_at"`
 }
 
+// ParseSettings decodes the group's settings. Groups without settings get
+// the zero value.
+func (g *Group) ParseSettings() (*GroupSettings, error) {
+	settings := &GroupSettings{}
+	if g.Settings == nil || *g.Settings == "" {
+		return settings, nil
+	}
+	if err := json.Unmarshal([]byte(*g.Settings), settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
 // GroupRepository defines the interface for group data access
 type GroupRepository interface {
 	Create(ctx context.Context, group *Group) error
 	GetByID(ctx context.Context, id int64) (*Group, error)
+	LockByID(ctx context.Context, id int64) (*Group, error)
 	GetAll(ctx context.Context) ([]*Group, error)
 	GetByUserID(ctx context.Context, userID int64) ([]*Group, error)
+	GetByOrganizationID(ctx context.Context, orgID *int64) ([]*Group, error)
 	Update(ctx context.Context, group *Group) error
 	Delete(ctx context.Context, id int64) error
-}
\ No newline at end of file
+}
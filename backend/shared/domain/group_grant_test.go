@@ -0,0 +1,101 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+func TestEffectiveGroupRole(t *testing.T) {
+	orgGroup := &domain.Group{ID: 1, UserID: 10, OrganizationID: int64Ptr(1)}
+	legacyGroup := &domain.Group{ID: 2, UserID: 10}
+
+	tests := []struct {
+		name  string
+		user  *domain.User
+		group *domain.Group
+		grant *domain.GroupGrant
+		want  domain.GroupRole
+	}{
+		{
+			name:  "creator owns the group",
+			user:  &domain.User{ID: 10, Role: domain.RoleUser, OrganizationID: int64Ptr(1)},
+			group: orgGroup,
+			want:  domain.GroupRoleOwner,
+		},
+		{
+			name:  "super admin owns any group",
+			user:  &domain.User{ID: 11, Role: domain.RoleSuperAdmin},
+			group: orgGroup,
+			want:  domain.GroupRoleOwner,
+		},
+		{
+			name:  "admin of the group's organization owns it",
+			user:  &domain.User{ID: 11, Role: domain.RoleAdmin, OrganizationID: int64Ptr(1)},
+			group: orgGroup,
+			want:  domain.GroupRoleOwner,
+		},
+		{
+			name:  "org admin of the group's organization owns it",
+			user:  &domain.User{ID: 11, Role: domain.RoleOrgAdmin, OrganizationID: int64Ptr(1)},
+			group: orgGroup,
+			want:  domain.GroupRoleOwner,
+		},
+		{
+			name:  "admin of another organization has no role",
+			user:  &domain.User{ID: 11, Role: domain.RoleAdmin, OrganizationID: int64Ptr(2)},
+			group: orgGroup,
+			want:  "",
+		},
+		{
+			name:  "admin without an organization owns groups without one",
+			user:  &domain.User{ID: 11, Role: domain.RoleAdmin},
+			group: legacyGroup,
+			want:  domain.GroupRoleOwner,
+		},
+		{
+			name:  "admin without an organization has no role in an organization's group",
+			user:  &domain.User{ID: 11, Role: domain.RoleAdmin},
+			group: orgGroup,
+			want:  "",
+		},
+		{
+			name:  "manager without an organization needs a grant",
+			user:  &domain.User{ID: 11, Role: domain.RoleManager},
+			group: legacyGroup,
+			want:  "",
+		},
+		{
+			name:  "member of the organization gets the granted role",
+			user:  &domain.User{ID: 11, Role: domain.RoleUser, OrganizationID: int64Ptr(1)},
+			group: orgGroup,
+			grant: &domain.GroupGrant{GroupID: 1, UserID: 11, Role: domain.GroupRoleDispatcher},
+			want:  domain.GroupRoleDispatcher,
+		},
+		{
+			name:  "grant for another group is ignored",
+			user:  &domain.User{ID: 11, Role: domain.RoleUser, OrganizationID: int64Ptr(1)},
+			group: orgGroup,
+			grant: &domain.GroupGrant{GroupID: 3, UserID: 11, Role: domain.GroupRoleManager},
+			want:  "",
+		},
+		{
+			name:  "grant outside the user's organization is ignored",
+			user:  &domain.User{ID: 11, Role: domain.RoleUser, OrganizationID: int64Ptr(2)},
+			group: orgGroup,
+			grant: &domain.GroupGrant{GroupID: 1, UserID: 11, Role: domain.GroupRoleManager},
+			want:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, domain.EffectiveGroupRole(tt.user, tt.group, tt.grant))
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrIdempotencyKeyInUse is returned when a key is stored twice before it expires
+var ErrIdempotencyKeyInUse = errors.New("idempotency key is already in use")
+
+// IdempotencyRecord remembers the outcome of an assignment request sent with
+// an Idempotency-Key header, so a retry gets the original answer instead of a
+// second assignment. Keys are scoped to the group and expire. A queued request's
+// record is pointed at its assignment once the request is dispatched.
+type IdempotencyRecord struct {
+	ID           int64     `bun:"id,pk,autoincrement" json:"id"`
+	GroupID      int64     `bun:"group_id,notnull,unique:group_key" json:"group_id"`
+	Key          string    `bun:"key,notnull,unique:group_key" json:"key"`
+	AssignmentID *int64    `bun:"assignment_id" json:"assignment_id,omitempty"`
+	QueuedID     *int64    `bun:"queued_id" json:"queued_id,omitempty"`
+	ExpiresAt    time.Time `bun:"expires_at,notnull" json:"expires_at"`
+	CreatedAt    time.Time `bun:"created_at,nullzero,notnull,default:current_timestamp" json:"created_at"`
+}
+
+// IdempotencyRepository defines the interface for idempotency record data access
+type IdempotencyRepository interface {
+	// Create stores a record, replacing an expired record for the same key. It
+	// returns ErrIdempotencyKeyInUse if the key already has a live record.
+	Create(ctx context.Context, record *IdempotencyRecord) error
+	// Get returns the group's record for key if it has not expired at the given time
+	Get(ctx context.Context, groupID int64, key string, at time.Time) (*IdempotencyRecord, error)
+	// ResolveQueued points records of a queued request at the assignment it became
+	ResolveQueued(ctx context.Context, queuedID, assignmentID int64) error
+	// DeleteExpired removes records that expired before the given time and returns how many were removed
+	DeleteExpired(ctx context.Context, before time.Time) (int, error)
+}
@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// UserIdentity links a user to an account at an external identity provider
+type UserIdentity struct {
+	ID          int64      `bun:"id,pk,autoincrement" json:"id"`
+	UserID      int64      `bun:"user_id,notnull" json:"user_id"`
+	Issuer      string     `bun:"issuer,notnull,unique:issuer_subject" json:"issuer"`
+	Subject     string     `bun:"subject,notnull,unique:issuer_subject" json:"subject"`
+	Email       string     `bun:"email" json:"email"`
+	LastLoginAt *time.Time `bun:"last_login_at" json:"last_login_at,omitempty"`
+	CreatedAt   time.Time  `bun:"created_at,nullzero,notnull,default:current_timestamp" json:"created_at"`
+}
+
+// UserIdentityRepository defines the interface for external identity data access
+type UserIdentityRepository interface {
+	Create(ctx context.Context, identity *UserIdentity) error
+	GetBySubject(ctx context.Context, issuer, subject string) (*UserIdentity, error)
+	UpdateLastLogin(ctx context.Context, id int64, email string) error
+}
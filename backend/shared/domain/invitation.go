@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Invitation allows one person to register with a given email, role and
+// organization. Only the hash of the token is stored.
+type Invitation struct {
+	ID             int64      `bun:"id,pk,autoincrement" json:"id"`
+	Email          string     `bun:"email,notnull" json:"email"`
+	Role           UserRole   `bun:"role,notnull" json:"role"`
+	OrganizationID *int64     `bun:"organization_id" json:"organization_id,omitempty"`
+	TokenHash      string     `bun:"token_hash,notnull,unique" json:"-"`
+	InvitedBy      int64      `bun:"invited_by,notnull" json:"invited_by"`
+	ExpiresAt      time.Time  `bun:"expires_at,notnull" json:"expires_at"`
+	AcceptedAt     *time.Time `bun:"accepted_at" json:"accepted_at,omitempty"`
+	RevokedAt      *time.Time `bun:"revoked_at" json:"revoked_at,omitempty"`
+	CreatedAt      time.Time  `bun:"created_at,nullzero,notnull,default:current_timestamp" json:"created_at"`
+}
+
+// IsPending reports whether the invitation can still be accepted
+func (i *Invitation) IsPending() bool {
+	return i.AcceptedAt == nil && i.RevokedAt == nil && time.Now().Before(i.ExpiresAt)
+}
+
+// InvitationRepository defines the interface for invitation data access
+type InvitationRepository interface {
+	Create(ctx context.Context, invitation *Invitation) error
+	GetByID(ctx context.Context, id int64) (*Invitation, error)
+	GetByHash(ctx context.Context, hash string) (*Invitation, error)
+	GetPending(ctx context.Context) ([]*Invitation, error)
+	GetPendingByOrganizationID(ctx context.Context, orgID int64) ([]*Invitation, error)
+	// GetPendingByEmail returns the newest pending invitation for an email, or nil
+	GetPendingByEmail(ctx context.Context, email string) (*Invitation, error)
+	// MarkAccepted atomically consumes an invitation. It returns false if it was no longer pending.
+	MarkAccepted(ctx context.Context, id int64) (bool, error)
+	Revoke(ctx context.Context, id int64) (bool, error)
+	// RevokePendingByEmail revokes every outstanding invitation for an email
+	RevokePendingByEmail(ctx context.Context, email string) error
+}
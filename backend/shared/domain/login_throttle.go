@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Login throttle scopes
+const (
+	ThrottleScopeAccount = "account" // Identifier is the normalized email, whether or not the account exists
+	ThrottleScopeIP      = "ip"
+)
+
+// LoginThrottle counts recent failed logins for an account or client IP
+type LoginThrottle struct {
+	ID            int64      `bun:"id,pk,autoincrement" json:"id"`
+	Scope         string     `bun:"scope,notnull,unique:scope_identifier" json:"scope"`
+	Identifier    string     `bun:"identifier,notnull,unique:scope_identifier" json:"identifier"`
+	Failures      int        `bun:"failures,notnull,default:0" json:"failures"`
+	LastFailureAt time.Time  `bun:"last_failure_at,notnull" json:"last_failure_at"`
+	LockedUntil   *time.Time `bun:"locked_until" json:"locked_until,omitempty"`
+	CreatedAt     time.Time  `bun:"created_at,nullzero,notnull,default:current_timestamp" json:"created_at"`
+}
+
+// IsLocked reports whether logins are currently blocked
+func (t *LoginThrottle) IsLocked() bool {
+	return t.LockedUntil != nil && time.Now().Before(*t.LockedUntil)
+}
+
+// LoginThrottleRepository defines the interface for login throttle data access
+type LoginThrottleRepository interface {
+	Get(ctx context.Context, scope, identifier string) (*LoginThrottle, error)
+	// RecordFailure atomically increments the failure count, restarting it when
+	// the previous failure is older than window, and returns the updated row
+	RecordFailure(ctx context.Context, scope, identifier string, window time.Duration) (*LoginThrottle, error)
+	// Lock blocks logins until the given time and clears the failure count
+	Lock(ctx context.Context, id int64, until time.Time) error
+	Reset(ctx context.Context, scope, identifier string) error
+	GetLocked(ctx context.Context) ([]*LoginThrottle, error)
+	// GetLockedAccounts returns account lockouts of users in an organization, or
+	// of users without one when organizationID is nil
+	GetLockedAccounts(ctx context.Context, organizationID *int64) ([]*LoginThrottle, error)
+}
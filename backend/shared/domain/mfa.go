@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// TOTPFactor is a user's authenticator app enrolment. The secret is encrypted at rest.
+// A factor only protects logins once it has been confirmed with a valid code.
+type TOTPFactor struct {
+	ID           int64      `bun:"id,pk,autoincrement" json:"id"`
+	UserID       int64      `bun:"user_id,notnull,unique" json:"user_id"`
+	Secret       string     `bun:"secret,notnull" json:"-"`
+	ConfirmedAt  *time.Time `bun:"confirmed_at" json:"confirmed_at,omitempty"`
+	LastUsedStep int64      `bun:"last_used_step,notnull,default:0" json:"-"` // Last accepted time step, to reject replayed codes
+	CreatedAt    time.Time  `bun:"created_at,nullzero,notnull,default:current_timestamp" json:"created_at"`
+}
+
+// IsConfirmed reports whether the factor has been verified and is enforced at login
+func (f *TOTPFactor) IsConfirmed() bool {
+	return f.ConfirmedAt != nil
+}
+
+// RecoveryCode is a single-use code that replaces a TOTP code when the
+// authenticator is unavailable. Only the hash is stored.
+type RecoveryCode struct {
+	ID        int64      `bun:"id,pk,autoincrement" json:"id"`
+	UserID    int64      `bun:"user_id,notnull" json:"user_id"`
+	CodeHash  string     `bun:"code_hash,notnull" json:"-"`
+	UsedAt    *time.Time `bun:"used_at" json:"used_at,omitempty"`
+	CreatedAt time.Time  `bun:"created_at,nullzero,notnull,default:current_timestamp" json:"created_at"`
+}
+
+// TOTPFactorRepository defines the interface for TOTP factor data access
+type TOTPFactorRepository interface {
+	Create(ctx context.Context, factor *TOTPFactor) error
+	GetByUserID(ctx context.Context, userID int64) (*TOTPFactor, error)
+	Confirm(ctx context.Context, id int64) error
+	// UseStep atomically records an accepted time step. It returns false if the
+	// step, or a later one, was already used.
+	UseStep(ctx context.Context, id int64, step int64) (bool, error)
+	DeleteByUserID(ctx context.Context, userID int64) error
+}
+
+// RecoveryCodeRepository defines the interface for recovery code data access
+type RecoveryCodeRepository interface {
+	// Replace discards a user's existing codes and stores a new set
+	Replace(ctx context.Context, userID int64, codes []*RecoveryCode) error
+	// Consume atomically marks a matching unused code as used. It returns false if none matched.
+	Consume(ctx context.Context, userID int64, codeHash string) (bool, error)
+	CountUnused(ctx context.Context, userID int64) (int, error)
+	DeleteByUserID(ctx context.Context, userID int64) error
+}
@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Organization is a tenant. Users and groups belong to at most one
+// organization and only super admins can see across organizations.
+type Organization struct {
+	ID         int64     `bun:"id,pk,autoincrement" json:"id"`
+	Name       string    `bun:"name,notnull" json:"name"`
+	Slug       string    `bun:"slug,notnull,unique" json:"slug"`
+	RequireMFA bool      `bun:"require_mfa,notnull,default:false" json:"require_mfa"` // Every member must enrol a second factor
+	CreatedAt  time.Time `bun:"created_at,nullzero,notnull,default:current_timestamp" json:"created_at"`
+	UpdatedAt  time.Time `bun:"updated_at,nullzero,notnull,default:current_timestamp" json:"updated_at"`
+}
+
+// OrganizationRepository defines the interface for organization data access
+type OrganizationRepository interface {
+	Create(ctx context.Context, org *Organization) error
+	GetByID(ctx context.Context, id int64) (*Organization, error)
+	GetBySlug(ctx context.Context, slug string) (*Organization, error)
+	GetAll(ctx context.Context) ([]*Organization, error)
+	Update(ctx context.Context, org *Organization) error
+	Delete(ctx context.Context, id int64) error
+}
@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// PasswordResetToken represents a single-use, time-limited password reset token.
+// Only the hash of the token is stored.
+type PasswordResetToken struct {
+	ID        int64      `bun:"id,pk,autoincrement" json:"id"`
+	UserID    int64      `bun:"user_id,notnull" json:"user_id"`
+	TokenHash string     `bun:"token_hash,notnull,unique" json:"-"`
+	ExpiresAt time.Time  `bun:"expires_at,notnull" json:"expires_at"`
+	UsedAt    *time.Time `bun:"used_at" json:"used_at,omitempty"`
+	CreatedAt time.Time  `bun:"created_at,nullzero,notnull,default:current_timestamp" json:"created_at"`
+}
+
+// PasswordResetTokenRepository defines the interface for password reset token data access
+type PasswordResetTokenRepository interface {
+	Create(ctx context.Context, token *PasswordResetToken) error
+	GetByHash(ctx context.Context, hash string) (*PasswordResetToken, error)
+	// MarkUsed atomically consumes a token. It returns false if the token was already used.
+	MarkUsed(ctx context.Context, id int64) (bool, error)
+	// InvalidateByUserID consumes every outstanding token of a user
+	InvalidateByUserID(ctx context.Context, userID int64) error
+}
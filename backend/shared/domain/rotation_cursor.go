@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// RotationCursor remembers where a strict rotation group's rotation stands.
+// Members are walked in ascending ID order, so the cursor stays meaningful when
+// members are added or removed.
+type RotationCursor struct {
+	GroupID      int64     `bun:"group_id,pk" json:"group_id"`
+	LastMemberID int64     `bun:"last_member_id,notnull" json:"last_member_id"`
+	UpdatedAt    time.Time `bun:"updated_at,nullzero,notnull,default:current_timestamp" json:"updated_at"`
+}
+
+// RotationCursorRepository defines the interface for rotation cursor data access
+type RotationCursorRepository interface {
+	Get(ctx context.Context, groupID int64) (*RotationCursor, error)
+	Set(ctx context.Context, groupID, lastMemberID int64) error
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Session represents a login session (refresh token family) on one device
+type Session struct {
+	ID         int64      `bun:"id,pk,autoincrement" json:"id"`
+	UserID     int64      `bun:"user_id,notnull" json:"user_id"`
+	UserAgent  string     `bun:"user_agent" json:"device"`
+	IPAddress  string     `bun:"ip_address" json:"ip_address"`
+	LastSeenAt time.Time  `bun:"last_seen_at,nullzero,notnull,default:current_timestamp" json:"last_seen_at"`
+	ExpiresAt  time.Time  `bun:"expires_at,notnull" json:"expires_at"`
+	RevokedAt  *time.Time `bun:"revoked_at" json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `bun:"created_at,nullzero,notnull,default:current_timestamp" json:"created_at"`
+}
+
+// IsActive reports whether the session can still be used
+func (s *Session) IsActive() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
+
+// RefreshToken represents a single-use refresh token issued for a session.
+// Each refresh rotates the token; presenting a used token revokes the session.
+type RefreshToken struct {
+	ID        int64      `bun:"id,pk,autoincrement" json:"id"`
+	SessionID int64      `bun:"session_id,notnull" json:"session_id"`
+	TokenHash string     `bun:"token_hash,notnull,unique" json:"-"`
+	ExpiresAt time.Time  `bun:"expires_at,notnull" json:"expires_at"`
+	UsedAt    *time.Time `bun:"used_at" json:"used_at,omitempty"`
+	CreatedAt time.Time  `bun:"created_at,nullzero,notnull,default:current_timestamp" json:"created_at"`
+}
+
+// SessionRepository defines the interface for session data access
+type SessionRepository interface {
+	Create(ctx context.Context, session *Session) error
+	GetByID(ctx context.Context, id int64) (*Session, error)
+	GetActiveByUserID(ctx context.Context, userID int64) ([]*Session, error)
+	Touch(ctx context.Context, id int64, ipAddress string) error
+	Revoke(ctx context.Context, id int64) error
+	RevokeAllByUserID(ctx context.Context, userID int64) error
+}
+
+// RefreshTokenRepository defines the interface for refresh token data access
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *RefreshToken) error
+	GetByHash(ctx context.Context, hash string) (*RefreshToken, error)
+	// MarkUsed atomically consumes a token. It returns false if the token was already used.
+	MarkUsed(ctx context.Context, id int64) (bool, error)
+}
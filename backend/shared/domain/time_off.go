@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// TimeOffReason explains why a member is out of office
+type TimeOffReason string
+
+const (
+	TimeOffVacation  TimeOffReason = "vacation"
+	TimeOffSickLeave TimeOffReason = "sick_leave"
+	TimeOffPersonal  TimeOffReason = "personal"
+	TimeOffTraining  TimeOffReason = "training"
+	TimeOffOther     TimeOffReason = "other"
+)
+
+// IsValid reports whether the reason is one of the known reasons
+func (r TimeOffReason) IsValid() bool {
+	switch r {
+	case TimeOffVacation, TimeOffSickLeave, TimeOffPersonal, TimeOffTraining, TimeOffOther:
+		return true
+	}
+	return false
+}
+
+// TimeOffWindow is a scheduled out-of-office window. The member receives no
+// assignments from StartsAt up to, but not including, EndsAt.
+type TimeOffWindow struct {
+	ID        int64         `bun:"id,pk,autoincrement" json:"id"`
+	MemberID  int64         `bun:"member_id,notnull" json:"member_id"`
+	StartsAt  time.Time     `bun:"starts_at,notnull" json:"starts_at"`
+	EndsAt    time.Time     `bun:"ends_at,notnull" json:"ends_at"`
+	Reason    TimeOffReason `bun:"reason,notnull" json:"reason"`
+	Note      *string       `bun:"note" json:"note,omitempty"`
+	CreatedBy int64         `bun:"created_by,notnull" json:"created_by"`
+	CreatedAt time.Time     `bun:"created_at,nullzero,notnull,default:current_timestamp" json:"created_at"`
+
+	StartNotifiedAt *time.Time `bun:"start_notified_at" json:"-"` // When the start was announced to webhooks
+	EndNotifiedAt   *time.Time `bun:"end_notified_at" json:"-"`   // When the end was announced to webhooks
+}
+
+// Covers reports whether the window includes the given instant
+func (t *TimeOffWindow) Covers(at time.Time) bool {
+	return !at.Before(t.StartsAt) && at.Before(t.EndsAt)
+}
+
+// TimeOffRepository defines the interface for time off data access
+type TimeOffRepository interface {
+	Create(ctx context.Context, window *TimeOffWindow) error
+	GetByID(ctx context.Context, id int64) (*TimeOffWindow, error)
+	// GetByMemberID returns windows that have not ended yet, soonest first
+	GetByMemberID(ctx context.Context, memberID int64, since time.Time) ([]*TimeOffWindow, error)
+	// GetOutOfOfficeMemberIDs returns which of the members are on time off at the given instant
+	GetOutOfOfficeMemberIDs(ctx context.Context, memberIDs []int64, at time.Time) (map[int64]bool, error)
+	Delete(ctx context.Context, id int64) error
+	// GetStartsToNotify returns windows that started by the given instant and whose start has not been announced, oldest first
+	GetStartsToNotify(ctx context.Context, at time.Time, limit int) ([]*TimeOffWindow, error)
+	// GetEndsToNotify returns windows that ended by the given instant and whose end has not been announced, oldest first
+	GetEndsToNotify(ctx context.Context, at time.Time, limit int) ([]*TimeOffWindow, error)
+	// MarkStartNotified records that a window's start was announced. It reports
+	// false if another caller already did, so each start is announced once.
+	MarkStartNotified(ctx context.Context, id int64) (bool, error)
+	// MarkEndNotified does the same for a window's end
+	MarkEndNotified(ctx context.Context, id int64) (bool, error)
+}
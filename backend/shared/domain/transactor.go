@@ -0,0 +1,11 @@
+package domain
+
+import "context"
+
+// Transactor runs work in a single database transaction. Repositories called
+// with the context handed to fn take part in the transaction. Nested calls run
+// in a savepoint of the outer transaction: when fn fails only its own work is
+// undone, and the outer transaction decides whether to commit.
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
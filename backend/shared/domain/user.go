@@ -11,20 +11,34 @@ type UserRole string
 const (
 	RoleSuperAdmin UserRole = "super_admin"
 	RoleAdmin      UserRole = "admin"
+	RoleOrgAdmin   UserRole = "org_admin"
 	RoleManager    UserRole = "manager"
 	RoleUser       UserRole = "user"
 )
 
 // User represents a system user
 type User struct {
-	ID             int64     `bun:"id,pk,autoincrement" json:"id"`
-	Email          string    `bun:"email,notnull,unique" json:"email"`
-	PasswordHash   string    `bun:"password_hash,notnull" json:"-"`
-	Name           string    `bun:"name,notnull" json:"name"`
-	Role           UserRole  `bun:"role,notnull,default:'user'" json:"role"`
-	OrganizationID *int64    `bun:"organization_id" json:"organization_id,omitempty"`
-	CreatedAt      time.Time `bun:"created_at,nullzero,notnull,default:current_timestamp" json:"created_at"`
-	UpdatedAt      time.Time `bun:"updated_at,nullzero,notnull,default:current_timestamp" json:"updated_at"`
+	ID              int64      `bun:"id,pk,autoincrement" json:"id"`
+	Email           string     `bun:"email,notnull,unique" json:"email"`
+	PasswordHash    string     `bun:"password_hash,notnull" json:"-"`
+	Name            string     `bun:"name,notnull" json:"name"`
+	Role            UserRole   `bun:"role,notnull,default:'user'" json:"role"`
+	OrganizationID  *int64     `bun:"organization_id" json:"organization_id,omitempty"`
+	EmailVerifiedAt *time.Time `bun:"email_verified_at" json:"email_verified_at,omitempty"`
+	DeactivatedAt   *time.Time `bun:"deactivated_at" json:"deactivated_at,omitempty"`
+	CreatedAt       time.Time  `bun:"created_at,nullzero,notnull,default:current_timestamp" json:"created_at"`
+	UpdatedAt       time.Time  `bun:"updated_at,nullzero,notnull,default:current_timestamp" json:"updated_at"`
+}
+
+// UserFilter narrows a user search. Zero values match everything.
+type UserFilter struct {
+	Query            string   // Case-insensitive match on name or email
+	Role             UserRole // Exact role
+	OrganizationID   *int64   // Users of one organization; nil means users without one
+	AllOrganizations bool     // Ignore OrganizationID and search every organization
+	Active           *bool    // true for active users, false for deactivated ones
+	Limit            int
+	Offset           int
 }
 
 // UserRepository defines the interface for user data access
@@ -36,4 +50,48 @@ type UserRepository interface {
 	Update(ctx context.Context, user *User) error
 	Delete(ctx context.Context, id int64) error
 	UpdateRole(ctx context.Context, id int64, role UserRole) error
-}
\ No newline at end of file
+	GetByOrganizationID(ctx context.Context, orgID int64) ([]*User, error)
+	MarkEmailVerified(ctx context.Context, id int64) error
+	Search(ctx context.Context, filter UserFilter) ([]*User, int, error) // Matching page of users and the total number of matches
+	Deactivate(ctx context.Context, id int64) error
+	Reactivate(ctx context.Context, id int64) error
+}
+
+// IsActive reports whether the user may sign in
+func (u *User) IsActive() bool {
+	return u.DeactivatedAt == nil
+}
+
+// IsEmailVerified reports whether the user has proven they control their email address
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// IsSuperAdmin reports whether the user can act across organizations
+func (u *User) IsSuperAdmin() bool {
+	return u.Role == RoleSuperAdmin
+}
+
+// BelongsTo reports whether the user may access data owned by an organization.
+// Data without an organization is only visible to users without one.
+func (u *User) BelongsTo(orgID *int64) bool {
+	if u.IsSuperAdmin() {
+		return true
+	}
+	if u.OrganizationID == nil || orgID == nil {
+		return u.OrganizationID == nil && orgID == nil
+	}
+	return *u.OrganizationID == *orgID
+}
+
+// IsOrgAdmin reports whether the user administers an organization.
+// Admins administer their own organization; super admins administer every one.
+func (u *User) IsOrgAdmin(orgID int64) bool {
+	if u.IsSuperAdmin() {
+		return true
+	}
+	if u.Role != RoleAdmin && u.Role != RoleOrgAdmin {
+		return false
+	}
+	return u.OrganizationID != nil && *u.OrganizationID == orgID
+}
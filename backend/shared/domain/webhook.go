@@ -19,6 +19,7 @@ type Webhook struct {
 // WebhookRepository defines the interface for webhook data access
 type WebhookRepository interface {
 	Create(ctx context.Context, webhook *Webhook) error
+	GetByID(ctx context.Context, id int64) (*Webhook, error)
 	GetByGroupID(ctx context.Context, groupID int64) ([]*Webhook, error)
 	GetActiveByGroupID(ctx context.Context, groupID int64) ([]*Webhook, error)
 	Update(ctx context.Context, webhook *Webhook) error
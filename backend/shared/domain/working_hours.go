@@ -0,0 +1,157 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ShiftRange is a span of local time within a day, in minutes after midnight.
+// An End before Start is an overnight shift that finishes the next day.
+type ShiftRange struct {
+	Start int
+	End   int
+}
+
+// WorkingHours lists the shifts a member works on each weekday. Days that are
+// missing have no shifts.
+type WorkingHours map[time.Weekday][]ShiftRange
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// ParseWorkingHours decodes the JSON stored in Member.WorkingHours. Each day
+// maps to one range ("09:00-17:00"), several comma separated ranges for split
+// shifts ("08:00-12:00,13:00-17:00") or an array of ranges. Overnight shifts
+// such as "22:00-06:00" belong to the day they start.
+func ParseWorkingHours(raw string) (WorkingHours, error) {
+	var days map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &days); err != nil {
+		return nil, fmt.Errorf("working hours must be an object of weekdays to time ranges")
+	}
+
+	hours := make(WorkingHours, len(days))
+	for day, value := range days {
+		weekday, ok := weekdays[strings.ToLower(day)]
+		if !ok {
+			return nil, fmt.Errorf("unknown weekday %q in working hours", day)
+		}
+
+		var specs []string
+		var single string
+		if err := json.Unmarshal(value, &single); err == nil {
+			specs = strings.Split(single, ",")
+		} else if err := json.Unmarshal(value, &specs); err != nil {
+			return nil, fmt.Errorf("working hours for %s must be a string or an array of strings", day)
+		}
+
+		for _, spec := range specs {
+			spec = strings.TrimSpace(spec)
+			if spec == "" {
+				continue
+			}
+			shift, err := parseShiftRange(spec)
+			if err != nil {
+				return nil, fmt.Errorf("working hours for %s: %w", day, err)
+			}
+			hours[weekday] = append(hours[weekday], shift)
+		}
+	}
+
+	return hours, nil
+}
+
+// parseShiftRange parses "HH:MM-HH:MM". "24:00" may be used as an end time.
+func parseShiftRange(spec string) (ShiftRange, error) {
+	startText, endText, found := strings.Cut(spec, "-")
+	if !found {
+		return ShiftRange{}, fmt.Errorf("invalid time range %q, expected HH:MM-HH:MM", spec)
+	}
+
+	start, err := parseClock(strings.TrimSpace(startText), false)
+	if err != nil {
+		return ShiftRange{}, err
+	}
+	end, err := parseClock(strings.TrimSpace(endText), true)
+	if err != nil {
+		return ShiftRange{}, err
+	}
+	if start == end {
+		return ShiftRange{}, fmt.Errorf("time range %q is empty", spec)
+	}
+
+	return ShiftRange{Start: start, End: end}, nil
+}
+
+// parseClock converts "HH:MM" to minutes after midnight
+func parseClock(text string, allowMidnightEnd bool) (int, error) {
+	if allowMidnightEnd && text == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse("15:04", text)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", text)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Contains reports whether the local time falls within the working hours
+func (h WorkingHours) Contains(local time.Time) bool {
+	minute := local.Hour()*60 + local.Minute()
+
+	for _, shift := range h[local.Weekday()] {
+		if shift.Start < shift.End {
+			if minute >= shift.Start && minute < shift.End {
+				return true
+			}
+		} else if minute >= shift.Start {
+			// Overnight shift that started today
+			return true
+		}
+	}
+
+	// Overnight shifts that started yesterday
+	yesterday := (local.Weekday() + 6) % 7
+	for _, shift := range h[yesterday] {
+		if shift.Start > shift.End && minute < shift.End {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Location returns the member's timezone, defaulting to UTC
+func (m *Member) Location() (*time.Location, error) {
+	if m.Timezone == nil || *m.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(*m.Timezone)
+}
+
+// OnShift reports whether the member is working at the given instant. Members
+// without working hours are always on shift.
+func (m *Member) OnShift(at time.Time) (bool, error) {
+	if m.WorkingHours == nil || *m.WorkingHours == "" {
+		return true, nil
+	}
+
+	hours, err := ParseWorkingHours(*m.WorkingHours)
+	if err != nil {
+		return false, err
+	}
+	location, err := m.Location()
+	if err != nil {
+		return false, err
+	}
+
+	return hours.Contains(at.In(location)), nil
+}
@@ -0,0 +1,84 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkingHours_Contains(t *testing.T) {
+	hours, err := domain.ParseWorkingHours(`{
+		"monday": "09:00-17:00",
+		"tuesday": "08:00-12:00,13:00-17:00",
+		"wednesday": ["22:00-06:00"],
+		"friday": "20:00-24:00",
+		"saturday": "23:00-02:00"
+	}`)
+	require.NoError(t, err)
+
+	// 2026-10-12 is a Monday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.October, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want bool
+	}{
+		{"start of a day shift is included", at(12, 9, 0), true},
+		{"end of a day shift is excluded", at(12, 17, 0), false},
+		{"before a day shift", at(12, 8, 59), false},
+		{"morning half of a split shift", at(13, 11, 59), true},
+		{"lunch break of a split shift", at(13, 12, 30), false},
+		{"afternoon half of a split shift", at(13, 13, 0), true},
+		{"overnight shift on the day it starts", at(14, 23, 30), true},
+		{"overnight shift after midnight", at(15, 5, 59), true},
+		{"overnight shift has ended", at(15, 6, 0), false},
+		{"before an overnight shift starts", at(14, 21, 59), false},
+		{"day without shifts", at(15, 12, 0), false},
+		{"shift ending at midnight", at(16, 23, 59), true},
+		{"shift ending at midnight does not run into the next day", at(17, 0, 0), false},
+		{"overnight shift running into the next week", at(18, 1, 0), true},
+		{"overnight shift into the next week has ended", at(18, 2, 0), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, hours.Contains(tt.at))
+		})
+	}
+}
+
+func TestParseWorkingHours_Errors(t *testing.T) {
+	for _, raw := range []string{
+		`"09:00-17:00"`,
+		`{"someday": "09:00-17:00"}`,
+		`{"monday": "9-5"}`,
+		`{"monday": "09:00-09:00"}`,
+		`{"monday": "25:00-26:00"}`,
+		`{"monday": 9}`,
+	} {
+		_, err := domain.ParseWorkingHours(raw)
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestMember_OnShift_UsesTimezone(t *testing.T) {
+	hours := `{"monday": "09:00-17:00"}`
+	timezone := "America/New_York"
+	member := &domain.Member{WorkingHours: &hours, Timezone: &timezone}
+
+	// 14:00 UTC on a Monday is 10:00 in New York
+	onShift, err := member.OnShift(time.Date(2026, 10, 12, 14, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.True(t, onShift)
+
+	// 22:00 UTC is 18:00 in New York
+	onShift, err = member.OnShift(time.Date(2026, 10, 12, 22, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.False(t, onShift)
+}
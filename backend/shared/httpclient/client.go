@@ -9,20 +9,46 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/raufhm/fairflow/shared/crypto"
 )
 
+// ServiceTokenHeader carries the caller's service identity token on internal calls
+const ServiceTokenHeader = "X-Service-Token"
+
 // ServiceClient is an HTTP client for inter-service communication
 type ServiceClient struct {
-	baseURL    string
-	httpClient *http.Client
+	baseURL     string
+	serviceName string // Name of the service being called, used as the token audience
+	httpClient  *http.Client
+	tokens      *crypto.ServiceTokenService
 }
 
-// NewServiceClient creates a new service client
+// NewServiceClient creates a new service client for the named service
 func NewServiceClient(baseURL, serviceName string) *ServiceClient {
 	return &ServiceClient{
-		baseURL:    baseURL,
-		httpClient: &http.Client{Timeout: 10 * time.Second},
+		baseURL:     baseURL,
+		serviceName: serviceName,
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// WithServiceAuth signs every request with the calling service's identity
+func (c *ServiceClient) WithServiceAuth(tokens *crypto.ServiceTokenService) *ServiceClient {
+	c.tokens = tokens
+	return c
+}
+
+// do attaches the service token, if configured, and sends the request
+func (c *ServiceClient) do(req *http.Request) (*http.Response, error) {
+	if c.tokens != nil {
+		token, err := c.tokens.Generate(c.serviceName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign service request: %w", err)
+		}
+		req.Header.Set(ServiceTokenHeader, token)
 	}
+	return c.httpClient.Do(req)
 }
 
 // Get performs a GET request
@@ -32,7 +58,7 @@ func (c *ServiceClient) Get(ctx context.Context, path string) ([]byte, error) {
 		return nil, err
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -62,7 +88,7 @@ func (c *ServiceClient) Post(ctx context.Context, path string, body interface{})
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -92,7 +118,7 @@ func (c *ServiceClient) Put(ctx context.Context, path string, body interface{})
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -116,7 +142,7 @@ func (c *ServiceClient) Delete(ctx context.Context, path string) error {
 		return err
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,119 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/crypto"
+	"github.com/raufhm/fairflow/shared/logger"
+	"go.uber.org/zap"
+)
+
+// jwksPath is the auth service's internal route for its verification keys.
+// Requests to it must carry a service token.
+const jwksPath = "/internal/v1/jwks.json"
+
+// minJWKSRefreshInterval limits refetches triggered by unknown key ids
+const minJWKSRefreshInterval = 30 * time.Second
+
+// JWKSCache fetches the auth service's JWKS and caches the keys.
+// It implements crypto.KeyResolver so services can verify tokens without
+// holding any signing secret.
+type JWKSCache struct {
+	client    *ServiceClient
+	ttl       time.Duration
+	mu        sync.RWMutex // Guards keys and fetchedAt; never held during a fetch
+	keys      map[string]interface{}
+	fetchedAt time.Time
+
+	fetchMu     sync.Mutex // Serializes fetches so concurrent callers share one request
+	attemptedAt time.Time  // Guarded by fetchMu
+}
+
+// NewJWKSCache creates a JWKS cache that refreshes keys after ttl
+func NewJWKSCache(client *ServiceClient, ttl time.Duration) *JWKSCache {
+	return &JWKSCache{
+		client: client,
+		ttl:    ttl,
+		keys:   make(map[string]interface{}),
+	}
+}
+
+// PublicKey returns the verification key for a kid. Known keys are served from
+// the cache even when it is stale, while a background fetch refreshes it, so a
+// slow or unreachable auth service never holds up their token checks. Unknown
+// kids (e.g. right after a key rotation) wait for a fetch.
+func (c *JWKSCache) PublicKey(kid string) (interface{}, error) {
+	c.mu.RLock()
+	key, ok := c.keys[kid]
+	fresh := time.Since(c.fetchedAt) < c.ttl
+	c.mu.RUnlock()
+
+	if ok {
+		if !fresh {
+			go c.refreshInBackground()
+		}
+		return key, nil
+	}
+
+	if err := c.refresh(); err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	key, ok = c.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id: %s", kid)
+	}
+	return key, nil
+}
+
+// refresh refetches the key set, waiting for a fetch already in flight
+func (c *JWKSCache) refresh() error {
+	c.fetchMu.Lock()
+	defer c.fetchMu.Unlock()
+	return c.fetch()
+}
+
+// refreshInBackground refetches the key set unless a fetch is already running
+func (c *JWKSCache) refreshInBackground() {
+	if !c.fetchMu.TryLock() {
+		return
+	}
+	defer c.fetchMu.Unlock()
+
+	if err := c.fetch(); err != nil {
+		logger.Log.Warn("Using stale JWKS", zap.Error(err))
+	}
+}
+
+// fetch loads the key set, at most once per minJWKSRefreshInterval.
+// The caller must hold fetchMu.
+func (c *JWKSCache) fetch() error {
+	if time.Since(c.attemptedAt) < minJWKSRefreshInterval {
+		return nil
+	}
+	c.attemptedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	body, err := c.client.Get(ctx, jwksPath)
+	if err != nil {
+		return fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+
+	keys, err := crypto.ParseJWKS(body)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.keys = keys
+	c.fetchedAt = time.Now()
+	c.mu.Unlock()
+	return nil
+}
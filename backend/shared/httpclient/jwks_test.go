@@ -0,0 +1,96 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jwksServer serves a key set, waiting for release before answering while block is set
+type jwksServer struct {
+	*httptest.Server
+	requests atomic.Int32
+	block    atomic.Bool
+	release  chan struct{}
+}
+
+func newJWKSServer(t *testing.T, keys *crypto.KeySet) *jwksServer {
+	s := &jwksServer{release: make(chan struct{})}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		if s.block.Load() {
+			<-s.release
+		}
+		json.NewEncoder(w).Encode(keys.JWKS())
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestJWKSCache_ServesKnownKeysWhileRefreshing(t *testing.T) {
+	keys, err := crypto.NewEphemeralKeySet()
+	require.NoError(t, err)
+	kid := keys.SigningKey().ID
+
+	server := newJWKSServer(t, keys)
+	cache := NewJWKSCache(NewServiceClient(server.URL, crypto.AuthService), time.Minute)
+
+	_, err = cache.PublicKey(kid)
+	require.NoError(t, err)
+
+	// The cache goes stale while the auth service hangs
+	cache.mu.Lock()
+	cache.fetchedAt = time.Now().Add(-time.Hour)
+	cache.mu.Unlock()
+	cache.attemptedAt = time.Now().Add(-time.Hour)
+	server.block.Store(true)
+	defer close(server.release)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := cache.PublicKey(kid)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("known key lookup waited for the JWKS fetch")
+	}
+}
+
+func TestJWKSCache_ConcurrentMissesShareOneFetch(t *testing.T) {
+	keys, err := crypto.NewEphemeralKeySet()
+	require.NoError(t, err)
+	kid := keys.SigningKey().ID
+
+	server := newJWKSServer(t, keys)
+	cache := NewJWKSCache(NewServiceClient(server.URL, crypto.AuthService), time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := cache.PublicKey(kid)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), server.requests.Load())
+
+	// Unknown kids do not trigger another fetch within the refresh interval
+	_, err = cache.PublicKey("unknown")
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), server.requests.Load())
+}
@@ -2,17 +2,232 @@ package middleware
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"strings"
 
+	"github.com/raufhm/fairflow/shared/crypto"
 	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/logger"
+	"go.uber.org/zap"
 )
 
 type contextKey string
 
 const (
-	UserContextKey contextKey = "user"
+	UserContextKey         contextKey = "user"
+	SessionContextKey      contextKey = "session"
+	APIKeyContextKey       contextKey = "api_key"
+	ImpersonatorContextKey contextKey = "impersonator"
 )
 
+// Authenticator resolves the calling user from a Bearer JWT or an X-Api-Key header
+type Authenticator struct {
+	userRepo     domain.UserRepository
+	apiKeyRepo   domain.APIKeyRepository
+	sessionRepo  domain.SessionRepository
+	auditRepo    domain.AuditLogRepository
+	tokenService *crypto.TokenService
+	apiKeySecret string
+}
+
+// NewAuthenticator creates a new authenticator. Access tokens are verified with
+// tokenService; API keys are hashed with apiKeySecret before lookup. Requests
+// made with an impersonation token are recorded in auditRepo.
+func NewAuthenticator(
+	userRepo domain.UserRepository,
+	apiKeyRepo domain.APIKeyRepository,
+	sessionRepo domain.SessionRepository,
+	auditRepo domain.AuditLogRepository,
+	tokenService *crypto.TokenService,
+	apiKeySecret string,
+) *Authenticator {
+	return &Authenticator{
+		userRepo:     userRepo,
+		apiKeyRepo:   apiKeyRepo,
+		sessionRepo:  sessionRepo,
+		auditRepo:    auditRepo,
+		tokenService: tokenService,
+		apiKeySecret: apiKeySecret,
+	}
+}
+
+// Authenticate middleware rejects requests without valid credentials
+func (a *Authenticator) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !hasCredentials(r) {
+			http.Error(w, `{"message":"Authentication required"}`, http.StatusUnauthorized)
+			return
+		}
+
+		ctx, ok := a.resolveIdentity(r)
+		if !ok {
+			http.Error(w, `{"message":"Invalid or expired credentials"}`, http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// OptionalAuth middleware populates the user when credentials are present
+// but lets anonymous requests through. Invalid credentials are still rejected.
+func (a *Authenticator) OptionalAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !hasCredentials(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, ok := a.resolveIdentity(r)
+		if !ok {
+			http.Error(w, `{"message":"Invalid or expired credentials"}`, http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// resolveIdentity loads the user for the credentials on the request and
+// returns a context carrying it. API keys take precedence over Bearer tokens
+// when both are sent.
+func (a *Authenticator) resolveIdentity(r *http.Request) (context.Context, bool) {
+	ctx := r.Context()
+
+	if rawKey := r.Header.Get("X-Api-Key"); rawKey != "" {
+		user, apiKey, ok := a.userFromAPIKey(ctx, rawKey)
+		if !ok {
+			return nil, false
+		}
+		ctx = context.WithValue(ctx, UserContextKey, user)
+		return context.WithValue(ctx, APIKeyContextKey, apiKey), true
+	}
+
+	token := bearerToken(r)
+	if token == "" {
+		return nil, false
+	}
+
+	claims, err := a.tokenService.VerifyToken(token)
+	if err != nil {
+		return nil, false
+	}
+
+	// Access tokens are bound to a session so that logout revokes them immediately.
+	// Impersonation sessions belong to the real actor.
+	sessionOwner := claims.UserID
+	if claims.IsImpersonation() {
+		sessionOwner = claims.ImpersonatorID
+	}
+	session, err := a.sessionRepo.GetByID(ctx, claims.SessionID)
+	if err != nil || session == nil || session.UserID != sessionOwner || !session.IsActive() {
+		return nil, false
+	}
+
+	user, err := a.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil || user == nil || !user.IsActive() {
+		return nil, false
+	}
+
+	// Update last seen timestamp (async, ignore errors)
+	go a.sessionRepo.Touch(context.Background(), session.ID, ClientIP(r))
+
+	ctx = context.WithValue(ctx, UserContextKey, user)
+	ctx = context.WithValue(ctx, SessionContextKey, session.ID)
+
+	if claims.IsImpersonation() {
+		// The real actor must still be allowed to impersonate
+		impersonator, err := a.userRepo.GetByID(ctx, claims.ImpersonatorID)
+		if err != nil || impersonator == nil || !impersonator.IsActive() || !impersonator.IsSuperAdmin() {
+			return nil, false
+		}
+		ctx = context.WithValue(ctx, ImpersonatorContextKey, impersonator)
+		ctx = domain.WithImpersonator(ctx, impersonator.ID)
+		go a.auditImpersonatedRequest(r, user, impersonator)
+	}
+
+	return ctx, true
+}
+
+// auditImpersonatedRequest records a request made while impersonating a user
+func (a *Authenticator) auditImpersonatedRequest(r *http.Request, user, impersonator *domain.User) {
+	resourceType := "user"
+	details := fmt.Sprintf("%s %s", r.Method, r.URL.Path)
+	entry := &domain.AuditLog{
+		UserID:         &user.ID,
+		UserName:       user.Name,
+		Action:         "impersonated_request",
+		ResourceType:   &resourceType,
+		ResourceID:     &user.ID,
+		Details:        &details,
+		IPAddress:      ClientIP(r),
+		ImpersonatorID: &impersonator.ID,
+	}
+
+	if err := a.auditRepo.Create(context.Background(), entry); err != nil {
+		logger.Log.Error("Failed to write audit log", zap.String("action", entry.Action), zap.Error(err))
+	}
+}
+
+// userFromAPIKey validates a raw API key and loads its owner
+func (a *Authenticator) userFromAPIKey(ctx context.Context, rawKey string) (*domain.User, *domain.APIKey, bool) {
+	keyHash := crypto.HashAPIKey(rawKey, a.apiKeySecret)
+	apiKey, err := a.apiKeyRepo.GetByHash(ctx, keyHash)
+	if err != nil || apiKey == nil || !apiKey.IsUsable() {
+		return nil, nil, false
+	}
+
+	user, err := a.userRepo.GetByID(ctx, apiKey.UserID)
+	if err != nil || user == nil || !user.IsActive() {
+		return nil, nil, false
+	}
+
+	// Update last used timestamp (async, ignore errors)
+	go a.apiKeyRepo.UpdateLastUsed(context.Background(), apiKey.ID)
+
+	return user, apiKey, true
+}
+
+// hasCredentials reports whether the request carries any authentication header
+func hasCredentials(r *http.Request) bool {
+	return r.Header.Get("X-Api-Key") != "" || r.Header.Get("Authorization") != ""
+}
+
+// bearerToken extracts the token from an "Authorization: Bearer <token>" header
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(header[7:])
+}
+
+// RequireVerifiedEmail rejects signed-in users who have not verified their email
+// address. Anonymous requests are passed through.
+func RequireVerifiedEmail(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := GetUserFromContext(r.Context())
+		if user != nil && !user.IsEmailVerified() {
+			http.Error(w, `{"message":"Forbidden: Verify your email address first"}`, http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// DenyImpersonation rejects impersonated requests on routes that manage the
+// caller's own credentials or other users
+func DenyImpersonation(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if GetImpersonatorFromContext(r.Context()) != nil {
+			http.Error(w, `{"message":"Forbidden: This endpoint cannot be called while impersonating"}`, http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // AdminOnly middleware ensures user is admin or super_admin
 func AdminOnly(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -57,3 +272,23 @@ func GetUserFromContext(ctx context.Context) *domain.User {
 	}
 	return user
 }
+
+// GetSessionIDFromContext retrieves the session ID of a token-authenticated request.
+// It returns 0 for requests authenticated with an API key.
+func GetSessionIDFromContext(ctx context.Context) int64 {
+	sessionID, ok := ctx.Value(SessionContextKey).(int64)
+	if !ok {
+		return 0
+	}
+	return sessionID
+}
+
+// GetImpersonatorFromContext retrieves the real actor of an impersonated request.
+// It returns nil when the caller is acting as themselves.
+func GetImpersonatorFromContext(ctx context.Context) *domain.User {
+	impersonator, ok := ctx.Value(ImpersonatorContextKey).(*domain.User)
+	if !ok {
+		return nil
+	}
+	return impersonator
+}
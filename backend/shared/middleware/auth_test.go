@@ -0,0 +1,143 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/crypto"
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+const apiKeySecret = "test-secret"
+
+// userRepo serves users by ID
+type userRepo struct {
+	domain.UserRepository
+	users map[int64]*domain.User
+}
+
+func (r userRepo) GetByID(ctx context.Context, id int64) (*domain.User, error) {
+	return r.users[id], nil
+}
+
+// apiKeyRepo serves API keys by hash
+type apiKeyRepo struct {
+	domain.APIKeyRepository
+	keys map[string]*domain.APIKey
+}
+
+func (r apiKeyRepo) GetByHash(ctx context.Context, keyHash string) (*domain.APIKey, error) {
+	return r.keys[keyHash], nil
+}
+
+func (r apiKeyRepo) UpdateLastUsed(ctx context.Context, id int64) error {
+	return nil
+}
+
+func TestAuthenticate_APIKey(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	users := userRepo{users: map[int64]*domain.User{
+		1: {ID: 1, Role: domain.RoleUser},
+		2: {ID: 2, Role: domain.RoleUser, DeactivatedAt: &past},
+	}}
+	keys := apiKeyRepo{keys: map[string]*domain.APIKey{
+		crypto.HashAPIKey("ff_valid", apiKeySecret):       {ID: 1, UserID: 1, Active: true},
+		crypto.HashAPIKey("ff_revoked", apiKeySecret):     {ID: 2, UserID: 1, Active: false},
+		crypto.HashAPIKey("ff_expired", apiKeySecret):     {ID: 3, UserID: 1, Active: true, ExpiresAt: &past},
+		crypto.HashAPIKey("ff_deactivated", apiKeySecret): {ID: 4, UserID: 2, Active: true},
+	}}
+	authenticator := middleware.NewAuthenticator(users, keys, nil, nil, nil, apiKeySecret)
+
+	var gotUser *domain.User
+	var gotKey *domain.APIKey
+	handler := authenticator.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUser = middleware.GetUserFromContext(r.Context())
+		gotKey = middleware.GetAPIKeyFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name string
+		key  string
+		want int
+	}{
+		{name: "no credentials", want: http.StatusUnauthorized},
+		{name: "valid key", key: "ff_valid", want: http.StatusOK},
+		{name: "unknown key", key: "ff_unknown", want: http.StatusUnauthorized},
+		{name: "revoked key", key: "ff_revoked", want: http.StatusUnauthorized},
+		{name: "expired key", key: "ff_expired", want: http.StatusUnauthorized},
+		{name: "deactivated owner", key: "ff_deactivated", want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotUser, gotKey = nil, nil
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/groups", nil)
+			if tt.key != "" {
+				req.Header.Set("X-Api-Key", tt.key)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.want, rec.Code)
+			if tt.want == http.StatusOK {
+				assert.Equal(t, int64(1), gotUser.ID)
+				assert.Equal(t, int64(1), gotKey.ID)
+			}
+		})
+	}
+}
+
+func TestRoleMiddleware(t *testing.T) {
+	now := time.Now()
+	user := &domain.User{ID: 1, Role: domain.RoleUser, EmailVerifiedAt: &now}
+	unverified := &domain.User{ID: 2, Role: domain.RoleUser}
+	orgAdmin := &domain.User{ID: 3, Role: domain.RoleOrgAdmin, OrganizationID: int64Ptr(1)}
+	admin := &domain.User{ID: 4, Role: domain.RoleAdmin}
+	superAdmin := &domain.User{ID: 5, Role: domain.RoleSuperAdmin}
+
+	tests := []struct {
+		name         string
+		middleware   func(http.Handler) http.Handler
+		user         *domain.User
+		impersonator *domain.User
+		want         int
+	}{
+		{name: "admin only allows admins", middleware: middleware.AdminOnly, user: admin, want: http.StatusOK},
+		{name: "admin only allows super admins", middleware: middleware.AdminOnly, user: superAdmin, want: http.StatusOK},
+		{name: "admin only rejects organization admins", middleware: middleware.AdminOnly, user: orgAdmin, want: http.StatusForbidden},
+		{name: "admin only rejects users", middleware: middleware.AdminOnly, user: user, want: http.StatusForbidden},
+		{name: "admin only rejects anonymous callers", middleware: middleware.AdminOnly, want: http.StatusUnauthorized},
+		{name: "super admin only allows super admins", middleware: middleware.SuperAdminOnly, user: superAdmin, want: http.StatusOK},
+		{name: "super admin only rejects admins", middleware: middleware.SuperAdminOnly, user: admin, want: http.StatusForbidden},
+		{name: "super admin only rejects anonymous callers", middleware: middleware.SuperAdminOnly, want: http.StatusUnauthorized},
+		{name: "verified email passes", middleware: middleware.RequireVerifiedEmail, user: user, want: http.StatusOK},
+		{name: "unverified email is rejected", middleware: middleware.RequireVerifiedEmail, user: unverified, want: http.StatusForbidden},
+		{name: "verified email lets anonymous callers through", middleware: middleware.RequireVerifiedEmail, want: http.StatusOK},
+		{name: "callers acting as themselves pass", middleware: middleware.DenyImpersonation, user: user, want: http.StatusOK},
+		{name: "impersonated callers are rejected", middleware: middleware.DenyImpersonation, user: user, impersonator: superAdmin, want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/users", nil)
+			if tt.user != nil {
+				req = withValue(req, middleware.UserContextKey, tt.user)
+			}
+			if tt.impersonator != nil {
+				req = withValue(req, middleware.ImpersonatorContextKey, tt.impersonator)
+			}
+			rec := httptest.NewRecorder()
+
+			tt.middleware(okHandler).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const ClientIPContextKey contextKey = "client_ip"
+
+// ParseTrustedProxies parses a list of proxy addresses, each a single IP or a CIDR range
+func ParseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", proxy)
+			}
+			bits := 8 * net.IPv4len
+			if ip.To4() == nil {
+				bits = 8 * net.IPv6len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// ResolveClientIP records the client address of each request for ClientIP.
+// X-Forwarded-For is only believed when the request comes from one of the
+// trusted proxies; the client is then the right-most hop that is not itself a
+// trusted proxy, since anything to its left was written by the client.
+func ResolveClientIP(trustedProxies []*net.IPNet, next http.Handler) http.Handler {
+	isTrusted := func(addr string) bool {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			return false
+		}
+		for _, network := range trustedProxies {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientIP := remoteIP(r)
+		if isTrusted(clientIP) {
+			var hops []string
+			for _, header := range r.Header.Values("X-Forwarded-For") {
+				for _, hop := range strings.Split(header, ",") {
+					if hop = strings.TrimSpace(hop); hop != "" {
+						hops = append(hops, hop)
+					}
+				}
+			}
+			for i := len(hops) - 1; i >= 0; i-- {
+				clientIP = hops[i]
+				if !isTrusted(clientIP) {
+					break
+				}
+			}
+		}
+
+		ctx := context.WithValue(r.Context(), ClientIPContextKey, clientIP)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ClientIP returns the originating client address of a request, as resolved by
+// ResolveClientIP, or the address of the connection's peer
+func ClientIP(r *http.Request) string {
+	if clientIP, ok := r.Context().Value(ClientIPContextKey).(string); ok {
+		return clientIP
+	}
+	return remoteIP(r)
+}
+
+// remoteIP returns the address of the connection's peer
+func remoteIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
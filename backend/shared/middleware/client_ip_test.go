@@ -0,0 +1,69 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/raufhm/fairflow/shared/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveClientIP(t *testing.T) {
+	trusted, err := middleware.ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{name: "direct client", remoteAddr: "203.0.113.7:5000", want: "203.0.113.7"},
+		{name: "forwarded header from an untrusted peer is ignored", remoteAddr: "203.0.113.7:5000", forwarded: []string{"198.51.100.1"}, want: "203.0.113.7"},
+		{name: "trusted proxy", remoteAddr: "10.1.2.3:5000", forwarded: []string{"198.51.100.1"}, want: "198.51.100.1"},
+		{name: "spoofed entries left of the real client", remoteAddr: "10.1.2.3:5000", forwarded: []string{"1.2.3.4, 5.6.7.8, 198.51.100.1"}, want: "198.51.100.1"},
+		{name: "chain of trusted proxies", remoteAddr: "10.1.2.3:5000", forwarded: []string{"1.2.3.4, 198.51.100.1, 192.168.1.1, 10.9.9.9"}, want: "198.51.100.1"},
+		{name: "repeated headers", remoteAddr: "10.1.2.3:5000", forwarded: []string{"1.2.3.4", "198.51.100.1, 10.0.0.2"}, want: "198.51.100.1"},
+		{name: "trusted proxy without a forwarded header", remoteAddr: "10.1.2.3:5000", want: "10.1.2.3"},
+		{name: "every hop trusted", remoteAddr: "10.1.2.3:5000", forwarded: []string{"10.0.0.5, 192.168.1.1"}, want: "10.0.0.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := middleware.ResolveClientIP(trusted, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = middleware.ClientIP(r)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestClientIP_IgnoresForwardedHeaderWithoutMiddleware(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", nil)
+	req.RemoteAddr = "203.0.113.7:5000"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+
+	assert.Equal(t, "203.0.113.7", middleware.ClientIP(req))
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	networks, err := middleware.ParseTrustedProxies([]string{"10.0.0.0/8", "::1", "172.16.0.1"})
+	require.NoError(t, err)
+	assert.Len(t, networks, 3)
+
+	_, err = middleware.ParseTrustedProxies([]string{"not-an-ip"})
+	assert.Error(t, err)
+	_, err = middleware.ParseTrustedProxies([]string{"10.0.0.0/99"})
+	assert.Error(t, err)
+}
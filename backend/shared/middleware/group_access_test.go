@@ -0,0 +1,115 @@
+package middleware_test
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+// groupRepo serves groups by ID
+type groupRepo struct {
+	domain.GroupRepository
+	groups map[int64]*domain.Group
+}
+
+func (r groupRepo) GetByID(ctx context.Context, id int64) (*domain.Group, error) {
+	group, ok := r.groups[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return group, nil
+}
+
+// grantRepo serves group grants keyed by group and user
+type grantRepo struct {
+	domain.GroupGrantRepository
+	grants map[[2]int64]*domain.GroupGrant
+}
+
+func (r grantRepo) Get(ctx context.Context, groupID, userID int64) (*domain.GroupGrant, error) {
+	return r.grants[[2]int64{groupID, userID}], nil
+}
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+func TestGroupAuthorizer_Enforce(t *testing.T) {
+	groups := groupRepo{groups: map[int64]*domain.Group{
+		1: {ID: 1, UserID: 10, OrganizationID: int64Ptr(1)},
+		2: {ID: 2, UserID: 20, OrganizationID: int64Ptr(2)},
+	}}
+	grants := grantRepo{grants: map[[2]int64]*domain.GroupGrant{
+		{1, 11}: {GroupID: 1, UserID: 11, Role: domain.GroupRoleViewer},
+		{1, 12}: {GroupID: 1, UserID: 12, Role: domain.GroupRoleManager},
+	}}
+	authorizer := middleware.NewGroupAuthorizer(groups, grants)
+
+	policy := middleware.GroupAccessPolicy{
+		Read:    domain.GroupRoleViewer,
+		Write:   domain.GroupRoleManager,
+		Delete:  domain.GroupRoleOwner,
+		GroupID: middleware.GroupIDFromPath("/api/v1/groups/"),
+	}
+
+	var gotGroup *domain.Group
+	var gotRole domain.GroupRole
+	handler := authorizer.Enforce(policy, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotGroup = middleware.GetGroupFromContext(r.Context())
+		gotRole = middleware.GetGroupRoleFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	owner := &domain.User{ID: 10, Role: domain.RoleUser, OrganizationID: int64Ptr(1)}
+	viewer := &domain.User{ID: 11, Role: domain.RoleUser, OrganizationID: int64Ptr(1)}
+	manager := &domain.User{ID: 12, Role: domain.RoleUser, OrganizationID: int64Ptr(1)}
+	stranger := &domain.User{ID: 13, Role: domain.RoleUser, OrganizationID: int64Ptr(1)}
+	orgAdmin := &domain.User{ID: 14, Role: domain.RoleOrgAdmin, OrganizationID: int64Ptr(1)}
+	otherOrgAdmin := &domain.User{ID: 20, Role: domain.RoleOrgAdmin, OrganizationID: int64Ptr(2)}
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		user     *domain.User
+		want     int
+		wantRole domain.GroupRole
+	}{
+		{name: "route without a group", method: http.MethodGet, path: "/api/v1/groups/", want: http.StatusOK},
+		{name: "anonymous caller", method: http.MethodGet, path: "/api/v1/groups/1", want: http.StatusUnauthorized},
+		{name: "missing group", method: http.MethodGet, path: "/api/v1/groups/9", user: owner, want: http.StatusNotFound},
+		{name: "group in another organization", method: http.MethodGet, path: "/api/v1/groups/1", user: otherOrgAdmin, want: http.StatusNotFound},
+		{name: "member of the organization without a grant", method: http.MethodGet, path: "/api/v1/groups/1", user: stranger, want: http.StatusForbidden},
+		{name: "viewer reads", method: http.MethodGet, path: "/api/v1/groups/1", user: viewer, want: http.StatusOK, wantRole: domain.GroupRoleViewer},
+		{name: "viewer cannot write", method: http.MethodPut, path: "/api/v1/groups/1", user: viewer, want: http.StatusForbidden},
+		{name: "manager writes", method: http.MethodPut, path: "/api/v1/groups/1", user: manager, want: http.StatusOK, wantRole: domain.GroupRoleManager},
+		{name: "manager cannot delete", method: http.MethodDelete, path: "/api/v1/groups/1", user: manager, want: http.StatusForbidden},
+		{name: "owner deletes", method: http.MethodDelete, path: "/api/v1/groups/1", user: owner, want: http.StatusOK, wantRole: domain.GroupRoleOwner},
+		{name: "organization admin deletes", method: http.MethodDelete, path: "/api/v1/groups/1", user: orgAdmin, want: http.StatusOK, wantRole: domain.GroupRoleOwner},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotGroup, gotRole = nil, ""
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.user != nil {
+				req = withValue(req, middleware.UserContextKey, tt.user)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.want, rec.Code)
+			assert.Equal(t, tt.wantRole, gotRole)
+			if tt.wantRole != "" {
+				assert.Equal(t, int64(1), gotGroup.ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+// okHandler responds 200 so tests can tell whether a middleware let the request through
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+// withValue returns a copy of r carrying a context value
+func withValue(r *http.Request, key, value any) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), key, value))
+}
+
+func TestEnforceAPIKeyPolicy(t *testing.T) {
+	policy := middleware.APIKeyPolicy{
+		ReadScope:  domain.ScopeMembersRead,
+		WriteScope: domain.ScopeMembersWrite,
+		GroupID:    middleware.GroupIDFromPath("/api/v1/groups/"),
+	}
+	handler := middleware.EnforceAPIKeyPolicy(policy, okHandler)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		apiKey *domain.APIKey
+		want   int
+	}{
+		{name: "session callers are not affected", method: http.MethodPost, path: "/api/v1/groups/1/members", want: http.StatusOK},
+		{name: "unrestricted key", method: http.MethodPost, path: "/api/v1/groups/1/members", apiKey: &domain.APIKey{}, want: http.StatusOK},
+		{name: "read scope allows reads", method: http.MethodGet, path: "/api/v1/groups/1/members", apiKey: &domain.APIKey{Scopes: []string{domain.ScopeMembersRead}}, want: http.StatusOK},
+		{name: "read scope does not allow writes", method: http.MethodPost, path: "/api/v1/groups/1/members", apiKey: &domain.APIKey{Scopes: []string{domain.ScopeMembersRead}}, want: http.StatusForbidden},
+		{name: "write scope does not allow reads", method: http.MethodGet, path: "/api/v1/groups/1/members", apiKey: &domain.APIKey{Scopes: []string{domain.ScopeMembersWrite}}, want: http.StatusForbidden},
+		{name: "another resource's scope", method: http.MethodGet, path: "/api/v1/groups/1/members", apiKey: &domain.APIKey{Scopes: []string{domain.ScopeGroupsRead}}, want: http.StatusForbidden},
+		{name: "group in the allow-list", method: http.MethodGet, path: "/api/v1/groups/1/members", apiKey: &domain.APIKey{GroupIDs: []int64{1, 2}}, want: http.StatusOK},
+		{name: "group outside the allow-list", method: http.MethodGet, path: "/api/v1/groups/3/members", apiKey: &domain.APIKey{GroupIDs: []int64{1, 2}}, want: http.StatusForbidden},
+		{name: "route without a group", method: http.MethodGet, path: "/api/v1/groups/", apiKey: &domain.APIKey{GroupIDs: []int64{1, 2}}, want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.apiKey != nil {
+				req = withValue(req, middleware.APIKeyContextKey, tt.apiKey)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
+
+func TestDenyAPIKeys(t *testing.T) {
+	handler := middleware.DenyAPIKeys(okHandler)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/api-keys", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	req := withValue(httptest.NewRequest(http.MethodGet, "/api/v1/api-keys", nil), middleware.APIKeyContextKey, &domain.APIKey{})
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/raufhm/fairflow/shared/crypto"
+	"github.com/raufhm/fairflow/shared/httpclient"
+)
+
+const ServiceContextKey contextKey = "service"
+
+// ServiceAuthenticator verifies the identity tokens that ServiceClient attaches
+// to calls between services
+type ServiceAuthenticator struct {
+	tokens *crypto.ServiceTokenService
+}
+
+// NewServiceAuthenticator creates a service authenticator. Only tokens issued for
+// the token service's own name are accepted.
+func NewServiceAuthenticator(tokens *crypto.ServiceTokenService) *ServiceAuthenticator {
+	return &ServiceAuthenticator{tokens: tokens}
+}
+
+// RequireService rejects requests that are not signed by one of the allowed services
+func (a *ServiceAuthenticator) RequireService(allowed []string, next http.Handler) http.Handler {
+	allowedServices := make(map[string]bool, len(allowed))
+	for _, service := range allowed {
+		allowedServices[service] = true
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get(httpclient.ServiceTokenHeader)
+		if token == "" {
+			http.Error(w, `{"message":"Service authentication required"}`, http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := a.tokens.Verify(token)
+		if err != nil {
+			http.Error(w, `{"message":"Invalid or expired service token"}`, http.StatusUnauthorized)
+			return
+		}
+
+		if !allowedServices[claims.Service()] {
+			http.Error(w, fmt.Sprintf(`{"message":"Forbidden: %s may not call this endpoint"}`, claims.Service()), http.StatusForbidden)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), ServiceContextKey, claims.Service())
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// GetServiceFromContext returns the name of the service that made the request,
+// or an empty string for requests that were not service authenticated
+func GetServiceFromContext(ctx context.Context) string {
+	service, _ := ctx.Value(ServiceContextKey).(string)
+	return service
+}
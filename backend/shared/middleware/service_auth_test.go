@@ -0,0 +1,102 @@
+package middleware_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/crypto"
+	"github.com/raufhm/fairflow/shared/httpclient"
+	"github.com/raufhm/fairflow/shared/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serviceTokens returns a token service for each named service, all trusting each other
+func serviceTokens(t *testing.T, services ...string) map[string]*crypto.ServiceTokenService {
+	privateKeys := make(map[string]ed25519.PrivateKey, len(services))
+	publicKeys := make(map[string]ed25519.PublicKey, len(services))
+	for _, service := range services {
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		privateKeys[service] = private
+		publicKeys[service] = public
+	}
+
+	tokens := make(map[string]*crypto.ServiceTokenService, len(services))
+	for _, service := range services {
+		tokens[service] = crypto.NewServiceTokenService(service, privateKeys[service], publicKeys, time.Minute)
+	}
+	return tokens
+}
+
+func TestRequireService(t *testing.T) {
+	tokens := serviceTokens(t, crypto.AuthService, crypto.GroupService, crypto.WebhookService)
+	auth := middleware.NewServiceAuthenticator(tokens[crypto.AuthService])
+
+	var caller string
+	handler := auth.RequireService([]string{crypto.GroupService}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller = middleware.GetServiceFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	sign := func(service, audience string) string {
+		token, err := tokens[service].Generate(audience)
+		require.NoError(t, err)
+		return token
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{name: "allowed service", token: sign(crypto.GroupService, crypto.AuthService), status: http.StatusOK},
+		{name: "service not on the allow-list", token: sign(crypto.WebhookService, crypto.AuthService), status: http.StatusForbidden},
+		{name: "token for another service", token: sign(crypto.GroupService, crypto.WebhookService), status: http.StatusUnauthorized},
+		{name: "garbage token", token: "not-a-token", status: http.StatusUnauthorized},
+		{name: "no token", status: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			caller = ""
+			req := httptest.NewRequest(http.MethodGet, "/internal/v1/jwks.json", nil)
+			if tt.token != "" {
+				req.Header.Set(httpclient.ServiceTokenHeader, tt.token)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.status, rec.Code)
+			if tt.status == http.StatusOK {
+				assert.Equal(t, crypto.GroupService, caller)
+			} else {
+				assert.Empty(t, caller)
+			}
+		})
+	}
+}
+
+func TestRequireService_ServiceClientIsAccepted(t *testing.T) {
+	tokens := serviceTokens(t, crypto.AuthService, crypto.AssignmentService)
+	auth := middleware.NewServiceAuthenticator(tokens[crypto.AuthService])
+
+	server := httptest.NewServer(auth.RequireService([]string{crypto.AssignmentService}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(middleware.GetServiceFromContext(r.Context())))
+	})))
+	defer server.Close()
+
+	client := httpclient.NewServiceClient(server.URL, crypto.AuthService).WithServiceAuth(tokens[crypto.AssignmentService])
+	body, err := client.Get(t.Context(), "/internal/v1/jwks.json")
+	require.NoError(t, err)
+	assert.Equal(t, crypto.AssignmentService, string(body))
+
+	// Unsigned calls are turned away
+	_, err = httpclient.NewServiceClient(server.URL, crypto.AuthService).Get(t.Context(), "/internal/v1/jwks.json")
+	assert.Error(t, err)
+}
@@ -0,0 +1,344 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/raufhm/fairflow/shared/crypto"
+)
+
+// discoveryPath is where providers publish their configuration (OpenID Connect Discovery 1.0)
+const discoveryPath = "/.well-known/openid-configuration"
+
+// minKeyRefreshInterval limits JWKS refetches triggered by unknown key ids
+const minKeyRefreshInterval = 30 * time.Second
+
+var ErrInvalidIDToken = errors.New("invalid ID token")
+
+// Config describes the FairFlow client registration at an OpenID provider
+type Config struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	GroupsClaim  string // Claim holding the user's IdP groups, usually "groups"
+}
+
+// IDTokenClaims are the ID token claims FairFlow uses
+type IDTokenClaims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Groups        []string
+}
+
+// discoveryDocument is the subset of the provider metadata FairFlow needs
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider is an OpenID Connect relying party for a single identity provider.
+// Discovery happens lazily so the auth service can start while the IdP is down.
+type Provider struct {
+	config     Config
+	httpClient *http.Client
+
+	mu            sync.RWMutex
+	discovery     *discoveryDocument
+	keys          map[string]interface{}
+	keysFetchedAt time.Time
+}
+
+// NewProvider creates a provider client for the given registration
+func NewProvider(config Config, httpClient *http.Client) *Provider {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email", "profile"}
+	}
+	if config.GroupsClaim == "" {
+		config.GroupsClaim = "groups"
+	}
+	config.IssuerURL = strings.TrimSuffix(config.IssuerURL, "/")
+
+	return &Provider{
+		config:     config,
+		httpClient: httpClient,
+		keys:       make(map[string]interface{}),
+	}
+}
+
+// AuthCodeURL builds the authorization request URL for the authorization code
+// flow with PKCE (RFC 7636, S256)
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", p.config.ClientID)
+	params.Set("redirect_uri", p.config.RedirectURL)
+	params.Set("scope", strings.Join(p.config.Scopes, " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", codeChallenge)
+	params.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(doc.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return doc.AuthorizationEndpoint + separator + params.Encode(), nil
+}
+
+// Exchange redeems an authorization code at the token endpoint and returns the raw ID token
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (string, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("client_id", p.config.ClientID)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, doc.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned status %d: %s", resp.StatusCode, body)
+	}
+
+	var token struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if token.IDToken == "" {
+		return "", fmt.Errorf("token response has no id_token")
+	}
+	return token.IDToken, nil
+}
+
+// VerifyIDToken checks the ID token signature against the provider's JWKS and
+// validates the issuer, audience, expiry and nonce
+func (p *Provider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (*IDTokenClaims, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, err := p.publicKey(ctx, doc, kid)
+		if err != nil {
+			return nil, err
+		}
+
+		// The key type must match the algorithm family in the header
+		switch key.(type) {
+		case *rsa.PublicKey:
+			if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+		case *ecdsa.PublicKey:
+			if _, ok := token.Method.(*jwt.SigningMethodECDSA); !ok {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+		case ed25519.PublicKey:
+			if token.Method != jwt.SigningMethodEdDSA {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+		default:
+			return nil, fmt.Errorf("unsupported key type for key id %s", kid)
+		}
+		return key, nil
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "EdDSA"}),
+		jwt.WithIssuer(doc.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	if tokenNonce, _ := claims["nonce"].(string); nonce == "" || tokenNonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	// With several audiences the authorized party must be us (OIDC Core 3.1.3.7)
+	if aud, ok := claims["aud"].([]interface{}); ok && len(aud) > 1 {
+		if azp, _ := claims["azp"].(string); azp != p.config.ClientID {
+			return nil, fmt.Errorf("%w: unexpected authorized party", ErrInvalidIDToken)
+		}
+	}
+
+	result := &IDTokenClaims{}
+	result.Subject, _ = claims["sub"].(string)
+	result.Email, _ = claims["email"].(string)
+	result.EmailVerified, _ = claims["email_verified"].(bool)
+	result.Name, _ = claims["name"].(string)
+	result.Groups = stringList(claims[p.config.GroupsClaim])
+
+	if result.Subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	}
+	return result, nil
+}
+
+// Issuer returns the configured issuer URL
+func (p *Provider) Issuer() string {
+	return p.config.IssuerURL
+}
+
+// discover loads and caches the provider's discovery document
+func (p *Provider) discover(ctx context.Context) (*discoveryDocument, error) {
+	p.mu.RLock()
+	doc := p.discovery
+	p.mu.RUnlock()
+	if doc != nil {
+		return doc, nil
+	}
+
+	body, err := p.get(ctx, p.config.IssuerURL+discoveryPath)
+	if err != nil {
+		return nil, fmt.Errorf("OIDC discovery failed: %w", err)
+	}
+
+	doc = &discoveryDocument{}
+	if err := json.Unmarshal(body, doc); err != nil {
+		return nil, fmt.Errorf("failed to decode discovery document: %w", err)
+	}
+	if strings.TrimSuffix(doc.Issuer, "/") != p.config.IssuerURL {
+		return nil, fmt.Errorf("discovery issuer %q does not match configured issuer %q", doc.Issuer, p.config.IssuerURL)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, fmt.Errorf("discovery document is missing required endpoints")
+	}
+
+	p.mu.Lock()
+	p.discovery = doc
+	p.mu.Unlock()
+	return doc, nil
+}
+
+// publicKey returns the provider key for a kid, refetching the JWKS when the
+// kid is unknown so provider key rotation is picked up
+func (p *Provider) publicKey(ctx context.Context, doc *discoveryDocument, kid string) (interface{}, error) {
+	p.mu.RLock()
+	key, ok := p.keys[kid]
+	recentlyFetched := time.Since(p.keysFetchedAt) < minKeyRefreshInterval
+	p.mu.RUnlock()
+
+	if ok {
+		return key, nil
+	}
+	if !recentlyFetched {
+		body, err := p.get(ctx, doc.JWKSURI)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch provider JWKS: %w", err)
+		}
+		keys, err := crypto.ParseJWKS(body)
+		if err != nil {
+			return nil, err
+		}
+
+		p.mu.Lock()
+		p.keys = keys
+		p.keysFetchedAt = time.Now()
+		p.mu.Unlock()
+
+		if key, ok := keys[kid]; ok {
+			return key, nil
+		}
+		// Providers with a single key may omit the kid
+		if kid == "" && len(keys) == 1 {
+			for _, key := range keys {
+				return key, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (p *Provider) get(ctx context.Context, target string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d", target, resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// stringList reads a claim that may be a single string or a list of strings
+func stringList(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
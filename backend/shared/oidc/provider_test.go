@@ -0,0 +1,101 @@
+package oidc_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/raufhm/fairflow/shared/oidc"
+	"github.com/raufhm/fairflow/shared/oidc/oidctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const redirectURL = "http://localhost:3001/api/v1/auth/oidc/callback"
+
+func newProvider(t *testing.T) (*oidctest.Provider, *oidc.Provider) {
+	mock, err := oidctest.NewProvider("fairflow", "secret")
+	require.NoError(t, err)
+	t.Cleanup(mock.Close)
+
+	provider := oidc.NewProvider(oidc.Config{
+		IssuerURL:    mock.Issuer(),
+		ClientID:     "fairflow",
+		ClientSecret: "secret",
+		RedirectURL:  redirectURL,
+	}, nil)
+	return mock, provider
+}
+
+// authorize follows the authorization URL and returns the code and state sent back to the redirect URL
+func authorize(t *testing.T, authURL string) (string, string) {
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func TestProvider_AuthorizationCodeFlow(t *testing.T) {
+	mock, provider := newProvider(t)
+	mock.SetUser(oidctest.User{
+		Subject:       "user-123",
+		Email:         "jane@example.com",
+		EmailVerified: true,
+		Name:          "Jane",
+		Groups:        []string{"fairflow-admins"},
+	})
+	ctx := context.Background()
+
+	verifier, err := oidc.RandomString()
+	require.NoError(t, err)
+
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", oidc.CodeChallenge(verifier))
+	require.NoError(t, err)
+
+	code, state := authorize(t, authURL)
+	assert.Equal(t, "state-1", state)
+
+	idToken, err := provider.Exchange(ctx, code, verifier)
+	require.NoError(t, err)
+
+	claims, err := provider.VerifyIDToken(ctx, idToken, "nonce-1")
+	require.NoError(t, err)
+	assert.Equal(t, "user-123", claims.Subject)
+	assert.Equal(t, "jane@example.com", claims.Email)
+	assert.True(t, claims.EmailVerified)
+	assert.Equal(t, []string{"fairflow-admins"}, claims.Groups)
+}
+
+func TestProvider_ExchangeRejectsWrongVerifier(t *testing.T) {
+	_, provider := newProvider(t)
+	ctx := context.Background()
+
+	verifier, err := oidc.RandomString()
+	require.NoError(t, err)
+
+	authURL, err := provider.AuthCodeURL(ctx, "state", "nonce", oidc.CodeChallenge(verifier))
+	require.NoError(t, err)
+
+	code, _ := authorize(t, authURL)
+
+	_, err = provider.Exchange(ctx, code, "not-the-verifier")
+	assert.Error(t, err)
+}
+
+func TestProvider_VerifyIDTokenRejectsNonceMismatch(t *testing.T) {
+	mock, provider := newProvider(t)
+
+	idToken, err := mock.SignIDToken(oidctest.User{Subject: "user-123"}, "nonce-1")
+	require.NoError(t, err)
+
+	_, err = provider.VerifyIDToken(context.Background(), idToken, "nonce-2")
+	assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+}
@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/raufhm/fairflow/shared/domain"
@@ -51,13 +52,19 @@ func (r *apiKeyRepository) GetByUserID(ctx context.Context, userID int64) ([]*do
 	if err != nil {
 		return nil, err
 	}
+	return apiKeys, nil
+}
 
-	// Set Active field for all keys
-	for _, key := range apiKeys {
-		key.Active = true
+func (r *apiKeyRepository) GetByReplacesID(ctx context.Context, id int64) (*domain.APIKey, error) {
+	apiKey := new(domain.APIKey)
+	err := r.db.NewSelect().Model(apiKey).Where("replaces_id = ?", id).Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil, nil
 	}
-
-	return apiKeys, nil
+	if err != nil {
+		return nil, err
+	}
+	return apiKey, nil
 }
 
 func (r *apiKeyRepository) Delete(ctx context.Context, id int64) error {
@@ -65,6 +72,36 @@ func (r *apiKeyRepository) Delete(ctx context.Context, id int64) error {
 	return err
 }
 
+func (r *apiKeyRepository) Deactivate(ctx context.Context, id int64) error {
+	_, err := r.db.NewUpdate().
+		Model(&domain.APIKey{}).
+		Set("active = ?", false).
+		Set("revoked_at = ?", time.Now()).
+		Where("id = ?", id).
+		Exec(ctx)
+	return err
+}
+
+func (r *apiKeyRepository) DeactivateByUserID(ctx context.Context, userID int64) error {
+	_, err := r.db.NewUpdate().
+		Model(&domain.APIKey{}).
+		Set("active = ?", false).
+		Set("revoked_at = ?", time.Now()).
+		Where("user_id = ?", userID).
+		Where("active = ?", true).
+		Exec(ctx)
+	return err
+}
+
+func (r *apiKeyRepository) UpdateExpiry(ctx context.Context, id int64, expiresAt time.Time) error {
+	_, err := r.db.NewUpdate().
+		Model(&domain.APIKey{}).
+		Set("expires_at = ?", expiresAt).
+		Where("id = ?", id).
+		Exec(ctx)
+	return err
+}
+
 func (r *apiKeyRepository) UpdateLastUsed(ctx context.Context, id int64) error {
 	_, err := r.db.NewUpdate().
 		Model(&domain.APIKey{}).
@@ -73,3 +110,28 @@ func (r *apiKeyRepository) UpdateLastUsed(ctx context.Context, id int64) error {
 		Exec(ctx)
 	return err
 }
+
+func (r *apiKeyRepository) GetExpiring(ctx context.Context, before time.Time) ([]*domain.APIKey, error) {
+	var apiKeys []*domain.APIKey
+	err := r.db.NewSelect().
+		Model(&apiKeys).
+		Where("active = ?", true).
+		Where("expires_at > ?", time.Now()).
+		Where("expires_at <= ?", before).
+		Where("expiry_notified_at IS NULL").
+		Order("expires_at").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return apiKeys, nil
+}
+
+func (r *apiKeyRepository) MarkExpiryNotified(ctx context.Context, id int64) error {
+	_, err := r.db.NewUpdate().
+		Model(&domain.APIKey{}).
+		Set("expiry_notified_at = ?", time.Now()).
+		Where("id = ?", id).
+		Exec(ctx)
+	return err
+}
@@ -3,6 +3,7 @@ package postgres_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/raufhm/fairflow/shared/domain"
@@ -66,6 +67,39 @@ func TestAPIKeyRepository_GetByHash(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestAPIKeyRepository_GetByReplacesID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	apiKeyRepo := postgres.NewAPIKeyRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"id", "replaces_id"}).AddRow(2, 1)
+	mock.ExpectQuery(`SELECT (.+) FROM "api_keys" AS "api_key" WHERE \(replaces_id = 1\)`).WillReturnRows(rows)
+
+	successor, err := apiKeyRepo.GetByReplacesID(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), successor.ID)
+}
+
+func TestAPIKeyRepository_GetByReplacesID_NotRotated(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	apiKeyRepo := postgres.NewAPIKeyRepository(bunDB)
+
+	mock.ExpectQuery(`SELECT (.+) FROM "api_keys"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	successor, err := apiKeyRepo.GetByReplacesID(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Nil(t, successor)
+}
+
 func TestAPIKeyRepository_GetByUserID(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
@@ -111,3 +145,80 @@ func TestAPIKeyRepository_UpdateLastUsed(t *testing.T) {
 
 	assert.NoError(t, err)
 }
+
+func TestAPIKeyRepository_Deactivate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	apiKeyRepo := postgres.NewAPIKeyRepository(bunDB)
+
+	mock.ExpectExec(`UPDATE "api_keys"`).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = apiKeyRepo.Deactivate(context.Background(), 1)
+
+	assert.NoError(t, err)
+}
+
+func TestAPIKeyRepository_DeactivateByUserID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	apiKeyRepo := postgres.NewAPIKeyRepository(bunDB)
+
+	mock.ExpectExec(`UPDATE "api_keys" AS "api_key" SET active = FALSE, (.+) WHERE \(user_id = 7\) AND \(active = TRUE\)`).WillReturnResult(sqlmock.NewResult(0, 2))
+
+	err = apiKeyRepo.DeactivateByUserID(context.Background(), 7)
+
+	assert.NoError(t, err)
+}
+
+func TestAPIKeyRepository_UpdateExpiry(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	apiKeyRepo := postgres.NewAPIKeyRepository(bunDB)
+
+	mock.ExpectExec(`UPDATE "api_keys"`).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = apiKeyRepo.UpdateExpiry(context.Background(), 1, time.Now().Add(time.Hour))
+
+	assert.NoError(t, err)
+}
+
+func TestAPIKeyRepository_GetExpiring(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	apiKeyRepo := postgres.NewAPIKeyRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"id", "user_id"}).AddRow(1, 1)
+	mock.ExpectQuery(`SELECT (.+) FROM "api_keys"`).WillReturnRows(rows)
+
+	keys, err := apiKeyRepo.GetExpiring(context.Background(), time.Now().Add(24*time.Hour))
+
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+}
+
+func TestAPIKeyRepository_MarkExpiryNotified(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	apiKeyRepo := postgres.NewAPIKeyRepository(bunDB)
+
+	mock.ExpectExec(`UPDATE "api_keys"`).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = apiKeyRepo.MarkExpiryNotified(context.Background(), 1)
+
+	assert.NoError(t, err)
+}
@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/raufhm/fairflow/shared/domain"
@@ -23,13 +24,30 @@ func (r *assignmentRepository) Create(ctx context.Context, assignment *domain.As
 	if assignment.Status == "" {
 		assignment.Status = domain.AssignmentStatusOpen
 	}
-	_, err := r.db.NewInsert().Model(assignment).Exec(ctx)
+	_, err := idb(ctx, r.db).NewInsert().Model(assignment).Exec(ctx)
 	return err
 }
 
 func (r *assignmentRepository) GetByID(ctx context.Context, id int64) (*domain.Assignment, error) {
 	assignment := &domain.Assignment{}
-	err := r.db.NewSelect().Model(assignment).Where("id = ?", id).Scan(ctx)
+	err := idb(ctx, r.db).NewSelect().Model(assignment).Where("id = ?", id).Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return assignment, nil
+}
+
+// GetByExternalRef returns the group's assignment with the caller's reference, or nil
+func (r *assignmentRepository) GetByExternalRef(ctx context.Context, groupID int64, externalRef string) (*domain.Assignment, error) {
+	assignment := new(domain.Assignment)
+	err := idb(ctx, r.db).NewSelect().
+		Model(assignment).
+		Where("group_id = ?", groupID).
+		Where("external_ref = ?", externalRef).
+		Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -38,24 +56,61 @@ func (r *assignmentRepository) GetByID(ctx context.Context, id int64) (*domain.A
 
 func (r *assignmentRepository) UpdateStatus(ctx context.Context, id int64, status domain.AssignmentStatus) error {
 	now := time.Now()
-	update := r.db.NewUpdate().
+	update := idb(ctx, r.db).NewUpdate().
 		Model(&domain.Assignment{}).
 		Set("status = ?", status).
 		Where("id = ?", id)
 
-	// Set completed_at timestamp if status is completed or cancelled
+	// A status change always ends the wait for acceptance
+	update = update.Set("accept_by = NULL")
+
+	// Set completed_at timestamp if status is completed or cancelled, and
+	// clear it when the assignment is reopened
 	if status == domain.AssignmentStatusCompleted || status == domain.AssignmentStatusCancelled {
 		update = update.Set("completed_at = ?", now)
+	} else {
+		update = update.Set("completed_at = NULL")
 	}
 
 	_, err := update.Exec(ctx)
 	return err
 }
 
+func (r *assignmentRepository) Reassign(ctx context.Context, id, memberID int64) error {
+	_, err := idb(ctx, r.db).NewUpdate().
+		Model(&domain.Assignment{}).
+		Set("member_id = ?", memberID).
+		Set("reassigned_at = ?", time.Now()).
+		Where("id = ?", id).
+		Exec(ctx)
+	return err
+}
+
+func (r *assignmentRepository) SetAcceptBy(ctx context.Context, id int64, acceptBy time.Time) error {
+	_, err := idb(ctx, r.db).NewUpdate().
+		Model(&domain.Assignment{}).
+		Set("accept_by = ?", acceptBy).
+		Where("id = ?", id).
+		Exec(ctx)
+	return err
+}
+
+func (r *assignmentRepository) GetExpiredPending(ctx context.Context, at time.Time, limit int) ([]*domain.Assignment, error) {
+	var assignments []*domain.Assignment
+	err := idb(ctx, r.db).NewSelect().
+		Model(&assignments).
+		Where("status = ?", domain.AssignmentStatusPendingAcceptance).
+		Where("accept_by <= ?", at).
+		Order("accept_by ASC", "id ASC").
+		Limit(limit).
+		Scan(ctx)
+	return assignments, err
+}
+
 func (r *assignmentRepository) GetByGroupID(ctx context.Context, groupID int64, limit, offset int) ([]*domain.AssignmentWithMember, error) {
 	var assignments []*domain.AssignmentWithMember
-	err := r.db.NewSelect().
-		ColumnExpr("a.id, a.metadata, a.created_at, m.id as member_id, m.name as member_name").
+	err := idb(ctx, r.db).NewSelect().
+		ColumnExpr("a.id, a.metadata, a.external_ref, a.status, a.completed_at, a.created_at, m.id as member_id, m.name as member_name").
 		TableExpr("assignments AS a").
 		Join("JOIN members AS m ON a.member_id = m.id").
 		Where("a.group_id = ?", groupID).
@@ -67,7 +122,7 @@ func (r *assignmentRepository) GetByGroupID(ctx context.Context, groupID int64,
 }
 
 func (r *assignmentRepository) GetCountByGroupID(ctx context.Context, groupID int64) (int, error) {
-	count, err := r.db.NewSelect().
+	count, err := idb(ctx, r.db).NewSelect().
 		Model(&domain.Assignment{}).
 		Where("group_id = ?", groupID).
 		Count(ctx)
@@ -84,7 +139,7 @@ func (r *assignmentRepository) GetCountsByMemberIDs(ctx context.Context, memberI
 		Count    int   `bun:"count"`
 	}
 
-	err := r.db.NewSelect().
+	err := idb(ctx, r.db).NewSelect().
 		ColumnExpr("member_id, COUNT(id) as count").
 		TableExpr("assignments").
 		Where("member_id IN (?)", bun.In(memberIDs)).
@@ -102,3 +157,33 @@ func (r *assignmentRepository) GetCountsByMemberIDs(ctx context.Context, memberI
 
 	return counts, nil
 }
+
+func (r *assignmentRepository) GetLastAssignedAtByMemberIDs(ctx context.Context, memberIDs []int64) (map[int64]time.Time, error) {
+	if len(memberIDs) == 0 {
+		return make(map[int64]time.Time), nil
+	}
+
+	var results []struct {
+		MemberID     int64     `bun:"member_id"`
+		LastAssigned time.Time `bun:"last_assigned"`
+	}
+
+	// A reassigned assignment counts from when its current member got it
+	err := idb(ctx, r.db).NewSelect().
+		ColumnExpr("member_id, MAX(COALESCE(reassigned_at, created_at)) as last_assigned").
+		TableExpr("assignments").
+		Where("member_id IN (?)", bun.In(memberIDs)).
+		Group("member_id").
+		Scan(ctx, &results)
+
+	if err != nil {
+		return nil, err
+	}
+
+	lastAssigned := make(map[int64]time.Time)
+	for _, result := range results {
+		lastAssigned[result.MemberID] = result.LastAssigned
+	}
+
+	return lastAssigned, nil
+}
@@ -0,0 +1,46 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/uptrace/bun"
+)
+
+type assignmentEventRepository struct {
+	db *bun.DB
+}
+
+// NewAssignmentEventRepository creates a new assignment event repository
+func NewAssignmentEventRepository(db *bun.DB) domain.AssignmentEventRepository {
+	return &assignmentEventRepository{db: db}
+}
+
+func (r *assignmentEventRepository) Create(ctx context.Context, event *domain.AssignmentEvent) error {
+	event.CreatedAt = time.Now()
+	_, err := idb(ctx, r.db).NewInsert().Model(event).Returning("*").Exec(ctx)
+	return err
+}
+
+func (r *assignmentEventRepository) GetByAssignmentID(ctx context.Context, assignmentID int64) ([]*domain.AssignmentEvent, error) {
+	var events []*domain.AssignmentEvent
+	err := idb(ctx, r.db).NewSelect().
+		Model(&events).
+		Where("assignment_id = ?", assignmentID).
+		Order("created_at ASC", "id ASC").
+		Scan(ctx)
+	return events, err
+}
+
+func (r *assignmentEventRepository) GetDeclinedMemberIDs(ctx context.Context, assignmentID int64) ([]int64, error) {
+	var ids []int64
+	err := idb(ctx, r.db).NewSelect().
+		Model((*domain.AssignmentEvent)(nil)).
+		Column("from_member_id").
+		Distinct().
+		Where("assignment_id = ?", assignmentID).
+		Where("type = ?", domain.AssignmentEventDeclined).
+		Scan(ctx, &ids)
+	return ids, err
+}
@@ -0,0 +1,75 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/repository/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+func TestAssignmentEventRepository_Create(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	eventRepo := postgres.NewAssignmentEventRepository(bunDB)
+
+	from, to, actor := int64(1), int64(2), int64(9)
+	event := &domain.AssignmentEvent{
+		AssignmentID: 5,
+		Type:         domain.AssignmentEventReassigned,
+		FromMemberID: &from,
+		ToMemberID:   &to,
+		ActorID:      &actor,
+	}
+
+	rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
+	mock.ExpectQuery(`INSERT INTO "assignment_events"`).WillReturnRows(rows)
+
+	err = eventRepo.Create(context.Background(), event)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), event.ID)
+}
+
+func TestAssignmentEventRepository_GetByAssignmentID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	eventRepo := postgres.NewAssignmentEventRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"id", "assignment_id", "type"}).
+		AddRow(1, 5, "reassigned").
+		AddRow(2, 5, "status_changed")
+	mock.ExpectQuery(`SELECT (.+) FROM "assignment_events" AS "assignment_event" WHERE \(assignment_id = 5\) ORDER BY "created_at" ASC, "id" ASC`).WillReturnRows(rows)
+
+	events, err := eventRepo.GetByAssignmentID(context.Background(), 5)
+
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+}
+
+func TestAssignmentEventRepository_GetDeclinedMemberIDs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	eventRepo := postgres.NewAssignmentEventRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"from_member_id"}).AddRow(2).AddRow(3)
+	mock.ExpectQuery(`SELECT DISTINCT "assignment_event"."from_member_id" FROM "assignment_events" AS "assignment_event" WHERE \(assignment_id = 5\) AND \(type = 'declined'\)`).WillReturnRows(rows)
+
+	ids, err := eventRepo.GetDeclinedMemberIDs(context.Background(), 5)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{2, 3}, ids)
+}
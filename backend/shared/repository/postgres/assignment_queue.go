@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/uptrace/bun"
+)
+
+type assignmentQueueRepository struct {
+	db *bun.DB
+}
+
+// NewAssignmentQueueRepository creates a new assignment queue repository
+func NewAssignmentQueueRepository(db *bun.DB) domain.AssignmentQueueRepository {
+	return &assignmentQueueRepository{db: db}
+}
+
+func (r *assignmentQueueRepository) Enqueue(ctx context.Context, item *domain.QueuedAssignment) error {
+	item.CreatedAt = time.Now()
+	_, err := idb(ctx, r.db).NewInsert().Model(item).Returning("*").Exec(ctx)
+	return err
+}
+
+func (r *assignmentQueueRepository) GetQueuedGroupIDs(ctx context.Context) ([]int64, error) {
+	var groupIDs []int64
+	err := idb(ctx, r.db).NewSelect().
+		Model((*domain.QueuedAssignment)(nil)).
+		Column("group_id").
+		Group("group_id").
+		OrderExpr("MIN(created_at) ASC, group_id ASC").
+		Scan(ctx, &groupIDs)
+	return groupIDs, err
+}
+
+func (r *assignmentQueueRepository) GetPendingByGroupID(ctx context.Context, groupID int64, limit int) ([]*domain.QueuedAssignment, error) {
+	var items []*domain.QueuedAssignment
+	err := idb(ctx, r.db).NewSelect().
+		Model(&items).
+		Where("group_id = ?", groupID).
+		Order("created_at ASC", "id ASC").
+		Limit(limit).
+		Scan(ctx)
+	return items, err
+}
+
+// GetByExternalRef returns the group's queued item with the caller's reference, or nil
+func (r *assignmentQueueRepository) GetByExternalRef(ctx context.Context, groupID int64, externalRef string) (*domain.QueuedAssignment, error) {
+	item := new(domain.QueuedAssignment)
+	err := idb(ctx, r.db).NewSelect().
+		Model(item).
+		Where("group_id = ?", groupID).
+		Where("external_ref = ?", externalRef).
+		Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (r *assignmentQueueRepository) Delete(ctx context.Context, id int64) error {
+	_, err := idb(ctx, r.db).NewDelete().Model((*domain.QueuedAssignment)(nil)).Where("id = ?", id).Exec(ctx)
+	return err
+}
@@ -0,0 +1,98 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/repository/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+func TestAssignmentQueueRepository_Enqueue(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	queueRepo := postgres.NewAssignmentQueueRepository(bunDB)
+
+	item := &domain.QueuedAssignment{GroupID: 1, QueuedBy: 2}
+
+	rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
+	mock.ExpectQuery(`INSERT INTO "queued_assignments"`).WillReturnRows(rows)
+
+	err = queueRepo.Enqueue(context.Background(), item)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), item.ID)
+}
+
+func TestAssignmentQueueRepository_GetQueuedGroupIDs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	queueRepo := postgres.NewAssignmentQueueRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"group_id"}).AddRow(3).AddRow(1)
+	mock.ExpectQuery(`SELECT "queued_assignment"."group_id" FROM "queued_assignments" AS "queued_assignment" GROUP BY "group_id" ORDER BY MIN\(created_at\) ASC, group_id ASC`).WillReturnRows(rows)
+
+	groupIDs, err := queueRepo.GetQueuedGroupIDs(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{3, 1}, groupIDs)
+}
+
+func TestAssignmentQueueRepository_GetPendingByGroupID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	queueRepo := postgres.NewAssignmentQueueRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"id", "group_id"}).AddRow(1, 3).AddRow(2, 3)
+	mock.ExpectQuery(`SELECT (.+) FROM "queued_assignments" AS "queued_assignment" WHERE \(group_id = 3\) ORDER BY "created_at" ASC, "id" ASC LIMIT 100`).WillReturnRows(rows)
+
+	items, err := queueRepo.GetPendingByGroupID(context.Background(), 3, 100)
+
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+}
+
+func TestAssignmentQueueRepository_Delete(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	queueRepo := postgres.NewAssignmentQueueRepository(bunDB)
+
+	mock.ExpectExec(`DELETE FROM "queued_assignments" AS "queued_assignment" WHERE \(id = 1\)`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = queueRepo.Delete(context.Background(), 1)
+
+	assert.NoError(t, err)
+}
+
+func TestAssignmentQueueRepository_GetByExternalRef(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	queueRepo := postgres.NewAssignmentQueueRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"id", "group_id", "external_ref"}).AddRow(4, 1, "ZD-1001")
+	mock.ExpectQuery(`SELECT (.+) FROM "queued_assignments" AS "queued_assignment" WHERE \(group_id = 1\) AND \(external_ref = 'ZD-1001'\)`).WillReturnRows(rows)
+
+	item, err := queueRepo.GetByExternalRef(context.Background(), 1, "ZD-1001")
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), item.ID)
+}
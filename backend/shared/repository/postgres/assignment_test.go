@@ -3,6 +3,7 @@ package postgres_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/raufhm/fairflow/shared/domain"
@@ -64,6 +65,70 @@ func TestAssignmentRepository_UpdateStatus(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestAssignmentRepository_UpdateStatusReopen(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	assignmentRepo := postgres.NewAssignmentRepository(bunDB)
+
+	mock.ExpectExec(`UPDATE "assignments" AS "assignment" SET status = 'open', accept_by = NULL, completed_at = NULL WHERE \(id = 1\)`).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = assignmentRepo.UpdateStatus(context.Background(), 1, domain.AssignmentStatusOpen)
+
+	assert.NoError(t, err)
+}
+
+func TestAssignmentRepository_Reassign(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	assignmentRepo := postgres.NewAssignmentRepository(bunDB)
+
+	mock.ExpectExec(`UPDATE "assignments" AS "assignment" SET member_id = 3, reassigned_at = (.+) WHERE \(id = 1\)`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = assignmentRepo.Reassign(context.Background(), 1, 3)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAssignmentRepository_SetAcceptBy(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	assignmentRepo := postgres.NewAssignmentRepository(bunDB)
+
+	mock.ExpectExec(`UPDATE "assignments" AS "assignment" SET accept_by = (.+) WHERE \(id = 1\)`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = assignmentRepo.SetAcceptBy(context.Background(), 1, time.Now().Add(30*time.Minute))
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAssignmentRepository_GetExpiredPending(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	assignmentRepo := postgres.NewAssignmentRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"id", "group_id", "member_id", "status"}).AddRow(1, 1, 2, "pending_acceptance")
+	mock.ExpectQuery(`SELECT (.+) FROM "assignments" AS "assignment" WHERE \(status = 'pending_acceptance'\) AND \(accept_by <= (.+)\) ORDER BY "accept_by" ASC, "id" ASC LIMIT 100`).WillReturnRows(rows)
+
+	assignments, err := assignmentRepo.GetExpiredPending(context.Background(), time.Now(), 100)
+
+	assert.NoError(t, err)
+	assert.Len(t, assignments, 1)
+}
+
 func TestAssignmentRepository_GetByGroupID(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
@@ -73,7 +138,7 @@ func TestAssignmentRepository_GetByGroupID(t *testing.T) {
 	assignmentRepo := postgres.NewAssignmentRepository(bunDB)
 
 	rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
-	mock.ExpectQuery(`SELECT a.id, a.metadata, a.created_at, m.id as member_id, m.name as member_name FROM assignments AS a JOIN members AS m ON a.member_id = m.id WHERE (.+)`).WillReturnRows(rows)
+	mock.ExpectQuery(`SELECT a.id, a.metadata, a.external_ref, a.status, a.completed_at, a.created_at, m.id as member_id, m.name as member_name FROM assignments AS a JOIN members AS m ON a.member_id = m.id WHERE (.+)`).WillReturnRows(rows)
 
 	_, err = assignmentRepo.GetByGroupID(context.Background(), 1, 10, 0)
 
@@ -111,3 +176,37 @@ func TestAssignmentRepository_GetCountsByMemberIDs(t *testing.T) {
 
 	assert.NoError(t, err)
 }
+
+func TestAssignmentRepository_GetLastAssignedAtByMemberIDs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	assignmentRepo := postgres.NewAssignmentRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"member_id", "last_assigned"}).AddRow(1, time.Now())
+	mock.ExpectQuery(`SELECT member_id, MAX\(COALESCE\(reassigned_at, created_at\)\) as last_assigned FROM assignments WHERE (.+) GROUP BY "member_id"`).WillReturnRows(rows)
+
+	lastAssigned, err := assignmentRepo.GetLastAssignedAtByMemberIDs(context.Background(), []int64{1})
+
+	assert.NoError(t, err)
+	assert.Contains(t, lastAssigned, int64(1))
+}
+
+func TestAssignmentRepository_GetByExternalRef(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	assignmentRepo := postgres.NewAssignmentRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"id", "group_id", "member_id", "external_ref"}).AddRow(1, 1, 2, "ZD-1001")
+	mock.ExpectQuery(`SELECT (.+) FROM "assignments" AS "assignment" WHERE \(group_id = 1\) AND \(external_ref = 'ZD-1001'\)`).WillReturnRows(rows)
+
+	assignment, err := assignmentRepo.GetByExternalRef(context.Background(), 1, "ZD-1001")
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), assignment.MemberID)
+}
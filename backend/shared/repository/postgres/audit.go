@@ -19,6 +19,9 @@ func NewAuditLogRepository(db *bun.DB) domain.AuditLogRepository {
 
 func (r *auditLogRepository) Create(ctx context.Context, log *domain.AuditLog) error {
 	log.CreatedAt = time.Now()
+	if log.ImpersonatorID == nil {
+		log.ImpersonatorID = domain.ImpersonatorIDFromContext(ctx)
+	}
 	_, err := r.db.NewInsert().Model(log).Exec(ctx)
 	return err
 }
@@ -27,3 +27,14 @@ func (r *BaseRepository) Execute(ctx context.Context, fn func() error) error {
 func (r *BaseRepository) GetDB() *bun.DB {
 	return r.db
 }
+
+// txKey is the context key of the transaction opened by a Transactor
+type txKey struct{}
+
+// idb returns the transaction carried by ctx, or db outside a transaction
+func idb(ctx context.Context, db *bun.DB) bun.IDB {
+	if tx, ok := ctx.Value(txKey{}).(bun.Tx); ok {
+		return tx
+	}
+	return db
+}
@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/uptrace/bun"
+)
+
+type emailVerificationTokenRepository struct {
+	db *bun.DB
+}
+
+// NewEmailVerificationTokenRepository creates a new email verification token repository
+func NewEmailVerificationTokenRepository(db *bun.DB) domain.EmailVerificationTokenRepository {
+	return &emailVerificationTokenRepository{db: db}
+}
+
+func (r *emailVerificationTokenRepository) Create(ctx context.Context, token *domain.EmailVerificationToken) error {
+	token.CreatedAt = time.Now()
+	_, err := r.db.NewInsert().Model(token).Exec(ctx)
+	return err
+}
+
+func (r *emailVerificationTokenRepository) GetByHash(ctx context.Context, hash string) (*domain.EmailVerificationToken, error) {
+	token := new(domain.EmailVerificationToken)
+	err := r.db.NewSelect().Model(token).Where("token_hash = ?", hash).Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (r *emailVerificationTokenRepository) MarkUsed(ctx context.Context, id int64) (bool, error) {
+	res, err := r.db.NewUpdate().
+		Model((*domain.EmailVerificationToken)(nil)).
+		Set("used_at = ?", time.Now()).
+		Where("id = ?", id).
+		Where("used_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (r *emailVerificationTokenRepository) InvalidateByUserID(ctx context.Context, userID int64) error {
+	_, err := r.db.NewUpdate().
+		Model((*domain.EmailVerificationToken)(nil)).
+		Set("used_at = ?", time.Now()).
+		Where("user_id = ?", userID).
+		Where("used_at IS NULL").
+		Exec(ctx)
+	return err
+}
@@ -0,0 +1,84 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/repository/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+func TestEmailVerificationTokenRepository_Create(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	tokenRepo := postgres.NewEmailVerificationTokenRepository(bunDB)
+
+	token := &domain.EmailVerificationToken{
+		UserID:    1,
+		TokenHash: "hash",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
+	mock.ExpectQuery(`INSERT INTO "email_verification_tokens"`).WillReturnRows(rows)
+
+	err = tokenRepo.Create(context.Background(), token)
+
+	assert.NoError(t, err)
+}
+
+func TestEmailVerificationTokenRepository_GetByHash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	tokenRepo := postgres.NewEmailVerificationTokenRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
+	mock.ExpectQuery(`SELECT (.+) FROM "email_verification_tokens"`).WillReturnRows(rows)
+
+	token, err := tokenRepo.GetByHash(context.Background(), "hash")
+
+	assert.NoError(t, err)
+	assert.NotNil(t, token)
+}
+
+func TestEmailVerificationTokenRepository_MarkUsed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	tokenRepo := postgres.NewEmailVerificationTokenRepository(bunDB)
+
+	mock.ExpectExec(`UPDATE "email_verification_tokens"`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	consumed, err := tokenRepo.MarkUsed(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.False(t, consumed)
+}
+
+func TestEmailVerificationTokenRepository_InvalidateByUserID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	tokenRepo := postgres.NewEmailVerificationTokenRepository(bunDB)
+
+	mock.ExpectExec(`UPDATE "email_verification_tokens"`).WillReturnResult(sqlmock.NewResult(0, 2))
+
+	err = tokenRepo.InvalidateByUserID(context.Background(), 1)
+
+	assert.NoError(t, err)
+}
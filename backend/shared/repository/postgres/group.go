@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/raufhm/fairflow/shared/domain"
@@ -21,13 +22,28 @@ func (r *groupRepository) Create(ctx context.Context, group *domain.Group) error
 	now := time.Now()
 	group.CreatedAt = now
 	group.UpdatedAt = now
-	_, err := r.db.NewInsert().Model(group).Exec(ctx)
+	_, err := idb(ctx, r.db).NewInsert().Model(group).Exec(ctx)
 	return err
 }
 
 func (r *groupRepository) GetByID(ctx context.Context, id int64) (*domain.Group, error) {
 	group := &domain.Group{}
-	err := r.db.NewSelect().Model(group).Where("id = ?", id).Scan(ctx)
+	err := idb(ctx, r.db).NewSelect().Model(group).Where("id = ?", id).Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// LockByID loads a group and locks its row until the surrounding transaction
+// ends, serializing assignments to the group. It returns nil when the group
+// does not exist.
+func (r *groupRepository) LockByID(ctx context.Context, id int64) (*domain.Group, error) {
+	group := new(domain.Group)
+	err := idb(ctx, r.db).NewSelect().Model(group).Where("id = ?", id).For("UPDATE").Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -36,23 +52,35 @@ func (r *groupRepository) GetByID(ctx context.Context, id int64) (*domain.Group,
 
 func (r *groupRepository) GetAll(ctx context.Context) ([]*domain.Group, error) {
 	var groups []*domain.Group
-	err := r.db.NewSelect().Model(&groups).Order("created_at DESC").Scan(ctx)
+	err := idb(ctx, r.db).NewSelect().Model(&groups).Order("created_at DESC").Scan(ctx)
 	return groups, err
 }
 
 func (r *groupRepository) GetByUserID(ctx context.Context, userID int64) ([]*domain.Group, error) {
 	var groups []*domain.Group
-	err := r.db.NewSelect().Model(&groups).Where("user_id = ?", userID).Order("created_at DESC").Scan(ctx)
+	err := idb(ctx, r.db).NewSelect().Model(&groups).Where("user_id = ?", userID).Order("created_at DESC").Scan(ctx)
+	return groups, err
+}
+
+func (r *groupRepository) GetByOrganizationID(ctx context.Context, orgID *int64) ([]*domain.Group, error) {
+	var groups []*domain.Group
+	query := idb(ctx, r.db).NewSelect().Model(&groups)
+	if orgID == nil {
+		query = query.Where("organization_id IS NULL")
+	} else {
+		query = query.Where("organization_id = ?", *orgID)
+	}
+	err := query.Order("created_at DESC").Scan(ctx)
 	return groups, err
 }
 
 func (r *groupRepository) Update(ctx context.Context, group *domain.Group) error {
 	group.UpdatedAt = time.Now()
-	_, err := r.db.NewUpdate().Model(group).Where("id = ?", group.ID).Exec(ctx)
+	_, err := idb(ctx, r.db).NewUpdate().Model(group).Where("id = ?", group.ID).Exec(ctx)
 	return err
 }
 
 func (r *groupRepository) Delete(ctx context.Context, id int64) error {
-	_, err := r.db.NewDelete().Model(&domain.Group{}).Where("id = ?", id).Exec(ctx)
+	_, err := idb(ctx, r.db).NewDelete().Model(&domain.Group{}).Where("id = ?", id).Exec(ctx)
 	return err
 }
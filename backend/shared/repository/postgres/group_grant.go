@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/uptrace/bun"
+)
+
+type groupGrantRepository struct {
+	db *bun.DB
+}
+
+// NewGroupGrantRepository creates a new group role repository
+func NewGroupGrantRepository(db *bun.DB) domain.GroupGrantRepository {
+	return &groupGrantRepository{db: db}
+}
+
+// Upsert grants a role, replacing any role the user already had in the group
+func (r *groupGrantRepository) Upsert(ctx context.Context, grant *domain.GroupGrant) error {
+	now := time.Now()
+	grant.CreatedAt = now
+	grant.UpdatedAt = now
+	_, err := r.db.NewInsert().
+		Model(grant).
+		On("CONFLICT (group_id, user_id) DO UPDATE").
+		Set("role = EXCLUDED.role").
+		Set("granted_by = EXCLUDED.granted_by").
+		Set("updated_at = EXCLUDED.updated_at").
+		Returning("*").
+		Exec(ctx)
+	return err
+}
+
+func (r *groupGrantRepository) Get(ctx context.Context, groupID, userID int64) (*domain.GroupGrant, error) {
+	grant := new(domain.GroupGrant)
+	err := r.db.NewSelect().
+		Model(grant).
+		Where("group_id = ?", groupID).
+		Where("user_id = ?", userID).
+		Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return grant, nil
+}
+
+func (r *groupGrantRepository) GetByGroupID(ctx context.Context, groupID int64) ([]*domain.GroupGrant, error) {
+	var grants []*domain.GroupGrant
+	err := r.db.NewSelect().Model(&grants).Where("group_id = ?", groupID).Order("created_at ASC").Scan(ctx)
+	return grants, err
+}
+
+func (r *groupGrantRepository) GetByUserID(ctx context.Context, userID int64) ([]*domain.GroupGrant, error) {
+	var grants []*domain.GroupGrant
+	err := r.db.NewSelect().Model(&grants).Where("user_id = ?", userID).Scan(ctx)
+	return grants, err
+}
+
+// Delete revokes a user's role, reporting whether they had one
+func (r *groupGrantRepository) Delete(ctx context.Context, groupID, userID int64) (bool, error) {
+	res, err := r.db.NewDelete().
+		Model((*domain.GroupGrant)(nil)).
+		Where("group_id = ?", groupID).
+		Where("user_id = ?", userID).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r *groupGrantRepository) DeleteByGroupID(ctx context.Context, groupID int64) error {
+	_, err := r.db.NewDelete().Model((*domain.GroupGrant)(nil)).Where("group_id = ?", groupID).Exec(ctx)
+	return err
+}
@@ -0,0 +1,126 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/repository/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+func TestGroupGrantRepository_Upsert(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	grantRepo := postgres.NewGroupGrantRepository(bunDB)
+
+	grant := &domain.GroupGrant{
+		GroupID:   1,
+		UserID:    2,
+		Role:      domain.GroupRoleManager,
+		GrantedBy: 3,
+	}
+
+	rows := sqlmock.NewRows([]string{"id", "group_id", "user_id", "role"}).AddRow(5, 1, 2, "manager")
+	mock.ExpectQuery(`INSERT INTO "group_grants" (.+) ON CONFLICT \(group_id, user_id\) DO UPDATE`).WillReturnRows(rows)
+
+	err = grantRepo.Upsert(context.Background(), grant)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), grant.ID)
+}
+
+func TestGroupGrantRepository_Get(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	grantRepo := postgres.NewGroupGrantRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"id", "group_id", "user_id", "role"}).AddRow(5, 1, 2, "viewer")
+	mock.ExpectQuery(`SELECT (.+) FROM "group_grants"`).WillReturnRows(rows)
+
+	grant, err := grantRepo.Get(context.Background(), 1, 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.GroupRoleViewer, grant.Role)
+
+	mock.ExpectQuery(`SELECT (.+) FROM "group_grants"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	grant, err = grantRepo.Get(context.Background(), 1, 3)
+
+	assert.NoError(t, err)
+	assert.Nil(t, grant)
+}
+
+func TestGroupGrantRepository_GetByGroupID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	grantRepo := postgres.NewGroupGrantRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"id", "group_id"}).AddRow(1, 1).AddRow(2, 1)
+	mock.ExpectQuery(`SELECT (.+) FROM "group_grants"`).WillReturnRows(rows)
+
+	grants, err := grantRepo.GetByGroupID(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Len(t, grants, 2)
+}
+
+func TestGroupGrantRepository_GetByUserID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	grantRepo := postgres.NewGroupGrantRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"id", "user_id"}).AddRow(1, 2)
+	mock.ExpectQuery(`SELECT (.+) FROM "group_grants"`).WillReturnRows(rows)
+
+	grants, err := grantRepo.GetByUserID(context.Background(), 2)
+
+	assert.NoError(t, err)
+	assert.Len(t, grants, 1)
+}
+
+func TestGroupGrantRepository_Delete(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	grantRepo := postgres.NewGroupGrantRepository(bunDB)
+
+	mock.ExpectExec(`DELETE FROM "group_grants"`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	deleted, err := grantRepo.Delete(context.Background(), 1, 2)
+
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+}
+
+func TestGroupGrantRepository_DeleteByGroupID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	grantRepo := postgres.NewGroupGrantRepository(bunDB)
+
+	mock.ExpectExec(`DELETE FROM "group_grants"`).WillReturnResult(sqlmock.NewResult(0, 3))
+
+	err = grantRepo.DeleteByGroupID(context.Background(), 1)
+
+	assert.NoError(t, err)
+}
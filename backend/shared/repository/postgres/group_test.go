@@ -50,6 +50,23 @@ func TestGroupRepository_GetByID(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestGroupRepository_LockByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	groupRepo := postgres.NewGroupRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
+	mock.ExpectQuery(`SELECT (.+) FROM "groups" AS "group" WHERE \(id = 1\) FOR UPDATE`).WillReturnRows(rows)
+
+	group, err := groupRepo.LockByID(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), group.ID)
+}
+
 func TestGroupRepository_GetAll(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
@@ -118,3 +135,27 @@ func TestGroupRepository_Delete(t *testing.T) {
 
 	assert.NoError(t, err)
 }
+
+func TestGroupRepository_GetByOrganizationID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	groupRepo := postgres.NewGroupRepository(bunDB)
+
+	orgID := int64(3)
+	rows := sqlmock.NewRows([]string{"id", "organization_id"}).AddRow(1, 3)
+	mock.ExpectQuery(`SELECT (.+) FROM "groups" AS "group" WHERE \(organization_id = 3\)`).WillReturnRows(rows)
+
+	groups, err := groupRepo.GetByOrganizationID(context.Background(), &orgID)
+
+	assert.NoError(t, err)
+	assert.Len(t, groups, 1)
+
+	mock.ExpectQuery(`SELECT (.+) FROM "groups" AS "group" WHERE \(organization_id IS NULL\)`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err = groupRepo.GetByOrganizationID(context.Background(), nil)
+
+	assert.NoError(t, err)
+}
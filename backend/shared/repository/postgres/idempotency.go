@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/uptrace/bun"
+)
+
+type idempotencyRepository struct {
+	db *bun.DB
+}
+
+// NewIdempotencyRepository creates a new idempotency record repository
+func NewIdempotencyRepository(db *bun.DB) domain.IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+// Create stores a record. Expired records linger until they are swept, so one
+// for the same key is overwritten rather than blocking the key's reuse.
+func (r *idempotencyRepository) Create(ctx context.Context, record *domain.IdempotencyRecord) error {
+	record.CreatedAt = time.Now()
+	res, err := idb(ctx, r.db).NewInsert().
+		Model(record).
+		On("CONFLICT (group_id, key) DO UPDATE").
+		Set("assignment_id = EXCLUDED.assignment_id").
+		Set("queued_id = EXCLUDED.queued_id").
+		Set("expires_at = EXCLUDED.expires_at").
+		Set("created_at = EXCLUDED.created_at").
+		Where("?TableAlias.expires_at <= EXCLUDED.created_at").
+		Returning("*").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	stored, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if stored == 0 {
+		return domain.ErrIdempotencyKeyInUse
+	}
+	return nil
+}
+
+func (r *idempotencyRepository) Get(ctx context.Context, groupID int64, key string, at time.Time) (*domain.IdempotencyRecord, error) {
+	record := new(domain.IdempotencyRecord)
+	err := idb(ctx, r.db).NewSelect().
+		Model(record).
+		Where("group_id = ?", groupID).
+		Where("key = ?", key).
+		Where("expires_at > ?", at).
+		Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func (r *idempotencyRepository) ResolveQueued(ctx context.Context, queuedID, assignmentID int64) error {
+	_, err := idb(ctx, r.db).NewUpdate().
+		Model((*domain.IdempotencyRecord)(nil)).
+		Set("assignment_id = ?", assignmentID).
+		Set("queued_id = NULL").
+		Where("queued_id = ?", queuedID).
+		Exec(ctx)
+	return err
+}
+
+func (r *idempotencyRepository) DeleteExpired(ctx context.Context, before time.Time) (int, error) {
+	res, err := idb(ctx, r.db).NewDelete().
+		Model((*domain.IdempotencyRecord)(nil)).
+		Where("expires_at <= ?", before).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := res.RowsAffected()
+	return int(deleted), err
+}
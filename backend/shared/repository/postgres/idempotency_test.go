@@ -0,0 +1,106 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/repository/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+func TestIdempotencyRepository_Create(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	idempotencyRepo := postgres.NewIdempotencyRepository(bunDB)
+
+	assignmentID := int64(3)
+	record := &domain.IdempotencyRecord{
+		GroupID:      1,
+		Key:          "retry-1",
+		AssignmentID: &assignmentID,
+		ExpiresAt:    time.Now().Add(24 * time.Hour),
+	}
+
+	rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
+	mock.ExpectQuery(`INSERT INTO "idempotency_records" (.+) ON CONFLICT \(group_id, key\) DO UPDATE (.+) WHERE \("idempotency_record"\.expires_at <= EXCLUDED\.created_at\)`).WillReturnRows(rows)
+
+	err = idempotencyRepo.Create(context.Background(), record)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), record.ID)
+}
+
+func TestIdempotencyRepository_Create_LiveKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	idempotencyRepo := postgres.NewIdempotencyRepository(bunDB)
+
+	record := &domain.IdempotencyRecord{GroupID: 1, Key: "retry-1", ExpiresAt: time.Now().Add(24 * time.Hour)}
+
+	// The conflicting record has not expired, so nothing is written
+	mock.ExpectQuery(`INSERT INTO "idempotency_records"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	err = idempotencyRepo.Create(context.Background(), record)
+
+	assert.ErrorIs(t, err, domain.ErrIdempotencyKeyInUse)
+}
+
+func TestIdempotencyRepository_ResolveQueued(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	idempotencyRepo := postgres.NewIdempotencyRepository(bunDB)
+
+	mock.ExpectExec(`UPDATE "idempotency_records" AS "idempotency_record" SET assignment_id = 7, queued_id = NULL WHERE \(queued_id = 4\)`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = idempotencyRepo.ResolveQueued(context.Background(), 4, 7)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIdempotencyRepository_Get(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	idempotencyRepo := postgres.NewIdempotencyRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"id", "group_id", "key", "assignment_id"}).AddRow(1, 1, "retry-1", 3)
+	mock.ExpectQuery(`SELECT (.+) FROM "idempotency_records" AS "idempotency_record" WHERE \(group_id = 1\) AND \(key = 'retry-1'\) AND \(expires_at > (.+)\)`).WillReturnRows(rows)
+
+	record, err := idempotencyRepo.Get(context.Background(), 1, "retry-1", time.Now())
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), *record.AssignmentID)
+}
+
+func TestIdempotencyRepository_DeleteExpired(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	idempotencyRepo := postgres.NewIdempotencyRepository(bunDB)
+
+	mock.ExpectExec(`DELETE FROM "idempotency_records" AS "idempotency_record" WHERE \(expires_at <= (.+)\)`).WillReturnResult(sqlmock.NewResult(0, 5))
+
+	deleted, err := idempotencyRepo.DeleteExpired(context.Background(), time.Now())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 5, deleted)
+}
@@ -0,0 +1,53 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/uptrace/bun"
+)
+
+type userIdentityRepository struct {
+	db *bun.DB
+}
+
+// NewUserIdentityRepository creates a new external identity repository
+func NewUserIdentityRepository(db *bun.DB) domain.UserIdentityRepository {
+	return &userIdentityRepository{db: db}
+}
+
+func (r *userIdentityRepository) Create(ctx context.Context, identity *domain.UserIdentity) error {
+	now := time.Now()
+	identity.CreatedAt = now
+	identity.LastLoginAt = &now
+	_, err := r.db.NewInsert().Model(identity).Exec(ctx)
+	return err
+}
+
+func (r *userIdentityRepository) GetBySubject(ctx context.Context, issuer, subject string) (*domain.UserIdentity, error) {
+	identity := new(domain.UserIdentity)
+	err := r.db.NewSelect().
+		Model(identity).
+		Where("issuer = ?", issuer).
+		Where("subject = ?", subject).
+		Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+func (r *userIdentityRepository) UpdateLastLogin(ctx context.Context, id int64, email string) error {
+	_, err := r.db.NewUpdate().
+		Model((*domain.UserIdentity)(nil)).
+		Set("last_login_at = ?", time.Now()).
+		Set("email = ?", email).
+		Where("id = ?", id).
+		Exec(ctx)
+	return err
+}
@@ -0,0 +1,69 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/repository/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+func TestUserIdentityRepository_Create(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	identityRepo := postgres.NewUserIdentityRepository(bunDB)
+
+	identity := &domain.UserIdentity{
+		UserID:  1,
+		Issuer:  "https://idp.example.com",
+		Subject: "user-123",
+		Email:   "jane@example.com",
+	}
+
+	rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
+	mock.ExpectQuery(`INSERT INTO "user_identities"`).WillReturnRows(rows)
+
+	err = identityRepo.Create(context.Background(), identity)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, identity.LastLoginAt)
+}
+
+func TestUserIdentityRepository_GetBySubject(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	identityRepo := postgres.NewUserIdentityRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"id", "user_id", "issuer", "subject"}).AddRow(1, 7, "https://idp.example.com", "user-123")
+	mock.ExpectQuery(`SELECT (.+) FROM "user_identities"`).WillReturnRows(rows)
+
+	identity, err := identityRepo.GetBySubject(context.Background(), "https://idp.example.com", "user-123")
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), identity.UserID)
+}
+
+func TestUserIdentityRepository_UpdateLastLogin(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	identityRepo := postgres.NewUserIdentityRepository(bunDB)
+
+	mock.ExpectExec(`UPDATE "user_identities"`).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = identityRepo.UpdateLastLogin(context.Background(), 1, "jane@example.com")
+
+	assert.NoError(t, err)
+}
@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/uptrace/bun"
+)
+
+type invitationRepository struct {
+	db *bun.DB
+}
+
+// NewInvitationRepository creates a new invitation repository
+func NewInvitationRepository(db *bun.DB) domain.InvitationRepository {
+	return &invitationRepository{db: db}
+}
+
+func (r *invitationRepository) Create(ctx context.Context, invitation *domain.Invitation) error {
+	invitation.CreatedAt = time.Now()
+	_, err := r.db.NewInsert().Model(invitation).Exec(ctx)
+	return err
+}
+
+func (r *invitationRepository) GetByID(ctx context.Context, id int64) (*domain.Invitation, error) {
+	invitation := new(domain.Invitation)
+	err := r.db.NewSelect().Model(invitation).Where("id = ?", id).Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+func (r *invitationRepository) GetByHash(ctx context.Context, hash string) (*domain.Invitation, error) {
+	invitation := new(domain.Invitation)
+	err := r.db.NewSelect().Model(invitation).Where("token_hash = ?", hash).Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+func (r *invitationRepository) GetPending(ctx context.Context) ([]*domain.Invitation, error) {
+	var invitations []*domain.Invitation
+	err := r.pending(&invitations).Scan(ctx)
+	return invitations, err
+}
+
+func (r *invitationRepository) GetPendingByOrganizationID(ctx context.Context, orgID int64) ([]*domain.Invitation, error) {
+	var invitations []*domain.Invitation
+	err := r.pending(&invitations).Where("organization_id = ?", orgID).Scan(ctx)
+	return invitations, err
+}
+
+func (r *invitationRepository) GetPendingByEmail(ctx context.Context, email string) (*domain.Invitation, error) {
+	var invitations []*domain.Invitation
+	err := r.pending(&invitations).Where("lower(email) = lower(?)", email).Limit(1).Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(invitations) == 0 {
+		return nil, nil
+	}
+	return invitations[0], nil
+}
+
+func (r *invitationRepository) MarkAccepted(ctx context.Context, id int64) (bool, error) {
+	res, err := idb(ctx, r.db).NewUpdate().
+		Model((*domain.Invitation)(nil)).
+		Set("accepted_at = ?", time.Now()).
+		Where("id = ?", id).
+		Where("accepted_at IS NULL").
+		Where("revoked_at IS NULL").
+		Where("expires_at > ?", time.Now()).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (r *invitationRepository) Revoke(ctx context.Context, id int64) (bool, error) {
+	res, err := r.db.NewUpdate().
+		Model((*domain.Invitation)(nil)).
+		Set("revoked_at = ?", time.Now()).
+		Where("id = ?", id).
+		Where("accepted_at IS NULL").
+		Where("revoked_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (r *invitationRepository) RevokePendingByEmail(ctx context.Context, email string) error {
+	_, err := r.db.NewUpdate().
+		Model((*domain.Invitation)(nil)).
+		Set("revoked_at = ?", time.Now()).
+		Where("lower(email) = lower(?)", email).
+		Where("accepted_at IS NULL").
+		Where("revoked_at IS NULL").
+		Exec(ctx)
+	return err
+}
+
+// pending selects invitations that can still be accepted, newest first
+func (r *invitationRepository) pending(invitations *[]*domain.Invitation) *bun.SelectQuery {
+	return r.db.NewSelect().
+		Model(invitations).
+		Where("accepted_at IS NULL").
+		Where("revoked_at IS NULL").
+		Where("expires_at > ?", time.Now()).
+		Order("created_at DESC")
+}
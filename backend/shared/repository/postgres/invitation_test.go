@@ -0,0 +1,186 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/repository/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+func TestInvitationRepository_Create(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	invitationRepo := postgres.NewInvitationRepository(bunDB)
+
+	orgID := int64(3)
+	invitation := &domain.Invitation{
+		Email:          "new@example.com",
+		Role:           domain.RoleUser,
+		OrganizationID: &orgID,
+		TokenHash:      "hash",
+		InvitedBy:      1,
+		ExpiresAt:      time.Now().Add(time.Hour),
+	}
+
+	rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
+	mock.ExpectQuery(`INSERT INTO "invitations"`).WillReturnRows(rows)
+
+	err = invitationRepo.Create(context.Background(), invitation)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), invitation.ID)
+}
+
+func TestInvitationRepository_GetByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	invitationRepo := postgres.NewInvitationRepository(bunDB)
+
+	mock.ExpectQuery(`SELECT (.+) FROM "invitations"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	invitation, err := invitationRepo.GetByID(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Nil(t, invitation)
+}
+
+func TestInvitationRepository_GetByHash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	invitationRepo := postgres.NewInvitationRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"id", "email", "role"}).AddRow(1, "new@example.com", "manager")
+	mock.ExpectQuery(`SELECT (.+) FROM "invitations"`).WillReturnRows(rows)
+
+	invitation, err := invitationRepo.GetByHash(context.Background(), "hash")
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.RoleManager, invitation.Role)
+}
+
+func TestInvitationRepository_GetPending(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	invitationRepo := postgres.NewInvitationRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2)
+	mock.ExpectQuery(`SELECT (.+) FROM "invitations" (.+) WHERE \(accepted_at IS NULL\) AND \(revoked_at IS NULL\)`).WillReturnRows(rows)
+
+	invitations, err := invitationRepo.GetPending(context.Background())
+
+	assert.NoError(t, err)
+	assert.Len(t, invitations, 2)
+}
+
+func TestInvitationRepository_GetPendingByOrganizationID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	invitationRepo := postgres.NewInvitationRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
+	mock.ExpectQuery(`SELECT (.+) FROM "invitations" (.+) AND \(organization_id = 3\)`).WillReturnRows(rows)
+
+	invitations, err := invitationRepo.GetPendingByOrganizationID(context.Background(), 3)
+
+	assert.NoError(t, err)
+	assert.Len(t, invitations, 1)
+}
+
+func TestInvitationRepository_GetPendingByEmail(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	invitationRepo := postgres.NewInvitationRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"id", "email"}).AddRow(4, "jane@example.com")
+	mock.ExpectQuery(`SELECT (.+) FROM "invitations" (.+) AND \(lower\(email\) = lower\('Jane@example.com'\)\) ORDER BY "created_at" DESC LIMIT 1`).WillReturnRows(rows)
+
+	invitation, err := invitationRepo.GetPendingByEmail(context.Background(), "Jane@example.com")
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), invitation.ID)
+
+	mock.ExpectQuery(`SELECT (.+) FROM "invitations"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	invitation, err = invitationRepo.GetPendingByEmail(context.Background(), "nobody@example.com")
+
+	assert.NoError(t, err)
+	assert.Nil(t, invitation)
+}
+
+func TestInvitationRepository_MarkAccepted(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	invitationRepo := postgres.NewInvitationRepository(bunDB)
+
+	mock.ExpectExec(`UPDATE "invitations"`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	accepted, err := invitationRepo.MarkAccepted(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.True(t, accepted)
+
+	mock.ExpectExec(`UPDATE "invitations"`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	accepted, err = invitationRepo.MarkAccepted(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.False(t, accepted)
+}
+
+func TestInvitationRepository_Revoke(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	invitationRepo := postgres.NewInvitationRepository(bunDB)
+
+	mock.ExpectExec(`UPDATE "invitations"`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	revoked, err := invitationRepo.Revoke(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+}
+
+func TestInvitationRepository_RevokePendingByEmail(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	invitationRepo := postgres.NewInvitationRepository(bunDB)
+
+	mock.ExpectExec(`UPDATE "invitations"`).WillReturnResult(sqlmock.NewResult(0, 2))
+
+	err = invitationRepo.RevokePendingByEmail(context.Background(), "new@example.com")
+
+	assert.NoError(t, err)
+}
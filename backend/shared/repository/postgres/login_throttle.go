@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/uptrace/bun"
+)
+
+type loginThrottleRepository struct {
+	db *bun.DB
+}
+
+// NewLoginThrottleRepository creates a new login throttle repository
+func NewLoginThrottleRepository(db *bun.DB) domain.LoginThrottleRepository {
+	return &loginThrottleRepository{db: db}
+}
+
+func (r *loginThrottleRepository) Get(ctx context.Context, scope, identifier string) (*domain.LoginThrottle, error) {
+	throttle := new(domain.LoginThrottle)
+	err := r.db.NewSelect().
+		Model(throttle).
+		Where("scope = ?", scope).
+		Where("identifier = ?", identifier).
+		Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return throttle, nil
+}
+
+func (r *loginThrottleRepository) RecordFailure(ctx context.Context, scope, identifier string, window time.Duration) (*domain.LoginThrottle, error) {
+	now := time.Now()
+	throttle := &domain.LoginThrottle{
+		Scope:         scope,
+		Identifier:    identifier,
+		Failures:      1,
+		LastFailureAt: now,
+		CreatedAt:     now,
+	}
+
+	_, err := r.db.NewInsert().
+		Model(throttle).
+		On("CONFLICT (scope, identifier) DO UPDATE").
+		Set("failures = CASE WHEN ?TableAlias.last_failure_at < ? THEN 1 ELSE ?TableAlias.failures + 1 END", now.Add(-window)).
+		Set("last_failure_at = EXCLUDED.last_failure_at").
+		Returning("*").
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return throttle, nil
+}
+
+func (r *loginThrottleRepository) Lock(ctx context.Context, id int64, until time.Time) error {
+	_, err := r.db.NewUpdate().
+		Model((*domain.LoginThrottle)(nil)).
+		Set("locked_until = ?", until).
+		Set("failures = 0").
+		Where("id = ?", id).
+		Exec(ctx)
+	return err
+}
+
+func (r *loginThrottleRepository) Reset(ctx context.Context, scope, identifier string) error {
+	_, err := r.db.NewDelete().
+		Model((*domain.LoginThrottle)(nil)).
+		Where("scope = ?", scope).
+		Where("identifier = ?", identifier).
+		Exec(ctx)
+	return err
+}
+
+func (r *loginThrottleRepository) GetLocked(ctx context.Context) ([]*domain.LoginThrottle, error) {
+	var throttles []*domain.LoginThrottle
+	err := r.db.NewSelect().
+		Model(&throttles).
+		Where("locked_until > ?", time.Now()).
+		Order("locked_until DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return throttles, nil
+}
+
+func (r *loginThrottleRepository) GetLockedAccounts(ctx context.Context, organizationID *int64) ([]*domain.LoginThrottle, error) {
+	var throttles []*domain.LoginThrottle
+	query := r.db.NewSelect().
+		Model(&throttles).
+		Join("JOIN users AS u ON lower(u.email) = login_throttle.identifier").
+		Where("login_throttle.scope = ?", domain.ThrottleScopeAccount).
+		Where("login_throttle.locked_until > ?", time.Now()).
+		Order("login_throttle.locked_until DESC")
+	if organizationID != nil {
+		query = query.Where("u.organization_id = ?", *organizationID)
+	} else {
+		query = query.Where("u.organization_id IS NULL")
+	}
+
+	if err := query.Scan(ctx); err != nil {
+		return nil, err
+	}
+	return throttles, nil
+}
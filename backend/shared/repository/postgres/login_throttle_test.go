@@ -0,0 +1,121 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/repository/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+func TestLoginThrottleRepository_Get(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	throttleRepo := postgres.NewLoginThrottleRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"id"})
+	mock.ExpectQuery(`SELECT (.+) FROM "login_throttles"`).WillReturnRows(rows)
+
+	throttle, err := throttleRepo.Get(context.Background(), domain.ThrottleScopeAccount, "jane@example.com")
+
+	assert.NoError(t, err)
+	assert.Nil(t, throttle)
+}
+
+func TestLoginThrottleRepository_RecordFailure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	throttleRepo := postgres.NewLoginThrottleRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"id", "scope", "identifier", "failures"}).AddRow(1, "ip", "10.0.0.1", 4)
+	mock.ExpectQuery(`INSERT INTO "login_throttles" (.+) ON CONFLICT \(scope, identifier\) DO UPDATE`).WillReturnRows(rows)
+
+	throttle, err := throttleRepo.RecordFailure(context.Background(), domain.ThrottleScopeIP, "10.0.0.1", 15*time.Minute)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, throttle.Failures)
+}
+
+func TestLoginThrottleRepository_Lock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	throttleRepo := postgres.NewLoginThrottleRepository(bunDB)
+
+	mock.ExpectExec(`UPDATE "login_throttles"`).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = throttleRepo.Lock(context.Background(), 1, time.Now().Add(15*time.Minute))
+
+	assert.NoError(t, err)
+}
+
+func TestLoginThrottleRepository_Reset(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	throttleRepo := postgres.NewLoginThrottleRepository(bunDB)
+
+	mock.ExpectExec(`DELETE FROM "login_throttles"`).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = throttleRepo.Reset(context.Background(), domain.ThrottleScopeAccount, "jane@example.com")
+
+	assert.NoError(t, err)
+}
+
+func TestLoginThrottleRepository_GetLocked(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	throttleRepo := postgres.NewLoginThrottleRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"id", "scope", "identifier"}).AddRow(1, "account", "jane@example.com")
+	mock.ExpectQuery(`SELECT (.+) FROM "login_throttles"`).WillReturnRows(rows)
+
+	throttles, err := throttleRepo.GetLocked(context.Background())
+
+	assert.NoError(t, err)
+	assert.Len(t, throttles, 1)
+}
+
+func TestLoginThrottleRepository_GetLockedAccounts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	throttleRepo := postgres.NewLoginThrottleRepository(bunDB)
+
+	orgID := int64(7)
+	rows := sqlmock.NewRows([]string{"id", "scope", "identifier"}).AddRow(1, "account", "jane@example.com")
+	mock.ExpectQuery(`SELECT (.+) FROM "login_throttles" AS "login_throttle" JOIN users AS u ON lower\(u.email\) = login_throttle.identifier WHERE \(login_throttle.scope = 'account'\) (.+) AND \(u.organization_id = 7\)`).WillReturnRows(rows)
+
+	throttles, err := throttleRepo.GetLockedAccounts(context.Background(), &orgID)
+
+	assert.NoError(t, err)
+	assert.Len(t, throttles, 1)
+
+	mock.ExpectQuery(`SELECT (.+) FROM "login_throttles" (.+) AND \(u.organization_id IS NULL\)`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	throttles, err = throttleRepo.GetLockedAccounts(context.Background(), nil)
+
+	assert.NoError(t, err)
+	assert.Empty(t, throttles)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
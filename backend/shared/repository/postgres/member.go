@@ -22,13 +22,13 @@ func (r *memberRepository) Create(ctx context.Context, member *domain.Member) er
 	member.CreatedAt = now
 	member.UpdatedAt = now
 	// Bun automatically populates ID field after insert
-	_, err := r.db.NewInsert().Model(member).Exec(ctx)
+	_, err := idb(ctx, r.db).NewInsert().Model(member).Exec(ctx)
 	return err
 }
 
 func (r *memberRepository) GetByID(ctx context.Context, id int64) (*domain.Member, error) {
 	member := &domain.Member{}
-	err := r.db.NewSelect().Model(member).Where("id = ?", id).Scan(ctx)
+	err := idb(ctx, r.db).NewSelect().Model(member).Where("id = ?", id).Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -37,7 +37,7 @@ func (r *memberRepository) GetByID(ctx context.Context, id int64) (*domain.Membe
 
 func (r *memberRepository) GetByGroupID(ctx context.Context, groupID int64) ([]*domain.Member, error) {
 	var members []*domain.Member
-	err := r.db.NewSelect().
+	err := idb(ctx, r.db).NewSelect().
 		Model(&members).
 		Where("group_id = ?", groupID).
 		Order("created_at").
@@ -47,7 +47,7 @@ func (r *memberRepository) GetByGroupID(ctx context.Context, groupID int64) ([]*
 
 func (r *memberRepository) GetActiveByGroupID(ctx context.Context, groupID int64) ([]*domain.Member, error) {
 	var members []*domain.Member
-	err := r.db.NewSelect().
+	err := idb(ctx, r.db).NewSelect().
 		Model(&members).
 		Where("group_id = ? AND active = true", groupID).
 		Order("created_at").
@@ -57,17 +57,17 @@ func (r *memberRepository) GetActiveByGroupID(ctx context.Context, groupID int64
 
 func (r *memberRepository) Update(ctx context.Context, member *domain.Member) error {
 	member.UpdatedAt = time.Now()
-	_, err := r.db.NewUpdate().Model(member).Where("id = ?", member.ID).Exec(ctx)
+	_, err := idb(ctx, r.db).NewUpdate().Model(member).Where("id = ?", member.ID).Exec(ctx)
 	return err
 }
 
 func (r *memberRepository) Delete(ctx context.Context, id int64) error {
-	_, err := r.db.NewDelete().Model(&domain.Member{}).Where("id = ?", id).Exec(ctx)
+	_, err := idb(ctx, r.db).NewDelete().Model(&domain.Member{}).Where("id = ?", id).Exec(ctx)
 	return err
 }
 
 func (r *memberRepository) IncrementOpenAssignments(ctx context.Context, memberID int64) error {
-	_, err := r.db.NewUpdate().
+	_, err := idb(ctx, r.db).NewUpdate().
 		Model(&domain.Member{}).
 		Set("current_open_assignments = current_open_assignments + 1").
 		Where("id = ?", memberID).
@@ -76,7 +76,7 @@ func (r *memberRepository) IncrementOpenAssignments(ctx context.Context, memberI
 }
 
 func (r *memberRepository) DecrementOpenAssignments(ctx context.Context, memberID int64) error {
-	_, err := r.db.NewUpdate().
+	_, err := idb(ctx, r.db).NewUpdate().
 		Model(&domain.Member{}).
 		Set("current_open_assignments = GREATEST(current_open_assignments - 1, 0)").
 		Where("id = ?", memberID).
@@ -85,11 +85,12 @@ func (r *memberRepository) DecrementOpenAssignments(ctx context.Context, memberI
 }
 
 func (r *memberRepository) GetDailyAssignmentCount(ctx context.Context, memberID int64) (int, error) {
-	// Get count of assignments created today
-	count, err := r.db.NewSelect().
+	// Get count of assignments the member received today. Reassigned work counts
+	// from the reassignment, against the member who holds it now.
+	count, err := idb(ctx, r.db).NewSelect().
 		Model(&domain.Assignment{}).
 		Where("member_id = ?", memberID).
-		Where("created_at >= CURRENT_DATE").
+		Where("COALESCE(reassigned_at, created_at) >= CURRENT_DATE").
 		Count(ctx)
 
 	return count, err
@@ -164,3 +164,22 @@ func TestMemberRepository_GetDailyAssignmentCount(t *testing.T) {
 
 	assert.NoError(t, err)
 }
+
+func TestMemberRepository_GetDailyAssignmentCount_CountsReassignments(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	memberRepo := postgres.NewMemberRepository(bunDB)
+
+	// One assignment created yesterday and reassigned to the member today
+	rows := sqlmock.NewRows([]string{"count"}).AddRow(1)
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "assignments" AS "assignment" WHERE \(member_id = 2\) AND \(COALESCE\(reassigned_at, created_at\) >= CURRENT_DATE\)`).WillReturnRows(rows)
+
+	count, err := memberRepo.GetDailyAssignmentCount(context.Background(), 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/uptrace/bun"
+)
+
+type organizationRepository struct {
+	db *bun.DB
+}
+
+// NewOrganizationRepository creates a new organization repository
+func NewOrganizationRepository(db *bun.DB) domain.OrganizationRepository {
+	return &organizationRepository{db: db}
+}
+
+func (r *organizationRepository) Create(ctx context.Context, org *domain.Organization) error {
+	now := time.Now()
+	org.CreatedAt = now
+	org.UpdatedAt = now
+	_, err := r.db.NewInsert().Model(org).Exec(ctx)
+	return err
+}
+
+func (r *organizationRepository) GetByID(ctx context.Context, id int64) (*domain.Organization, error) {
+	org := new(domain.Organization)
+	err := r.db.NewSelect().Model(org).Where("id = ?", id).Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+func (r *organizationRepository) GetBySlug(ctx context.Context, slug string) (*domain.Organization, error) {
+	org := new(domain.Organization)
+	err := r.db.NewSelect().Model(org).Where("slug = ?", slug).Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+func (r *organizationRepository) GetAll(ctx context.Context) ([]*domain.Organization, error) {
+	var orgs []*domain.Organization
+	err := r.db.NewSelect().Model(&orgs).Order("name ASC").Scan(ctx)
+	return orgs, err
+}
+
+func (r *organizationRepository) Update(ctx context.Context, org *domain.Organization) error {
+	org.UpdatedAt = time.Now()
+	_, err := r.db.NewUpdate().Model(org).WherePK().Exec(ctx)
+	return err
+}
+
+func (r *organizationRepository) Delete(ctx context.Context, id int64) error {
+	_, err := r.db.NewDelete().Model((*domain.Organization)(nil)).Where("id = ?", id).Exec(ctx)
+	return err
+}
@@ -0,0 +1,118 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/repository/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+func TestOrganizationRepository_Create(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	orgRepo := postgres.NewOrganizationRepository(bunDB)
+
+	org := &domain.Organization{
+		Name: "Acme",
+		Slug: "acme",
+	}
+
+	rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
+	mock.ExpectQuery(`INSERT INTO "organizations"`).WillReturnRows(rows)
+
+	err = orgRepo.Create(context.Background(), org)
+
+	assert.NoError(t, err)
+	assert.False(t, org.CreatedAt.IsZero())
+}
+
+func TestOrganizationRepository_GetByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	orgRepo := postgres.NewOrganizationRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"id", "name", "slug", "require_mfa"}).AddRow(1, "Acme", "acme", true)
+	mock.ExpectQuery(`SELECT (.+) FROM "organizations"`).WillReturnRows(rows)
+
+	org, err := orgRepo.GetByID(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "acme", org.Slug)
+	assert.True(t, org.RequireMFA)
+}
+
+func TestOrganizationRepository_GetBySlug(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	orgRepo := postgres.NewOrganizationRepository(bunDB)
+
+	mock.ExpectQuery(`SELECT (.+) FROM "organizations"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	org, err := orgRepo.GetBySlug(context.Background(), "missing")
+
+	assert.NoError(t, err)
+	assert.Nil(t, org)
+}
+
+func TestOrganizationRepository_GetAll(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	orgRepo := postgres.NewOrganizationRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2)
+	mock.ExpectQuery(`SELECT (.+) FROM "organizations"`).WillReturnRows(rows)
+
+	orgs, err := orgRepo.GetAll(context.Background())
+
+	assert.NoError(t, err)
+	assert.Len(t, orgs, 2)
+}
+
+func TestOrganizationRepository_Update(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	orgRepo := postgres.NewOrganizationRepository(bunDB)
+
+	org := &domain.Organization{ID: 1, Name: "Acme", Slug: "acme", RequireMFA: true}
+
+	mock.ExpectExec(`UPDATE "organizations"`).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = orgRepo.Update(context.Background(), org)
+
+	assert.NoError(t, err)
+}
+
+func TestOrganizationRepository_Delete(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	orgRepo := postgres.NewOrganizationRepository(bunDB)
+
+	mock.ExpectExec(`DELETE FROM "organizations"`).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = orgRepo.Delete(context.Background(), 1)
+
+	assert.NoError(t, err)
+}
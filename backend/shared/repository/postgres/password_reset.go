@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/uptrace/bun"
+)
+
+type passwordResetTokenRepository struct {
+	db *bun.DB
+}
+
+// NewPasswordResetTokenRepository creates a new password reset token repository
+func NewPasswordResetTokenRepository(db *bun.DB) domain.PasswordResetTokenRepository {
+	return &passwordResetTokenRepository{db: db}
+}
+
+func (r *passwordResetTokenRepository) Create(ctx context.Context, token *domain.PasswordResetToken) error {
+	token.CreatedAt = time.Now()
+	_, err := r.db.NewInsert().Model(token).Exec(ctx)
+	return err
+}
+
+func (r *passwordResetTokenRepository) GetByHash(ctx context.Context, hash string) (*domain.PasswordResetToken, error) {
+	token := new(domain.PasswordResetToken)
+	err := r.db.NewSelect().Model(token).Where("token_hash = ?", hash).Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (r *passwordResetTokenRepository) MarkUsed(ctx context.Context, id int64) (bool, error) {
+	res, err := r.db.NewUpdate().
+		Model((*domain.PasswordResetToken)(nil)).
+		Set("used_at = ?", time.Now()).
+		Where("id = ?", id).
+		Where("used_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (r *passwordResetTokenRepository) InvalidateByUserID(ctx context.Context, userID int64) error {
+	_, err := r.db.NewUpdate().
+		Model((*domain.PasswordResetToken)(nil)).
+		Set("used_at = ?", time.Now()).
+		Where("user_id = ?", userID).
+		Where("used_at IS NULL").
+		Exec(ctx)
+	return err
+}
@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/uptrace/bun"
+)
+
+type recoveryCodeRepository struct {
+	db *bun.DB
+}
+
+// NewRecoveryCodeRepository creates a new recovery code repository
+func NewRecoveryCodeRepository(db *bun.DB) domain.RecoveryCodeRepository {
+	return &recoveryCodeRepository{db: db}
+}
+
+func (r *recoveryCodeRepository) Replace(ctx context.Context, userID int64, codes []*domain.RecoveryCode) error {
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewDelete().
+			Model((*domain.RecoveryCode)(nil)).
+			Where("user_id = ?", userID).
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		if len(codes) == 0 {
+			return nil
+		}
+
+		now := time.Now()
+		for _, code := range codes {
+			code.UserID = userID
+			code.CreatedAt = now
+		}
+		_, err := tx.NewInsert().Model(&codes).Exec(ctx)
+		return err
+	})
+}
+
+func (r *recoveryCodeRepository) Consume(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	res, err := r.db.NewUpdate().
+		Model((*domain.RecoveryCode)(nil)).
+		Set("used_at = ?", time.Now()).
+		Where("user_id = ?", userID).
+		Where("code_hash = ?", codeHash).
+		Where("used_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r *recoveryCodeRepository) CountUnused(ctx context.Context, userID int64) (int, error) {
+	return r.db.NewSelect().
+		Model((*domain.RecoveryCode)(nil)).
+		Where("user_id = ?", userID).
+		Where("used_at IS NULL").
+		Count(ctx)
+}
+
+func (r *recoveryCodeRepository) DeleteByUserID(ctx context.Context, userID int64) error {
+	_, err := r.db.NewDelete().
+		Model((*domain.RecoveryCode)(nil)).
+		Where("user_id = ?", userID).
+		Exec(ctx)
+	return err
+}
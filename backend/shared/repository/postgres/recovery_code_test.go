@@ -0,0 +1,87 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/repository/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+func TestRecoveryCodeRepository_Replace(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	codeRepo := postgres.NewRecoveryCodeRepository(bunDB)
+
+	codes := []*domain.RecoveryCode{
+		{CodeHash: "hash1"},
+		{CodeHash: "hash2"},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "recovery_codes"`).WillReturnResult(sqlmock.NewResult(0, 2))
+	rows := sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2)
+	mock.ExpectQuery(`INSERT INTO "recovery_codes"`).WillReturnRows(rows)
+	mock.ExpectCommit()
+
+	err = codeRepo.Replace(context.Background(), 1, codes)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), codes[0].UserID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecoveryCodeRepository_Consume(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	codeRepo := postgres.NewRecoveryCodeRepository(bunDB)
+
+	mock.ExpectExec(`UPDATE "recovery_codes"`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	consumed, err := codeRepo.Consume(context.Background(), 1, "hash")
+
+	assert.NoError(t, err)
+	assert.True(t, consumed)
+}
+
+func TestRecoveryCodeRepository_CountUnused(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	codeRepo := postgres.NewRecoveryCodeRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"count"}).AddRow(8)
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "recovery_codes"`).WillReturnRows(rows)
+
+	count, err := codeRepo.CountUnused(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 8, count)
+}
+
+func TestRecoveryCodeRepository_DeleteByUserID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	codeRepo := postgres.NewRecoveryCodeRepository(bunDB)
+
+	mock.ExpectExec(`DELETE FROM "recovery_codes"`).WillReturnResult(sqlmock.NewResult(0, 10))
+
+	err = codeRepo.DeleteByUserID(context.Background(), 1)
+
+	assert.NoError(t, err)
+}
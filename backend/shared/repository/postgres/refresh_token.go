@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/uptrace/bun"
+)
+
+type refreshTokenRepository struct {
+	db *bun.DB
+}
+
+// NewRefreshTokenRepository creates a new refresh token repository
+func NewRefreshTokenRepository(db *bun.DB) domain.RefreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+func (r *refreshTokenRepository) Create(ctx context.Context, token *domain.RefreshToken) error {
+	token.CreatedAt = time.Now()
+	_, err := r.db.NewInsert().Model(token).Exec(ctx)
+	return err
+}
+
+func (r *refreshTokenRepository) GetByHash(ctx context.Context, hash string) (*domain.RefreshToken, error) {
+	token := new(domain.RefreshToken)
+	err := r.db.NewSelect().Model(token).Where("token_hash = ?", hash).Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (r *refreshTokenRepository) MarkUsed(ctx context.Context, id int64) (bool, error) {
+	res, err := r.db.NewUpdate().
+		Model((*domain.RefreshToken)(nil)).
+		Set("used_at = ?", time.Now()).
+		Where("id = ?", id).
+		Where("used_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
@@ -0,0 +1,73 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/repository/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+func TestRefreshTokenRepository_Create(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	tokenRepo := postgres.NewRefreshTokenRepository(bunDB)
+
+	token := &domain.RefreshToken{
+		SessionID: 1,
+		TokenHash: "hash",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
+	mock.ExpectQuery(`INSERT INTO "refresh_tokens"`).WillReturnRows(rows)
+
+	err = tokenRepo.Create(context.Background(), token)
+
+	assert.NoError(t, err)
+}
+
+func TestRefreshTokenRepository_GetByHash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	tokenRepo := postgres.NewRefreshTokenRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"id"})
+	mock.ExpectQuery(`SELECT (.+) FROM "refresh_tokens"`).WillReturnRows(rows)
+
+	token, err := tokenRepo.GetByHash(context.Background(), "hash")
+
+	assert.NoError(t, err)
+	assert.Nil(t, token)
+}
+
+func TestRefreshTokenRepository_MarkUsed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	tokenRepo := postgres.NewRefreshTokenRepository(bunDB)
+
+	mock.ExpectExec(`UPDATE "refresh_tokens"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE "refresh_tokens"`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	first, err := tokenRepo.MarkUsed(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.True(t, first)
+
+	second, err := tokenRepo.MarkUsed(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.False(t, second)
+}
@@ -0,0 +1,47 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/uptrace/bun"
+)
+
+type rotationCursorRepository struct {
+	db *bun.DB
+}
+
+// NewRotationCursorRepository creates a new rotation cursor repository
+func NewRotationCursorRepository(db *bun.DB) domain.RotationCursorRepository {
+	return &rotationCursorRepository{db: db}
+}
+
+func (r *rotationCursorRepository) Get(ctx context.Context, groupID int64) (*domain.RotationCursor, error) {
+	cursor := new(domain.RotationCursor)
+	err := idb(ctx, r.db).NewSelect().Model(cursor).Where("group_id = ?", groupID).Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cursor, nil
+}
+
+// Set moves the group's cursor to the member that was just assigned
+func (r *rotationCursorRepository) Set(ctx context.Context, groupID, lastMemberID int64) error {
+	cursor := &domain.RotationCursor{
+		GroupID:      groupID,
+		LastMemberID: lastMemberID,
+		UpdatedAt:    time.Now(),
+	}
+	_, err := idb(ctx, r.db).NewInsert().
+		Model(cursor).
+		On("CONFLICT (group_id) DO UPDATE").
+		Set("last_member_id = EXCLUDED.last_member_id").
+		Set("updated_at = EXCLUDED.updated_at").
+		Exec(ctx)
+	return err
+}
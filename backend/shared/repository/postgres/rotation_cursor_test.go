@@ -0,0 +1,44 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/raufhm/fairflow/shared/repository/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+func TestRotationCursorRepository_Get(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	cursorRepo := postgres.NewRotationCursorRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"group_id", "last_member_id"}).AddRow(1, 7)
+	mock.ExpectQuery(`SELECT (.+) FROM "rotation_cursors" AS "rotation_cursor" WHERE \(group_id = 1\)`).WillReturnRows(rows)
+
+	cursor, err := cursorRepo.Get(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), cursor.LastMemberID)
+}
+
+func TestRotationCursorRepository_Set(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	cursorRepo := postgres.NewRotationCursorRepository(bunDB)
+
+	mock.ExpectExec(`INSERT INTO "rotation_cursors" (.+) ON CONFLICT \(group_id\) DO UPDATE SET last_member_id = EXCLUDED.last_member_id`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = cursorRepo.Set(context.Background(), 1, 7)
+
+	assert.NoError(t, err)
+}
@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/uptrace/bun"
+)
+
+type sessionRepository struct {
+	db *bun.DB
+}
+
+// NewSessionRepository creates a new session repository
+func NewSessionRepository(db *bun.DB) domain.SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *domain.Session) error {
+	now := time.Now()
+	session.CreatedAt = now
+	session.LastSeenAt = now
+	_, err := r.db.NewInsert().Model(session).Exec(ctx)
+	return err
+}
+
+func (r *sessionRepository) GetByID(ctx context.Context, id int64) (*domain.Session, error) {
+	session := new(domain.Session)
+	err := r.db.NewSelect().Model(session).Where("id = ?", id).Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (r *sessionRepository) GetActiveByUserID(ctx context.Context, userID int64) ([]*domain.Session, error) {
+	var sessions []*domain.Session
+	err := r.db.NewSelect().
+		Model(&sessions).
+		Where("user_id = ?", userID).
+		Where("revoked_at IS NULL").
+		Where("expires_at > ?", time.Now()).
+		Order("last_seen_at DESC").
+		Scan(ctx)
+	return sessions, err
+}
+
+func (r *sessionRepository) Touch(ctx context.Context, id int64, ipAddress string) error {
+	_, err := r.db.NewUpdate().
+		Model((*domain.Session)(nil)).
+		Set("last_seen_at = ?", time.Now()).
+		Set("ip_address = ?", ipAddress).
+		Where("id = ?", id).
+		Exec(ctx)
+	return err
+}
+
+func (r *sessionRepository) Revoke(ctx context.Context, id int64) error {
+	_, err := r.db.NewUpdate().
+		Model((*domain.Session)(nil)).
+		Set("revoked_at = ?", time.Now()).
+		Where("id = ?", id).
+		Where("revoked_at IS NULL").
+		Exec(ctx)
+	return err
+}
+
+func (r *sessionRepository) RevokeAllByUserID(ctx context.Context, userID int64) error {
+	_, err := r.db.NewUpdate().
+		Model((*domain.Session)(nil)).
+		Set("revoked_at = ?", time.Now()).
+		Where("user_id = ?", userID).
+		Where("revoked_at IS NULL").
+		Exec(ctx)
+	return err
+}
@@ -0,0 +1,115 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/repository/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+func TestSessionRepository_Create(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	sessionRepo := postgres.NewSessionRepository(bunDB)
+
+	session := &domain.Session{
+		UserID:    1,
+		UserAgent: "test-agent",
+		IPAddress: "127.0.0.1",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
+	mock.ExpectQuery(`INSERT INTO "sessions"`).WillReturnRows(rows)
+
+	err = sessionRepo.Create(context.Background(), session)
+
+	assert.NoError(t, err)
+}
+
+func TestSessionRepository_GetByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	sessionRepo := postgres.NewSessionRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
+	mock.ExpectQuery(`SELECT (.+) FROM "sessions"`).WillReturnRows(rows)
+
+	session, err := sessionRepo.GetByID(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, session)
+}
+
+func TestSessionRepository_GetActiveByUserID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	sessionRepo := postgres.NewSessionRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
+	mock.ExpectQuery(`SELECT (.+) FROM "sessions" (.+) WHERE (.+)revoked_at IS NULL`).WillReturnRows(rows)
+
+	_, err = sessionRepo.GetActiveByUserID(context.Background(), 1)
+
+	assert.NoError(t, err)
+}
+
+func TestSessionRepository_Touch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	sessionRepo := postgres.NewSessionRepository(bunDB)
+
+	mock.ExpectExec(`UPDATE "sessions"`).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = sessionRepo.Touch(context.Background(), 1, "127.0.0.1")
+
+	assert.NoError(t, err)
+}
+
+func TestSessionRepository_Revoke(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	sessionRepo := postgres.NewSessionRepository(bunDB)
+
+	mock.ExpectExec(`UPDATE "sessions"`).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = sessionRepo.Revoke(context.Background(), 1)
+
+	assert.NoError(t, err)
+}
+
+func TestSessionRepository_RevokeAllByUserID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	sessionRepo := postgres.NewSessionRepository(bunDB)
+
+	mock.ExpectExec(`UPDATE "sessions"`).WillReturnResult(sqlmock.NewResult(1, 3))
+
+	err = sessionRepo.RevokeAllByUserID(context.Background(), 1)
+
+	assert.NoError(t, err)
+}
@@ -0,0 +1,125 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/uptrace/bun"
+)
+
+type timeOffRepository struct {
+	db *bun.DB
+}
+
+// NewTimeOffRepository creates a new time off repository
+func NewTimeOffRepository(db *bun.DB) domain.TimeOffRepository {
+	return &timeOffRepository{db: db}
+}
+
+func (r *timeOffRepository) Create(ctx context.Context, window *domain.TimeOffWindow) error {
+	window.CreatedAt = time.Now()
+	_, err := idb(ctx, r.db).NewInsert().Model(window).Returning("*").Exec(ctx)
+	return err
+}
+
+func (r *timeOffRepository) GetByID(ctx context.Context, id int64) (*domain.TimeOffWindow, error) {
+	window := new(domain.TimeOffWindow)
+	err := idb(ctx, r.db).NewSelect().Model(window).Where("id = ?", id).Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return window, nil
+}
+
+func (r *timeOffRepository) GetByMemberID(ctx context.Context, memberID int64, since time.Time) ([]*domain.TimeOffWindow, error) {
+	var windows []*domain.TimeOffWindow
+	err := idb(ctx, r.db).NewSelect().
+		Model(&windows).
+		Where("member_id = ?", memberID).
+		Where("ends_at > ?", since).
+		Order("starts_at ASC").
+		Scan(ctx)
+	return windows, err
+}
+
+func (r *timeOffRepository) GetOutOfOfficeMemberIDs(ctx context.Context, memberIDs []int64, at time.Time) (map[int64]bool, error) {
+	outOfOffice := make(map[int64]bool)
+	if len(memberIDs) == 0 {
+		return outOfOffice, nil
+	}
+
+	var ids []int64
+	err := idb(ctx, r.db).NewSelect().
+		Model((*domain.TimeOffWindow)(nil)).
+		Column("member_id").
+		Distinct().
+		Where("member_id IN (?)", bun.In(memberIDs)).
+		Where("starts_at <= ?", at).
+		Where("ends_at > ?", at).
+		Scan(ctx, &ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		outOfOffice[id] = true
+	}
+	return outOfOffice, nil
+}
+
+func (r *timeOffRepository) Delete(ctx context.Context, id int64) error {
+	_, err := idb(ctx, r.db).NewDelete().Model((*domain.TimeOffWindow)(nil)).Where("id = ?", id).Exec(ctx)
+	return err
+}
+
+func (r *timeOffRepository) GetStartsToNotify(ctx context.Context, at time.Time, limit int) ([]*domain.TimeOffWindow, error) {
+	var windows []*domain.TimeOffWindow
+	err := idb(ctx, r.db).NewSelect().
+		Model(&windows).
+		Where("starts_at <= ?", at).
+		Where("start_notified_at IS NULL").
+		Order("starts_at ASC").
+		Limit(limit).
+		Scan(ctx)
+	return windows, err
+}
+
+func (r *timeOffRepository) GetEndsToNotify(ctx context.Context, at time.Time, limit int) ([]*domain.TimeOffWindow, error) {
+	var windows []*domain.TimeOffWindow
+	err := idb(ctx, r.db).NewSelect().
+		Model(&windows).
+		Where("ends_at <= ?", at).
+		Where("end_notified_at IS NULL").
+		Order("ends_at ASC").
+		Limit(limit).
+		Scan(ctx)
+	return windows, err
+}
+
+func (r *timeOffRepository) MarkStartNotified(ctx context.Context, id int64) (bool, error) {
+	return r.markNotified(ctx, id, "start_notified_at")
+}
+
+func (r *timeOffRepository) MarkEndNotified(ctx context.Context, id int64) (bool, error) {
+	return r.markNotified(ctx, id, "end_notified_at")
+}
+
+// markNotified sets a notification timestamp unless it is already set
+func (r *timeOffRepository) markNotified(ctx context.Context, id int64, column string) (bool, error) {
+	res, err := idb(ctx, r.db).NewUpdate().
+		Model((*domain.TimeOffWindow)(nil)).
+		Set("? = ?", bun.Ident(column), time.Now()).
+		Where("id = ?", id).
+		Where("? IS NULL", bun.Ident(column)).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
@@ -0,0 +1,174 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/repository/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+func TestTimeOffRepository_Create(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	windowRepo := postgres.NewTimeOffRepository(bunDB)
+
+	now := time.Now()
+	window := &domain.TimeOffWindow{
+		MemberID:  1,
+		StartsAt:  now,
+		EndsAt:    now.Add(48 * time.Hour),
+		Reason:    domain.TimeOffVacation,
+		CreatedBy: 2,
+	}
+
+	rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
+	mock.ExpectQuery(`INSERT INTO "time_off_windows"`).WillReturnRows(rows)
+
+	err = windowRepo.Create(context.Background(), window)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), window.ID)
+}
+
+func TestTimeOffRepository_GetByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	windowRepo := postgres.NewTimeOffRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"id", "member_id"}).AddRow(1, 1)
+	mock.ExpectQuery(`SELECT (.+) FROM "time_off_windows" AS "time_off_window" WHERE \(id = 1\)`).WillReturnRows(rows)
+
+	window, err := windowRepo.GetByID(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), window.MemberID)
+}
+
+func TestTimeOffRepository_GetByMemberID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	windowRepo := postgres.NewTimeOffRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"id", "member_id"}).AddRow(1, 1)
+	mock.ExpectQuery(`SELECT (.+) FROM "time_off_windows" AS "time_off_window" WHERE \(member_id = 1\) AND \(ends_at > (.+)\) ORDER BY "starts_at" ASC`).WillReturnRows(rows)
+
+	windows, err := windowRepo.GetByMemberID(context.Background(), 1, time.Now())
+
+	assert.NoError(t, err)
+	assert.Len(t, windows, 1)
+}
+
+func TestTimeOffRepository_GetOutOfOfficeMemberIDs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	windowRepo := postgres.NewTimeOffRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"member_id"}).AddRow(2)
+	mock.ExpectQuery(`SELECT DISTINCT "time_off_window"."member_id" FROM "time_off_windows" AS "time_off_window" WHERE \(member_id IN \(1, 2\)\) AND \(starts_at <= (.+)\) AND \(ends_at > (.+)\)`).WillReturnRows(rows)
+
+	outOfOffice, err := windowRepo.GetOutOfOfficeMemberIDs(context.Background(), []int64{1, 2}, time.Now())
+
+	assert.NoError(t, err)
+	assert.False(t, outOfOffice[1])
+	assert.True(t, outOfOffice[2])
+}
+
+func TestTimeOffRepository_Delete(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	windowRepo := postgres.NewTimeOffRepository(bunDB)
+
+	mock.ExpectExec(`DELETE FROM "time_off_windows" AS "time_off_window" WHERE \(id = 1\)`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = windowRepo.Delete(context.Background(), 1)
+
+	assert.NoError(t, err)
+}
+
+func TestTimeOffRepository_GetStartsToNotify(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	windowRepo := postgres.NewTimeOffRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"id", "member_id"}).AddRow(1, 1)
+	mock.ExpectQuery(`SELECT (.+) FROM "time_off_windows" AS "time_off_window" WHERE \(starts_at <= (.+)\) AND \(start_notified_at IS NULL\) ORDER BY "starts_at" ASC LIMIT 100`).WillReturnRows(rows)
+
+	windows, err := windowRepo.GetStartsToNotify(context.Background(), time.Now(), 100)
+
+	assert.NoError(t, err)
+	assert.Len(t, windows, 1)
+}
+
+func TestTimeOffRepository_GetEndsToNotify(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	windowRepo := postgres.NewTimeOffRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"id", "member_id"}).AddRow(1, 1)
+	mock.ExpectQuery(`SELECT (.+) FROM "time_off_windows" AS "time_off_window" WHERE \(ends_at <= (.+)\) AND \(end_notified_at IS NULL\) ORDER BY "ends_at" ASC LIMIT 100`).WillReturnRows(rows)
+
+	windows, err := windowRepo.GetEndsToNotify(context.Background(), time.Now(), 100)
+
+	assert.NoError(t, err)
+	assert.Len(t, windows, 1)
+}
+
+func TestTimeOffRepository_MarkStartNotified(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	windowRepo := postgres.NewTimeOffRepository(bunDB)
+
+	mock.ExpectExec(`UPDATE "time_off_windows" AS "time_off_window" SET "start_notified_at" = (.+) WHERE \(id = 1\) AND \("start_notified_at" IS NULL\)`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	marked, err := windowRepo.MarkStartNotified(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.True(t, marked)
+}
+
+func TestTimeOffRepository_MarkEndNotified(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	windowRepo := postgres.NewTimeOffRepository(bunDB)
+
+	// Another caller already announced the end
+	mock.ExpectExec(`UPDATE "time_off_windows" AS "time_off_window" SET "end_notified_at" = (.+) WHERE \(id = 1\) AND \("end_notified_at" IS NULL\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	marked, err := windowRepo.MarkEndNotified(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.False(t, marked)
+}
@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/uptrace/bun"
+)
+
+type totpFactorRepository struct {
+	db *bun.DB
+}
+
+// NewTOTPFactorRepository creates a new TOTP factor repository
+func NewTOTPFactorRepository(db *bun.DB) domain.TOTPFactorRepository {
+	return &totpFactorRepository{db: db}
+}
+
+func (r *totpFactorRepository) Create(ctx context.Context, factor *domain.TOTPFactor) error {
+	factor.CreatedAt = time.Now()
+	_, err := r.db.NewInsert().Model(factor).Exec(ctx)
+	return err
+}
+
+func (r *totpFactorRepository) GetByUserID(ctx context.Context, userID int64) (*domain.TOTPFactor, error) {
+	factor := new(domain.TOTPFactor)
+	err := r.db.NewSelect().Model(factor).Where("user_id = ?", userID).Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return factor, nil
+}
+
+func (r *totpFactorRepository) Confirm(ctx context.Context, id int64) error {
+	_, err := r.db.NewUpdate().
+		Model((*domain.TOTPFactor)(nil)).
+		Set("confirmed_at = ?", time.Now()).
+		Where("id = ?", id).
+		Exec(ctx)
+	return err
+}
+
+func (r *totpFactorRepository) UseStep(ctx context.Context, id int64, step int64) (bool, error) {
+	res, err := r.db.NewUpdate().
+		Model((*domain.TOTPFactor)(nil)).
+		Set("last_used_step = ?", step).
+		Where("id = ?", id).
+		Where("last_used_step < ?", step).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (r *totpFactorRepository) DeleteByUserID(ctx context.Context, userID int64) error {
+	_, err := r.db.NewDelete().
+		Model((*domain.TOTPFactor)(nil)).
+		Where("user_id = ?", userID).
+		Exec(ctx)
+	return err
+}
@@ -0,0 +1,97 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/raufhm/fairflow/shared/repository/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+func TestTOTPFactorRepository_Create(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	factorRepo := postgres.NewTOTPFactorRepository(bunDB)
+
+	factor := &domain.TOTPFactor{
+		UserID: 1,
+		Secret: "encrypted",
+	}
+
+	rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
+	mock.ExpectQuery(`INSERT INTO "totp_factors"`).WillReturnRows(rows)
+
+	err = factorRepo.Create(context.Background(), factor)
+
+	assert.NoError(t, err)
+}
+
+func TestTOTPFactorRepository_GetByUserID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	factorRepo := postgres.NewTOTPFactorRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"id"})
+	mock.ExpectQuery(`SELECT (.+) FROM "totp_factors"`).WillReturnRows(rows)
+
+	factor, err := factorRepo.GetByUserID(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Nil(t, factor)
+}
+
+func TestTOTPFactorRepository_Confirm(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	factorRepo := postgres.NewTOTPFactorRepository(bunDB)
+
+	mock.ExpectExec(`UPDATE "totp_factors"`).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = factorRepo.Confirm(context.Background(), 1)
+
+	assert.NoError(t, err)
+}
+
+func TestTOTPFactorRepository_UseStep(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	factorRepo := postgres.NewTOTPFactorRepository(bunDB)
+
+	mock.ExpectExec(`UPDATE "totp_factors"`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	used, err := factorRepo.UseStep(context.Background(), 1, 100)
+
+	assert.NoError(t, err)
+	assert.False(t, used)
+}
+
+func TestTOTPFactorRepository_DeleteByUserID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	factorRepo := postgres.NewTOTPFactorRepository(bunDB)
+
+	mock.ExpectExec(`DELETE FROM "totp_factors"`).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = factorRepo.DeleteByUserID(context.Background(), 1)
+
+	assert.NoError(t, err)
+}
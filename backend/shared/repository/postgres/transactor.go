@@ -0,0 +1,29 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/uptrace/bun"
+)
+
+type transactor struct {
+	db *bun.DB
+}
+
+// NewTransactor creates a transactor for repositories sharing db
+func NewTransactor(db *bun.DB) domain.Transactor {
+	return &transactor{db: db}
+}
+
+// WithinTx commits when fn succeeds and rolls back when it returns an error
+func (t *transactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if tx, ok := ctx.Value(txKey{}).(bun.Tx); ok {
+		return tx.RunInTx(ctx, nil, func(ctx context.Context, sp bun.Tx) error {
+			return fn(context.WithValue(ctx, txKey{}, sp))
+		})
+	}
+	return t.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
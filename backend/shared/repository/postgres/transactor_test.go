@@ -0,0 +1,86 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/raufhm/fairflow/shared/repository/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+func TestTransactor_WithinTx(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	transactor := postgres.NewTransactor(bunDB)
+	memberRepo := postgres.NewMemberRepository(bunDB)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SAVEPOINT`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`UPDATE "members"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`RELEASE SAVEPOINT`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err = transactor.WithinTx(context.Background(), func(ctx context.Context) error {
+		// Nested calls run in a savepoint of the outer transaction
+		return transactor.WithinTx(ctx, func(ctx context.Context) error {
+			return memberRepo.IncrementOpenAssignments(ctx, 1)
+		})
+	})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTransactor_WithinTxRollsBack(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	transactor := postgres.NewTransactor(bunDB)
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	failure := errors.New("boom")
+	err = transactor.WithinTx(context.Background(), func(ctx context.Context) error {
+		return failure
+	})
+
+	assert.ErrorIs(t, err, failure)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTransactor_WithinTxRollsBackSavepoint(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	transactor := postgres.NewTransactor(bunDB)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SAVEPOINT`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`ROLLBACK TO SAVEPOINT`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	failure := errors.New("boom")
+	err = transactor.WithinTx(context.Background(), func(ctx context.Context) error {
+		// The outer transaction survives a failed nested call it chooses to ignore
+		nestedErr := transactor.WithinTx(ctx, func(ctx context.Context) error {
+			return failure
+		})
+		assert.ErrorIs(t, nestedErr, failure)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
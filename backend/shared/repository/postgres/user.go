@@ -22,7 +22,7 @@ func (r *userRepository) Create(ctx context.Context, user *domain.User) error {
 	now := time.Now()
 	user.CreatedAt = now
 	user.UpdatedAt = now
-	_, err := r.db.NewInsert().Model(user).Exec(ctx)
+	_, err := idb(ctx, r.db).NewInsert().Model(user).Exec(ctx)
 	return err
 }
 
@@ -76,3 +76,78 @@ func (r *userRepository) UpdateRole(ctx context.Context, id int64, role domain.U
 		Exec(ctx)
 	return err
 }
+
+func (r *userRepository) GetByOrganizationID(ctx context.Context, orgID int64) ([]*domain.User, error) {
+	var users []*domain.User
+	err := r.db.NewSelect().Model(&users).Where("organization_id = ?", orgID).Order("name ASC").Scan(ctx)
+	return users, err
+}
+
+func (r *userRepository) MarkEmailVerified(ctx context.Context, id int64) error {
+	_, err := r.db.NewUpdate().
+		Model((*domain.User)(nil)).
+		Set("email_verified_at = ?", time.Now()).
+		Set("updated_at = ?", time.Now()).
+		Where("id = ?", id).
+		Where("email_verified_at IS NULL").
+		Exec(ctx)
+	return err
+}
+
+func (r *userRepository) Search(ctx context.Context, filter domain.UserFilter) ([]*domain.User, int, error) {
+	var users []*domain.User
+	query := r.db.NewSelect().Model(&users)
+
+	if filter.Query != "" {
+		pattern := "%" + filter.Query + "%"
+		query = query.WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("name ILIKE ?", pattern).WhereOr("email ILIKE ?", pattern)
+		})
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	if !filter.AllOrganizations {
+		if filter.OrganizationID != nil {
+			query = query.Where("organization_id = ?", *filter.OrganizationID)
+		} else {
+			query = query.Where("organization_id IS NULL")
+		}
+	}
+	if filter.Active != nil {
+		if *filter.Active {
+			query = query.Where("deactivated_at IS NULL")
+		} else {
+			query = query.Where("deactivated_at IS NOT NULL")
+		}
+	}
+
+	total, err := query.Count(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = query.Order("created_at DESC").Limit(filter.Limit).Offset(filter.Offset).Scan(ctx)
+	return users, total, err
+}
+
+func (r *userRepository) Deactivate(ctx context.Context, id int64) error {
+	_, err := r.db.NewUpdate().
+		Model((*domain.User)(nil)).
+		Set("deactivated_at = ?", time.Now()).
+		Set("updated_at = ?", time.Now()).
+		Where("id = ?", id).
+		Where("deactivated_at IS NULL").
+		Exec(ctx)
+	return err
+}
+
+func (r *userRepository) Reactivate(ctx context.Context, id int64) error {
+	_, err := r.db.NewUpdate().
+		Model((*domain.User)(nil)).
+		Set("deactivated_at = NULL").
+		Set("updated_at = ?", time.Now()).
+		Where("id = ?", id).
+		Exec(ctx)
+	return err
+}
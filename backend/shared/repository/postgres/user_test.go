@@ -133,3 +133,93 @@ func TestUserRepository_UpdateRole(t *testing.T) {
 
 	assert.NoError(t, err)
 }
+
+func TestUserRepository_GetByOrganizationID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	userRepo := postgres.NewUserRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"id", "organization_id"}).AddRow(1, 3).AddRow(2, 3)
+	mock.ExpectQuery(`SELECT (.+) FROM "users" AS "user" WHERE \(organization_id = 3\)`).WillReturnRows(rows)
+
+	users, err := userRepo.GetByOrganizationID(context.Background(), 3)
+
+	assert.NoError(t, err)
+	assert.Len(t, users, 2)
+}
+
+func TestUserRepository_MarkEmailVerified(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	userRepo := postgres.NewUserRepository(bunDB)
+
+	mock.ExpectExec(`UPDATE "users" AS "user" SET email_verified_at = (.+) WHERE \(id = 1\) AND \(email_verified_at IS NULL\)`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = userRepo.MarkEmailVerified(context.Background(), 1)
+
+	assert.NoError(t, err)
+}
+
+func TestUserRepository_Search(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	userRepo := postgres.NewUserRepository(bunDB)
+
+	orgID := int64(3)
+	active := true
+	rows := sqlmock.NewRows([]string{"id", "organization_id"}).AddRow(1, 3)
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "users"`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(41))
+	mock.ExpectQuery(`SELECT (.+) FROM "users" AS "user" WHERE \(\(name ILIKE '%ann%'\) OR \(email ILIKE '%ann%'\)\) AND \(role = 'manager'\) AND \(organization_id = 3\) AND \(deactivated_at IS NULL\) ORDER BY "created_at" DESC LIMIT 20 OFFSET 40`).WillReturnRows(rows)
+
+	users, total, err := userRepo.Search(context.Background(), domain.UserFilter{
+		Query:          "ann",
+		Role:           domain.RoleManager,
+		OrganizationID: &orgID,
+		Active:         &active,
+		Limit:          20,
+		Offset:         40,
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+	assert.Equal(t, 41, total)
+}
+
+func TestUserRepository_Deactivate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	userRepo := postgres.NewUserRepository(bunDB)
+
+	mock.ExpectExec(`UPDATE "users" AS "user" SET deactivated_at = (.+) WHERE \(id = 1\) AND \(deactivated_at IS NULL\)`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = userRepo.Deactivate(context.Background(), 1)
+
+	assert.NoError(t, err)
+}
+
+func TestUserRepository_Reactivate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	userRepo := postgres.NewUserRepository(bunDB)
+
+	mock.ExpectExec(`UPDATE "users" AS "user" SET deactivated_at = NULL, (.+) WHERE \(id = 1\)`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = userRepo.Reactivate(context.Background(), 1)
+
+	assert.NoError(t, err)
+}
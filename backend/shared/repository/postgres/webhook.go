@@ -32,6 +32,15 @@ func (r *webhookRepository) Create(ctx context.Context, webhook *domain.Webhook)
 	return err
 }
 
+func (r *webhookRepository) GetByID(ctx context.Context, id int64) (*domain.Webhook, error) {
+	webhook := &domain.Webhook{}
+	err := r.db.NewSelect().Model(webhook).Where("id = ?", id).Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
 func (r *webhookRepository) GetByGroupID(ctx context.Context, groupID int64) ([]*domain.Webhook, error) {
 	var webhooks []*domain.Webhook
 
@@ -35,6 +35,23 @@ func TestWebhookRepository_Create(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestWebhookRepository_GetByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	bunDB := bun.NewDB(db, pgdialect.New())
+	webhookRepo := postgres.NewWebhookRepository(bunDB)
+
+	rows := sqlmock.NewRows([]string{"id", "group_id"}).AddRow(1, 2)
+	mock.ExpectQuery(`SELECT (.+) FROM "webhooks"`).WillReturnRows(rows)
+
+	webhook, err := webhookRepo.GetByID(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), webhook.GroupID)
+}
+
 func TestWebhookRepository_GetByGroupID(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
@@ -0,0 +1,209 @@
+package strategy
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"math/rand/v2"
+	"sort"
+
+	"github.com/raufhm/fairflow/shared/domain"
+)
+
+// weightedRoundRobin picks the member furthest below their weighted share of
+// all assignments
+type weightedRoundRobin struct{}
+
+// NewWeightedRoundRobin creates the weighted round robin strategy. It takes no parameters.
+func NewWeightedRoundRobin(params json.RawMessage) (Strategy, error) {
+	if err := decodeParams(params, &struct{}{}); err != nil {
+		return nil, err
+	}
+	return weightedRoundRobin{}, nil
+}
+
+func (weightedRoundRobin) Pick(ctx context.Context, input Input) (*domain.Member, error) {
+	counts, err := input.History.AssignmentCounts(ctx, memberIDs(input.Members))
+	if err != nil {
+		return nil, err
+	}
+
+	var lowestRatio float64 = math.MaxFloat64
+	var nextAssignee *domain.Member
+
+	for _, member := range input.Members {
+		actual := float64(counts[member.ID])
+		expected := float64(member.Weight) / 100.0
+
+		var ratio float64
+		if expected > 0 {
+			ratio = actual / expected
+		} else {
+			ratio = actual
+		}
+
+		if ratio < lowestRatio {
+			lowestRatio = ratio
+			nextAssignee = member
+		}
+	}
+
+	return nextAssignee, nil
+}
+
+// strictRotation walks members in ascending ID order, starting after the member
+// recorded in the group's rotation cursor. Ineligible members are skipped, and
+// members added or removed since the last assignment simply take or leave their
+// place in the order.
+type strictRotation struct{}
+
+// NewStrictRotation creates the strict rotation strategy. It takes no parameters.
+func NewStrictRotation(params json.RawMessage) (Strategy, error) {
+	if err := decodeParams(params, &struct{}{}); err != nil {
+		return nil, err
+	}
+	return strictRotation{}, nil
+}
+
+func (strictRotation) Pick(ctx context.Context, input Input) (*domain.Member, error) {
+	if len(input.Members) == 0 {
+		return nil, nil
+	}
+
+	lastMemberID, err := input.History.LastRotationMemberID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ordered := make([]*domain.Member, len(input.Members))
+	copy(ordered, input.Members)
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].ID < ordered[j].ID })
+
+	for _, member := range ordered {
+		if member.ID > lastMemberID {
+			return member, nil
+		}
+	}
+	return ordered[0], nil
+}
+
+// LeastOpenLoadParams configures the least-open-load strategy
+type LeastOpenLoadParams struct {
+	// Weighted divides each member's open assignments by their weight, so
+	// heavier members are expected to carry more open work
+	Weighted bool `json:"weighted"`
+}
+
+// leastOpenLoad picks the member with the fewest open assignments
+type leastOpenLoad struct {
+	params LeastOpenLoadParams
+}
+
+// NewLeastOpenLoad creates the least-open-load strategy
+func NewLeastOpenLoad(params json.RawMessage) (Strategy, error) {
+	s := &leastOpenLoad{}
+	if err := decodeParams(params, &s.params); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *leastOpenLoad) Pick(ctx context.Context, input Input) (*domain.Member, error) {
+	var lowestLoad float64 = math.MaxFloat64
+	var nextAssignee *domain.Member
+
+	for _, member := range input.Members {
+		load := float64(member.CurrentOpenAssignments)
+		if s.params.Weighted && member.Weight > 0 {
+			load = load / (float64(member.Weight) / 100.0)
+		}
+
+		if load < lowestLoad {
+			lowestLoad = load
+			nextAssignee = member
+		}
+	}
+
+	return nextAssignee, nil
+}
+
+// weightedRandom picks a member at random with probability proportional to
+// their weight. When every weight is zero all members are equally likely.
+type weightedRandom struct {
+	random func() float64
+}
+
+// NewWeightedRandom creates the weighted random strategy. It takes no parameters.
+func NewWeightedRandom(params json.RawMessage) (Strategy, error) {
+	if err := decodeParams(params, &struct{}{}); err != nil {
+		return nil, err
+	}
+	return &weightedRandom{random: rand.Float64}, nil
+}
+
+func (s *weightedRandom) Pick(ctx context.Context, input Input) (*domain.Member, error) {
+	if len(input.Members) == 0 {
+		return nil, nil
+	}
+
+	totalWeight := 0
+	for _, member := range input.Members {
+		if member.Weight > 0 {
+			totalWeight += member.Weight
+		}
+	}
+	if totalWeight == 0 {
+		return input.Members[int(s.random()*float64(len(input.Members)))], nil
+	}
+
+	target := s.random() * float64(totalWeight)
+	for _, member := range input.Members {
+		if member.Weight <= 0 {
+			continue
+		}
+		target -= float64(member.Weight)
+		if target < 0 {
+			return member, nil
+		}
+	}
+
+	// Guard against floating point rounding on the last member
+	for i := len(input.Members) - 1; i >= 0; i-- {
+		if input.Members[i].Weight > 0 {
+			return input.Members[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// leastRecentlyAssigned picks the member who has waited longest since their
+// last assignment. Members who were never assigned go first.
+type leastRecentlyAssigned struct{}
+
+// NewLeastRecentlyAssigned creates the least-recently-assigned strategy. It takes no parameters.
+func NewLeastRecentlyAssigned(params json.RawMessage) (Strategy, error) {
+	if err := decodeParams(params, &struct{}{}); err != nil {
+		return nil, err
+	}
+	return leastRecentlyAssigned{}, nil
+}
+
+func (leastRecentlyAssigned) Pick(ctx context.Context, input Input) (*domain.Member, error) {
+	lastAssigned, err := input.History.LastAssignedAt(ctx, memberIDs(input.Members))
+	if err != nil {
+		return nil, err
+	}
+
+	var nextAssignee *domain.Member
+	for _, member := range input.Members {
+		at, assigned := lastAssigned[member.ID]
+		if !assigned {
+			return member, nil
+		}
+		if nextAssignee == nil || at.Before(lastAssigned[nextAssignee.ID]) {
+			nextAssignee = member
+		}
+	}
+
+	return nextAssignee, nil
+}
@@ -0,0 +1,188 @@
+package strategy
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// history serves fixed assignment history to strategies
+type history struct {
+	counts       map[int64]int
+	lastAssigned map[int64]time.Time
+	lastRotation int64
+}
+
+func (h history) AssignmentCounts(ctx context.Context, memberIDs []int64) (map[int64]int, error) {
+	return h.counts, nil
+}
+
+func (h history) LastAssignedAt(ctx context.Context, memberIDs []int64) (map[int64]time.Time, error) {
+	return h.lastAssigned, nil
+}
+
+func (h history) LastRotationMemberID(ctx context.Context) (int64, error) {
+	return h.lastRotation, nil
+}
+
+// member creates a member with the given ID, weight and open assignments
+func member(id int64, weight, open int) *domain.Member {
+	return &domain.Member{ID: id, Weight: weight, CurrentOpenAssignments: open, Active: true, Available: true}
+}
+
+func TestStrategies_Pick(t *testing.T) {
+	hoursAgo := func(h int) time.Time { return time.Now().Add(-time.Duration(h) * time.Hour) }
+
+	tests := []struct {
+		name     string
+		strategy Strategy
+		members  []*domain.Member
+		history  history
+		want     int64
+	}{
+		{
+			name:     "weighted round robin picks the member furthest below their share",
+			strategy: weightedRoundRobin{},
+			members:  []*domain.Member{member(1, 50, 0), member(2, 50, 0)},
+			history:  history{counts: map[int64]int{1: 3, 2: 2}},
+			want:     2,
+		},
+		{
+			name:     "weighted round robin favours heavier members",
+			strategy: weightedRoundRobin{},
+			members:  []*domain.Member{member(1, 75, 0), member(2, 25, 0)},
+			history:  history{counts: map[int64]int{1: 2, 2: 1}},
+			want:     1,
+		},
+		{
+			name:     "weighted round robin breaks ties by member order",
+			strategy: weightedRoundRobin{},
+			members:  []*domain.Member{member(2, 50, 0), member(1, 50, 0)},
+			history:  history{counts: map[int64]int{}},
+			want:     2,
+		},
+		{
+			name:     "strict rotation takes the next member after the cursor",
+			strategy: strictRotation{},
+			members:  []*domain.Member{member(3, 1, 0), member(1, 1, 0), member(2, 1, 0)},
+			history:  history{lastRotation: 1},
+			want:     2,
+		},
+		{
+			name:     "strict rotation wraps around",
+			strategy: strictRotation{},
+			members:  []*domain.Member{member(1, 1, 0), member(2, 1, 0), member(3, 1, 0)},
+			history:  history{lastRotation: 3},
+			want:     1,
+		},
+		{
+			name:     "strict rotation starts with the lowest ID",
+			strategy: strictRotation{},
+			members:  []*domain.Member{member(2, 1, 0), member(1, 1, 0)},
+			history:  history{},
+			want:     1,
+		},
+		{
+			name:     "strict rotation skips a member who left",
+			strategy: strictRotation{},
+			members:  []*domain.Member{member(1, 1, 0), member(3, 1, 0)},
+			history:  history{lastRotation: 2},
+			want:     3,
+		},
+		{
+			name:     "least open load picks the member with the fewest open assignments",
+			strategy: &leastOpenLoad{},
+			members:  []*domain.Member{member(1, 75, 3), member(2, 25, 2)},
+			want:     2,
+		},
+		{
+			name:     "weighted least open load divides by weight",
+			strategy: &leastOpenLoad{params: LeastOpenLoadParams{Weighted: true}},
+			members:  []*domain.Member{member(1, 75, 3), member(2, 25, 2)},
+			want:     1,
+		},
+		{
+			name:     "least recently assigned prefers members never assigned",
+			strategy: leastRecentlyAssigned{},
+			members:  []*domain.Member{member(1, 1, 0), member(2, 1, 0), member(3, 1, 0)},
+			history:  history{lastAssigned: map[int64]time.Time{1: hoursAgo(5), 3: hoursAgo(1)}},
+			want:     2,
+		},
+		{
+			name:     "least recently assigned picks the longest wait",
+			strategy: leastRecentlyAssigned{},
+			members:  []*domain.Member{member(1, 1, 0), member(2, 1, 0), member(3, 1, 0)},
+			history:  history{lastAssigned: map[int64]time.Time{1: hoursAgo(2), 2: hoursAgo(6), 3: hoursAgo(4)}},
+			want:     2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			picked, err := tt.strategy.Pick(context.Background(), Input{Members: tt.members, History: tt.history})
+
+			require.NoError(t, err)
+			require.NotNil(t, picked)
+			assert.Equal(t, tt.want, picked.ID)
+		})
+	}
+}
+
+func TestWeightedRandom_Pick(t *testing.T) {
+	tests := []struct {
+		name    string
+		members []*domain.Member
+		random  float64
+		want    int64
+	}{
+		{name: "low draw lands on the first weight", members: []*domain.Member{member(1, 25, 0), member(2, 75, 0)}, random: 0.1, want: 1},
+		{name: "high draw lands on the second weight", members: []*domain.Member{member(1, 25, 0), member(2, 75, 0)}, random: 0.5, want: 2},
+		{name: "zero weights are never drawn", members: []*domain.Member{member(1, 0, 0), member(2, 10, 0)}, random: 0, want: 2},
+		{name: "all zero weights are drawn uniformly", members: []*domain.Member{member(1, 0, 0), member(2, 0, 0)}, random: 0.6, want: 2},
+		{name: "rounding at the top falls back to the last weighted member", members: []*domain.Member{member(1, 10, 0), member(2, 10, 0), member(3, 0, 0)}, random: 1, want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &weightedRandom{random: func() float64 { return tt.random }}
+
+			picked, err := s.Pick(context.Background(), Input{Members: tt.members})
+
+			require.NoError(t, err)
+			require.NotNil(t, picked)
+			assert.Equal(t, tt.want, picked.ID)
+		})
+	}
+}
+
+func TestStrategies_PickWithoutMembers(t *testing.T) {
+	for _, s := range []Strategy{weightedRoundRobin{}, strictRotation{}, &leastOpenLoad{}, &weightedRandom{random: func() float64 { return 0 }}, leastRecentlyAssigned{}} {
+		picked, err := s.Pick(context.Background(), Input{History: history{}})
+		assert.NoError(t, err)
+		assert.Nil(t, picked)
+	}
+}
+
+func TestRegistry_ForGroup(t *testing.T) {
+	registry := DefaultRegistry()
+
+	settings := `{"strategy":"least_open_load","strategy_params":{"weighted":true}}`
+	s, err := registry.ForGroup(&domain.Group{Strategy: domain.StrategyStrictRotation, Settings: &settings})
+	require.NoError(t, err)
+	assert.Equal(t, &leastOpenLoad{params: LeastOpenLoadParams{Weighted: true}}, s)
+
+	s, err = registry.ForGroup(&domain.Group{})
+	require.NoError(t, err)
+	assert.Equal(t, weightedRoundRobin{}, s)
+
+	_, err = registry.Build(domain.StrategyLeastOpenLoad, json.RawMessage(`{"unknown":true}`))
+	assert.ErrorIs(t, err, ErrInvalidParams)
+
+	_, err = registry.Build("fastest_typist", nil)
+	assert.ErrorIs(t, err, ErrUnknownStrategy)
+}
@@ -0,0 +1,128 @@
+package strategy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/raufhm/fairflow/shared/domain"
+)
+
+var (
+	ErrUnknownStrategy = errors.New("unknown assignment strategy")
+	ErrInvalidParams   = errors.New("invalid assignment strategy parameters")
+)
+
+// History gives strategies read access to a group's past assignments
+type History interface {
+	// AssignmentCounts returns the number of assignments per member
+	AssignmentCounts(ctx context.Context, memberIDs []int64) (map[int64]int, error)
+	// LastAssignedAt returns when each member last received an assignment.
+	// Members who were never assigned are absent from the map.
+	LastAssignedAt(ctx context.Context, memberIDs []int64) (map[int64]time.Time, error)
+	// LastRotationMemberID returns the member the rotation cursor points at, or 0
+	LastRotationMemberID(ctx context.Context) (int64, error)
+}
+
+// Input is everything a strategy may look at to pick a member
+type Input struct {
+	Group *domain.Group
+	// Members are the active members with capacity left, ordered by creation
+	Members []*domain.Member
+	History History
+}
+
+// Strategy picks the member who receives the next assignment
+type Strategy interface {
+	Pick(ctx context.Context, input Input) (*domain.Member, error)
+}
+
+// Factory builds a strategy from the parameters stored in the group's settings.
+// params is nil when the group has none.
+type Factory func(params json.RawMessage) (Strategy, error)
+
+// Registry maps strategy names to their factories
+type Registry struct {
+	factories map[domain.AssignmentStrategy]Factory
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{factories: make(map[domain.AssignmentStrategy]Factory)}
+}
+
+// DefaultRegistry creates a registry with every built-in strategy
+func DefaultRegistry() *Registry {
+	registry := NewRegistry()
+	registry.Register(domain.StrategyWeightedRoundRobin, NewWeightedRoundRobin)
+	registry.Register(domain.StrategyStrictRotation, NewStrictRotation)
+	registry.Register(domain.StrategyLeastOpenLoad, NewLeastOpenLoad)
+	registry.Register(domain.StrategyWeightedRandom, NewWeightedRandom)
+	registry.Register(domain.StrategyLeastRecentlyAssigned, NewLeastRecentlyAssigned)
+	return registry
+}
+
+// Register adds or replaces a strategy
+func (r *Registry) Register(name domain.AssignmentStrategy, factory Factory) {
+	r.factories[name] = factory
+}
+
+// Has reports whether a strategy is registered under name
+func (r *Registry) Has(name domain.AssignmentStrategy) bool {
+	_, ok := r.factories[name]
+	return ok
+}
+
+// Build creates the named strategy with the given parameters
+func (r *Registry) Build(name domain.AssignmentStrategy, params json.RawMessage) (Strategy, error) {
+	factory, ok := r.factories[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownStrategy, name)
+	}
+	return factory(params)
+}
+
+// ForGroup creates the strategy a group is configured with. The strategy named
+// in the group's settings takes precedence over the group's strategy column.
+func (r *Registry) ForGroup(group *domain.Group) (Strategy, error) {
+	settings, err := group.ParseSettings()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidParams, err)
+	}
+
+	name := group.Strategy
+	if settings.Strategy != "" {
+		name = settings.Strategy
+	}
+	if name == "" {
+		name = domain.StrategyWeightedRoundRobin
+	}
+
+	return r.Build(name, settings.StrategyParams)
+}
+
+// decodeParams strictly decodes strategy parameters into dst. Missing
+// parameters leave dst untouched.
+func decodeParams(params json.RawMessage, dst any) error {
+	if len(params) == 0 || string(params) == "null" {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(params))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidParams, err)
+	}
+	return nil
+}
+
+// memberIDs returns the IDs of members in order
+func memberIDs(members []*domain.Member) []int64 {
+	ids := make([]int64, len(members))
+	for i, m := range members {
+		ids[i] = m.ID
+	}
+	return ids
+}
@@ -0,0 +1,8 @@
+package webhook
+
+// Event types delivered to subscribed webhooks
+const (
+	EventMemberAvailabilityChanged = "member.availability_changed"
+	EventMemberTimeOffScheduled    = "member.time_off_scheduled"
+	EventMemberTimeOffCancelled    = "member.time_off_cancelled"
+)